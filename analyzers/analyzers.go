@@ -0,0 +1,112 @@
+// Package analyzers is the registry of standards analyzers run by stdcheck.
+package analyzers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/apistability"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/confighygiene"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/coverageignore"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/envaccess"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/factorydecisions"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/factorylogic"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/godoc"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/nildeps"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/primaryctor"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/testfactory"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/testlayout"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/wiringmix"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"golang.org/x/tools/go/analysis"
+)
+
+// All lists every standards analyzer, sorted by name. Keep the rule names in
+// config/schema.json in sync.
+var All = []*analysis.Analyzer{
+	apistability.Analyzer,
+	confighygiene.Analyzer,
+	coverageignore.Analyzer,
+	envaccess.Analyzer,
+	factorydecisions.Analyzer,
+	factorylogic.Analyzer,
+	godoc.Analyzer,
+	nildeps.Analyzer,
+	primaryctor.Analyzer,
+	testfactory.Analyzer,
+	testlayout.Analyzer,
+	wiringmix.Analyzer,
+}
+
+// syntactic names the analyzers that need only the syntax of a package's
+// files, not type information, so they can run without loading packages.
+var syntactic = map[string]bool{
+	"godoc": true,
+}
+
+// Syntactic reports whether a needs only syntax trees: its Pass may carry an
+// empty TypesInfo and a package with no scope contents.
+func Syntactic(a *analysis.Analyzer) bool {
+	return syntactic[a.Name]
+}
+
+// inputFlags names, for the analyzers that read files besides the package
+// sources, the flag holding the file's path.
+var inputFlags = map[string]string{
+	"apistability": "baseline",
+}
+
+// Inputs returns the paths of the files a reads besides the package sources,
+// such as a recorded baseline. Its results depend on their content too.
+func Inputs(a *analysis.Analyzer) []string {
+	name, ok := inputFlags[a.Name]
+	if !ok {
+		return nil
+	}
+	if f := a.Flags.Lookup(name); f != nil && f.Value.String() != "" {
+		return []string{f.Value.String()}
+	}
+	return nil
+}
+
+// Select returns the analyzers named in names, in registry order. An empty
+// list selects all analyzers.
+func Select(names []string) ([]*analysis.Analyzer, error) {
+	if len(names) == 0 {
+		return All, nil
+	}
+	known := make(map[string]bool, len(All))
+	for _, a := range All {
+		known[a.Name] = true
+	}
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if !known[n] {
+			return nil, fmt.Errorf("unknown rule %q", n)
+		}
+		want[n] = true
+	}
+	var selected []*analysis.Analyzer
+	for _, a := range All {
+		if want[a.Name] {
+			selected = append(selected, a)
+		}
+	}
+	return selected, nil
+}
+
+// Rules describes the analyzers as report rules. The summary is the first
+// line of each analyzer's Doc and the help text is the rest.
+func Rules(as []*analysis.Analyzer) []report.Rule {
+	rules := make([]report.Rule, 0, len(as))
+	for _, a := range as {
+		summary, help, _ := strings.Cut(a.Doc, "\n")
+		help = strings.TrimSpace(help)
+		if help == "" {
+			help = summary
+		}
+		rules = append(rules, report.Rule{ID: a.Name, Summary: summary, Help: help})
+	}
+	return rules
+}
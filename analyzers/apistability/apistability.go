@@ -0,0 +1,112 @@
+// Package apistability defines an Analyzer that reports breaking changes to
+// a package's dependency-injection surface relative to a recorded API
+// baseline.
+//
+// Other teams wire against a package's constructors, its interfaces and its
+// container's accessors. Changing one silently breaks their builds, or
+// their mocks, the next time they update. The baseline (see package api)
+// records the surface that was agreed on; a break must either be undone or
+// recorded deliberately by re-recording the baseline.
+package apistability
+
+import (
+	"errors"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/api"
+	"golang.org/x/tools/go/analysis"
+)
+
+const Doc = `report breaking changes to exported constructors, interfaces and container accessors
+
+The surface of each package recorded in the API baseline (-baseline,
+default .stdcheck-api.json) is compared with its current surface. Removing
+or changing the signature of a New* constructor or of a method of a
+*Container type breaks its callers; adding, removing or changing a method
+of an exported interface breaks its callers or its implementations. Each
+such change is reported. Additions are compatible and not reported. Without
+a baseline file nothing is reported. Restore the old signature, or record
+the break with stdcheck api baseline.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "apistability",
+	Doc:  Doc,
+	Run:  run,
+}
+
+var baselinePath string
+
+func init() {
+	Analyzer.Flags.StringVar(&baselinePath, "baseline", api.DefaultPath, "API baseline `file`")
+}
+
+// baselines memoizes the baselines read, by path; packages are analyzed
+// concurrently.
+var baselines struct {
+	sync.Mutex
+	byPath map[string]*api.Baseline
+}
+
+// baseline returns the baseline at path, or nil if there is none.
+func baseline(path string) (*api.Baseline, error) {
+	baselines.Lock()
+	defer baselines.Unlock()
+	if b, ok := baselines.byPath[path]; ok {
+		return b, nil
+	}
+	b, err := api.ReadBaseline(path)
+	if errors.Is(err, os.ErrNotExist) {
+		b, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if baselines.byPath == nil {
+		baselines.byPath = make(map[string]*api.Baseline)
+	}
+	baselines.byPath[path] = b
+	return b, nil
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	b, err := baseline(baselinePath)
+	if err != nil || b == nil {
+		return nil, err
+	}
+	recorded, ok := b.Lookup(pass.Pkg.Path())
+	if !ok {
+		return nil, nil
+	}
+	for _, c := range api.Compare(recorded, api.Extract(pass.Fset, pass.Pkg)) {
+		if !c.Breaking {
+			continue
+		}
+		pass.Reportf(position(pass, c), "breaking API change: %s; restore it, or record the break with stdcheck api baseline", c)
+	}
+	return nil, nil
+}
+
+// position returns where to report c: the declaration of the symbol or, for
+// a removed method, of its type if they still exist, or else the package
+// clause of the package's first non-test file.
+func position(pass *analysis.Pass, c api.Change) token.Pos {
+	typeName, member, _ := strings.Cut(c.Name, ".")
+	if obj := pass.Pkg.Scope().Lookup(typeName); obj != nil && (member != "" || !c.Removed) {
+		if member != "" && !c.Removed {
+			if m, _, _ := types.LookupFieldOrMethod(obj.Type(), true, pass.Pkg, member); m != nil {
+				return m.Pos()
+			}
+		}
+		return obj.Pos()
+	}
+	for _, f := range pass.Files {
+		if !strings.HasSuffix(pass.Fset.File(f.Pos()).Name(), "_test.go") {
+			return f.Name.Pos()
+		}
+	}
+	return pass.Files[0].Name.Pos()
+}
@@ -0,0 +1,16 @@
+package apistability
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_Run(t *testing.T) {
+	testdata := analysistest.TestData()
+	if err := Analyzer.Flags.Set("baseline", filepath.Join(testdata, "api.json")); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, testdata, Analyzer, "store")
+}
@@ -0,0 +1,21 @@
+package store // want `breaking API change: constructor NewCache func\(\) \*Cache was removed`
+
+type Store struct{}
+
+type Cache struct{}
+
+// NewStore gained a parameter since the baseline was recorded.
+func NewStore(dsn string, size int) *Store { return &Store{} } // want `breaking API change: constructor NewStore changed from func\(string\) \*Store to func\(string, int\) \*Store`
+
+// NewIndex is new, which is compatible.
+func NewIndex() *Cache { return &Cache{} }
+
+type Reader interface { // want `breaking API change: interface method Reader.Close func\(\) error was removed`
+	Read(key string) ([]byte, error)
+	Keys() []string // want `breaking API change: interface method Reader.Keys func\(\) \[\]string was added`
+}
+
+type StoreContainer struct{}
+
+// Store is unchanged.
+func (c *StoreContainer) Store() *Store { return nil }
@@ -0,0 +1,271 @@
+// Package confighygiene defines an Analyzer that keeps configuration types
+// plain, loaded and read-only.
+//
+// A Config is data: it is loaded once, handed to the config layer to make
+// decisions, and then read by the factories. Live dependencies hidden in it
+// bypass the primary constructor; fields the loader never fills are silently
+// zero; and writes after construction make behavior depend on call order.
+package confighygiene
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const Doc = `check that configuration types hold plain, loaded, read-only values
+
+Types whose name ends in Config are configuration. Their fields must be plain
+values: no interfaces (other than any), channels, funcs, or live clients and
+connections (types named *Client, *Conn, *DB, *Pool, *Session or *Tx), which
+must be constructor dependencies instead. When a Config is loaded through
+struct tags (yaml, json, env, ...), every field a production factory or
+wiring helper reads must carry a tag, or the loader never fills it. Config
+fields must not be assigned outside the functions that build the Config
+(those returning it) and tests.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "confighygiene",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// loaderTags are the struct tag keys of common configuration loaders.
+var loaderTags = []string{"yaml", "json", "toml", "env", "envconfig", "mapstructure", "koanf", "hcl"}
+
+// liveSuffixes end the names of types that hold connections or clients.
+var liveSuffixes = []string{"Client", "Conn", "DB", "Pool", "Session", "Tx"}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.TypeSpec)(nil), (*ast.FuncDecl)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		switch n := n.(type) {
+		case *ast.TypeSpec:
+			if st, ok := n.Type.(*ast.StructType); ok && isConfigName(n.Name.Name) {
+				checkFields(pass, n.Name.Name, st)
+			}
+		case *ast.FuncDecl:
+			if n.Body == nil {
+				return
+			}
+			if factory.IsProduction(n) || factory.IsWiringHelper(n) {
+				checkReads(pass, n)
+			}
+			if !strings.HasSuffix(pass.Fset.File(n.Pos()).Name(), "_test.go") && !buildsConfig(pass, n) {
+				checkWrites(pass, n)
+			}
+		}
+	})
+	return nil, nil
+}
+
+func isConfigName(name string) bool {
+	return strings.HasSuffix(name, "Config")
+}
+
+func isConfig(t types.Type) bool {
+	return isConfigName(factory.TypeNameOf(t))
+}
+
+// checkFields reports the fields of the Config struct st that are not plain
+// values.
+func checkFields(pass *analysis.Pass, typeName string, st *ast.StructType) {
+	for _, field := range st.Fields.List {
+		t := pass.TypesInfo.TypeOf(field.Type)
+		if t == nil {
+			continue
+		}
+		if why := notPlain(t, make(map[types.Type]bool)); why != "" {
+			name := types.ExprString(field.Type)
+			if len(field.Names) > 0 {
+				name = field.Names[0].Name
+			}
+			pass.Reportf(field.Pos(), "%s.%s is %s; configuration holds plain values, so inject it as a constructor dependency instead", typeName, name, why)
+		}
+	}
+}
+
+// notPlain describes why t is not a plain value, or returns "".
+func notPlain(t types.Type, seen map[types.Type]bool) string {
+	if seen[t] {
+		return ""
+	}
+	seen[t] = true
+	if n, ok := types.Unalias(t).(*types.Named); ok {
+		for _, suffix := range liveSuffixes {
+			if strings.HasSuffix(n.Obj().Name(), suffix) {
+				return fmt.Sprintf("a live %s", n.Obj().Name())
+			}
+		}
+		if isConfigName(n.Obj().Name()) {
+			return "" // checked where it is declared
+		}
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Interface:
+		if !u.Empty() {
+			return "an interface"
+		}
+	case *types.Chan:
+		return "a channel"
+	case *types.Signature:
+		return "a func"
+	case *types.Pointer:
+		return notPlain(u.Elem(), seen)
+	case *types.Slice:
+		return notPlain(u.Elem(), seen)
+	case *types.Array:
+		return notPlain(u.Elem(), seen)
+	case *types.Map:
+		if why := notPlain(u.Key(), seen); why != "" {
+			return why
+		}
+		return notPlain(u.Elem(), seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if why := notPlain(u.Field(i).Type(), seen); why != "" {
+				return why
+			}
+		}
+	}
+	return ""
+}
+
+// checkReads reports Config fields read by the wiring function fn that the
+// Config's loader never fills: untagged fields of a struct whose other
+// fields are tagged.
+func checkReads(pass *analysis.Pass, fn *ast.FuncDecl) {
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		s := pass.TypesInfo.Selections[sel]
+		if s == nil || s.Kind() != types.FieldVal || len(s.Index()) != 1 || !isConfig(s.Recv()) {
+			return true
+		}
+		st, ok := derefStruct(s.Recv())
+		if !ok || !tagged(st) {
+			return true
+		}
+		i := s.Index()[0]
+		if !st.Field(i).Embedded() && loaderTag(st.Tag(i)) == "" {
+			pass.Reportf(sel.Sel.Pos(), "%s reads %s, which has no loader tag (%s) unlike the other fields of %s, so it is never loaded", fn.Name.Name, types.ExprString(sel), strings.Join(tagKeys(st), ", "), factory.TypeNameOf(s.Recv()))
+		}
+		return true
+	})
+}
+
+func derefStruct(t types.Type) (*types.Struct, bool) {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	return st, ok
+}
+
+// tagged reports whether any field of st carries a loader tag.
+func tagged(st *types.Struct) bool {
+	return len(tagKeys(st)) > 0
+}
+
+// tagKeys returns the loader tag keys used by the fields of st.
+func tagKeys(st *types.Struct) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for i := 0; i < st.NumFields(); i++ {
+		if key := loaderTag(st.Tag(i)); key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// loaderTag returns the first loader key present in tag, or "". A tag of
+// "-" excludes the field from loading and does not count.
+func loaderTag(tag string) string {
+	for _, key := range loaderTags {
+		if v, ok := reflect.StructTag(tag).Lookup(key); ok && v != "-" {
+			return key
+		}
+	}
+	return ""
+}
+
+// buildsConfig reports whether fn returns a Config, and so may set its
+// fields while building it.
+func buildsConfig(pass *analysis.Pass, fn *ast.FuncDecl) bool {
+	obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return false
+	}
+	results := obj.Type().(*types.Signature).Results()
+	for i := 0; i < results.Len(); i++ {
+		if isConfig(results.At(i).Type()) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWrites reports assignments to Config fields in fn.
+func checkWrites(pass *analysis.Pass, fn *ast.FuncDecl) {
+	report := func(lhs ast.Expr) {
+		if sel := configFieldOf(pass.TypesInfo, lhs); sel != nil {
+			pass.Reportf(lhs.Pos(), "%s assigns %s; configuration is read-only once built, so derive a new value instead", fn.Name.Name, types.ExprString(sel))
+		}
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// A closure returning a Config builds one, like a function.
+			if sig, ok := pass.TypesInfo.TypeOf(n).(*types.Signature); ok {
+				for i := 0; i < sig.Results().Len(); i++ {
+					if isConfig(sig.Results().At(i).Type()) {
+						return false
+					}
+				}
+			}
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				report(lhs)
+			}
+		case *ast.IncDecStmt:
+			report(n.X)
+		}
+		return true
+	})
+}
+
+// configFieldOf returns the selector of the Config field that assigning to
+// expr writes, looking through indexing and dereferences, or nil.
+func configFieldOf(info *types.Info, expr ast.Expr) *ast.SelectorExpr {
+	for {
+		switch e := expr.(type) {
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			if s := info.Selections[e]; s != nil && s.Kind() == types.FieldVal && isConfig(s.Recv()) {
+				return e
+			}
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
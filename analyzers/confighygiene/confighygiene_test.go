@@ -0,0 +1,11 @@
+package confighygiene
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_Run(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "cfg")
+}
@@ -0,0 +1,54 @@
+package cfg
+
+import "io"
+
+type HTTPClient struct{}
+
+type ServerConfig struct {
+	Addr    string `yaml:"addr"`
+	Timeout int    `yaml:"timeout"`
+	Region  string
+	Tags    map[string][]string `yaml:"tags"`
+	Limits  LimitsConfig        `yaml:"limits"`
+	Out     io.Writer           // want `ServerConfig.Out is an interface; configuration holds plain values`
+	Client  *HTTPClient         // want `ServerConfig.Client is a live HTTPClient; configuration holds plain values`
+	Done    chan struct{}       // want `ServerConfig.Done is a channel; configuration holds plain values`
+	Hooks   []func()            // want `ServerConfig.Hooks is a func; configuration holds plain values`
+	Extra   any                 `yaml:"extra"`
+}
+
+type LimitsConfig struct {
+	Max int `yaml:"max"`
+}
+
+// Server is not configuration; it may hold anything.
+type Server struct {
+	Out    io.Writer
+	Client *HTTPClient
+}
+
+// LoadServerConfig builds the Config, so it may set its fields.
+func LoadServerConfig() ServerConfig {
+	var c ServerConfig
+	c.Addr = ":8080"
+	c.Limits.Max = 10
+	return c
+}
+
+func NewServerForProduction(c ServerConfig) *Server {
+	_ = c.Addr
+	_ = c.Region // want `NewServerForProduction reads c.Region, which has no loader tag \(yaml\) unlike the other fields of ServerConfig, so it is never loaded`
+	return &Server{}
+}
+
+func tune(c *ServerConfig) {
+	c.Timeout = 30    // want `tune assigns c.Timeout; configuration is read-only once built`
+	c.Timeout++       // want `tune assigns c.Timeout; configuration is read-only once built`
+	c.Tags["a"] = nil // want `tune assigns c.Tags; configuration is read-only once built`
+	build := func() ServerConfig {
+		var d ServerConfig
+		d.Addr = ":9090"
+		return d
+	}
+	_ = build
+}
@@ -0,0 +1,101 @@
+// Package coverageignore defines an Analyzer that checks the placement of
+// coverage:ignore markers.
+//
+// Production factories and container wiring are infrastructure glue and must
+// be excluded from coverage with a "// coverage:ignore" comment. The marker
+// must never hide business logic, which would otherwise silently drop out of
+// the coverage numbers.
+package coverageignore
+
+import (
+	"fmt"
+	"go/ast"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const Doc = `check coverage:ignore markers on wiring code
+
+Every production factory (New<Type>ForProduction) and DI wiring helper
+(New*Container functions, init* methods on Container) must carry a
+"// coverage:ignore" doc comment line. Conversely, a function marked
+coverage:ignore must not contain business logic: repository calls or
+conditionals on data values.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "coverageignore",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		fn := n.(*ast.FuncDecl)
+		marked := factory.HasCoverageIgnore(fn)
+		switch {
+		case !marked && factory.IsProduction(fn):
+			reportMissing(pass, fn, "production factory")
+		case !marked && factory.IsWiringHelper(fn):
+			reportMissing(pass, fn, "wiring helper")
+		case marked && fn.Body != nil:
+			if reason := businessLogic(pass, fn); reason != "" {
+				pass.Reportf(fn.Name.Pos(), "%s is marked %s but contains business logic (%s); it must stay covered", fn.Name.Name, factory.CoverageIgnore, reason)
+			}
+		}
+	})
+	return nil, nil
+}
+
+// reportMissing reports fn as missing its marker, with a fix that appends the
+// marker to the doc comment (or adds one). No fix is offered while fn still
+// contains business logic, since marking it would hide that logic.
+func reportMissing(pass *analysis.Pass, fn *ast.FuncDecl, kind string) {
+	marker := "// " + factory.CoverageIgnore
+	d := analysis.Diagnostic{
+		Pos:     fn.Name.Pos(),
+		Message: fmt.Sprintf("%s %s is missing %s marker", kind, fn.Name.Name, marker),
+	}
+	if businessLogic(pass, fn) == "" {
+		edit := analysis.TextEdit{Pos: fn.Pos(), End: fn.Pos(), NewText: []byte(marker + "\n")}
+		if fn.Doc != nil {
+			edit = analysis.TextEdit{Pos: fn.Doc.End(), End: fn.Doc.End(), NewText: []byte("\n" + marker)}
+		}
+		d.SuggestedFixes = []analysis.SuggestedFix{{
+			Message:   "Add " + marker,
+			TextEdits: []analysis.TextEdit{edit},
+		}}
+	}
+	pass.Report(d)
+}
+
+// businessLogic describes the first piece of business logic found in fn, or
+// returns "" if there is none.
+func businessLogic(pass *analysis.Pass, fn *ast.FuncDecl) string {
+	var reason string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if reason != "" {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			if m := factory.RepositoryMethod(pass.TypesInfo, n); m != "" {
+				reason = "repository call " + m
+			}
+		case *ast.IfStmt:
+			if v := factory.DataValue(pass.TypesInfo, fn, n.Cond); v != nil {
+				reason = "branch on " + v.Name()
+			}
+		}
+		return true
+	})
+	return reason
+}
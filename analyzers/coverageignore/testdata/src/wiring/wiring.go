@@ -0,0 +1,53 @@
+package wiring
+
+type UserRepository struct{}
+
+func (r *UserRepository) Count() int { return 0 }
+
+type Service struct{ repo *UserRepository }
+
+func NewService(repo *UserRepository) *Service { return &Service{repo: repo} }
+
+// NewServiceForProduction wires the service.
+// coverage:ignore
+func NewServiceForProduction() *Service {
+	return NewService(&UserRepository{})
+}
+
+// NewCacheForProduction wires the cache.
+func NewCacheForProduction() *Service { // want `production factory NewCacheForProduction is missing // coverage:ignore marker`
+	return NewService(&UserRepository{})
+}
+
+func NewAppContainer() *Container { // want `wiring helper NewAppContainer is missing // coverage:ignore marker`
+	return &Container{}
+}
+
+type Container struct{ svc *Service }
+
+func (c *Container) initService() { // want `wiring helper initService is missing // coverage:ignore marker`
+	c.svc = NewService(&UserRepository{})
+}
+
+// NewQueueForProduction counts users, so it gets no fix.
+func NewQueueForProduction(repo *UserRepository) *Service { // want `production factory NewQueueForProduction is missing // coverage:ignore marker`
+	if repo.Count() > 0 {
+		return nil
+	}
+	return NewService(repo)
+}
+
+// Sized hides a branch on its argument.
+// coverage:ignore
+func Sized(n int) *Service { // want `Sized is marked coverage:ignore but contains business logic \(branch on n\); it must stay covered`
+	if n > 10 {
+		return nil
+	}
+	return &Service{}
+}
+
+// Counted hides a repository call.
+// coverage:ignore
+func Counted(repo *UserRepository) int { // want `Counted is marked coverage:ignore but contains business logic \(repository call Count\); it must stay covered`
+	return repo.Count()
+}
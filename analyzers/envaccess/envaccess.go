@@ -0,0 +1,169 @@
+// Package envaccess defines an Analyzer that reports environment variable
+// reads in services, factories and repositories.
+//
+// Services, factories and repositories that read the environment directly
+// depend on process state no test controls and no Config documents. The
+// standards route every setting through the config loader, which reads the
+// environment once into a Config the rest of the code receives. Commands
+// and other code outside those roles are not checked: a main package reads
+// its own flags and environment.
+package envaccess
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+)
+
+const Doc = `report environment variable reads in services, factories and repositories
+
+Calls to os.Getenv, os.LookupEnv and os.Environ (or references to them) are
+reported in:
+
+  - methods of types named *Service, *Repository or *Repo;
+  - constructors and factories: top-level functions named New*;
+  - any code in a package named service(s), repository, repositories or
+    persistence (or beneath a directory of that name).
+
+The config layer is exempt: packages named config, configuration or
+settings (or beneath a directory of that name), and functions that return a
+Config type, such as LoadConfig. Main packages and test files are not
+checked. Read the variable in the config loader and pass its value down in
+the Config.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "envaccess",
+	Doc:  Doc,
+	Run:  run,
+}
+
+// envFuncs are the functions of package os that read the environment.
+var envFuncs = map[string]bool{
+	"Getenv":    true,
+	"LookupEnv": true,
+	"Environ":   true,
+}
+
+// roleDirs name the packages and directories whose code is all services or
+// repositories.
+var roleDirs = map[string]bool{
+	"service":      true,
+	"services":     true,
+	"repository":   true,
+	"repositories": true,
+	"persistence":  true,
+}
+
+// configDirs name the packages and directories of the config layer.
+var configDirs = map[string]bool{
+	"config":        true,
+	"configuration": true,
+	"settings":      true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if pass.Pkg.Name() == "main" || inDirs(pass.Pkg, configDirs) {
+		return nil, nil
+	}
+	rolePackage := inDirs(pass.Pkg, roleDirs)
+	for _, file := range pass.Files {
+		if strings.HasSuffix(pass.Fset.File(file.Pos()).Name(), "_test.go") {
+			continue
+		}
+		for _, decl := range file.Decls {
+			where := "package initialization"
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				if loadsConfig(pass, fn) {
+					continue
+				}
+				if !rolePackage && !hasRole(fn) {
+					continue
+				}
+				where = fn.Name.Name
+			} else if !rolePackage {
+				continue
+			}
+			checkDecl(pass, decl, where)
+		}
+	}
+	return nil, nil
+}
+
+// checkDecl reports the references to environment functions in the
+// top-level declaration decl, described as where in messages.
+func checkDecl(pass *analysis.Pass, decl ast.Decl, where string) {
+	ast.Inspect(decl, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if f, ok := pass.TypesInfo.Uses[sel.Sel].(*types.Func); ok && f.Pkg() != nil && f.Pkg().Path() == "os" && envFuncs[f.Name()] {
+			pass.Reportf(sel.Pos(), "%s reads the environment with os.%s; read it in the config loader and pass the value in a Config", where, f.Name())
+		}
+		return true
+	})
+}
+
+// inDirs reports whether pkg is named, or lies beneath a directory named, by
+// one of dirs.
+func inDirs(pkg *types.Package, dirs map[string]bool) bool {
+	if dirs[pkg.Name()] {
+		return true
+	}
+	for _, elem := range strings.Split(pkg.Path(), "/") {
+		if dirs[elem] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRole reports whether fn is a service or repository method, or a
+// constructor or factory.
+func hasRole(fn *ast.FuncDecl) bool {
+	if fn.Recv == nil {
+		return strings.HasPrefix(fn.Name.Name, "New")
+	}
+	if len(fn.Recv.List) == 0 {
+		return false
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if idx, ok := recv.(*ast.IndexExpr); ok {
+		recv = idx.X
+	}
+	if idx, ok := recv.(*ast.IndexListExpr); ok {
+		recv = idx.X
+	}
+	id, ok := recv.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	for _, suffix := range []string{"Service", "Repository", "Repo"} {
+		if strings.HasSuffix(id.Name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadsConfig reports whether fn returns a Config type, and so is a config
+// loader.
+func loadsConfig(pass *analysis.Pass, fn *ast.FuncDecl) bool {
+	obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return false
+	}
+	results := obj.Type().(*types.Signature).Results()
+	for i := 0; i < results.Len(); i++ {
+		if strings.HasSuffix(factory.TypeNameOf(results.At(i).Type()), "Config") {
+			return true
+		}
+	}
+	return false
+}
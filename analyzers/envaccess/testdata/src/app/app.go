@@ -0,0 +1,30 @@
+package app
+
+import "os"
+
+type UserService struct{ region string }
+
+type Config struct{ Region string }
+
+func NewUserService() *UserService {
+	return &UserService{region: os.Getenv("REGION")} // want `NewUserService reads the environment with os.Getenv; read it in the config loader and pass the value in a Config`
+}
+
+func (s *UserService) Region() string {
+	if r, ok := os.LookupEnv("REGION"); ok { // want `Region reads the environment with os.LookupEnv`
+		return r
+	}
+	return s.region
+}
+
+// LoadConfig is the config loader, which may read the environment.
+func LoadConfig() Config {
+	return Config{Region: os.Getenv("REGION")}
+}
+
+// helper has no role, so it is not checked.
+func helper() []string {
+	return os.Environ()
+}
+
+var lookup = os.Getenv
@@ -0,0 +1,7 @@
+package config
+
+import "os"
+
+func NewLoader() string {
+	return os.Getenv("REGION")
+}
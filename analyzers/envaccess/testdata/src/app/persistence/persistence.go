@@ -0,0 +1,9 @@
+package persistence
+
+import "os"
+
+var dsn = os.Getenv("DSN") // want `package initialization reads the environment with os.Getenv`
+
+func open() []string {
+	return os.Environ() // want `open reads the environment with os.Environ`
+}
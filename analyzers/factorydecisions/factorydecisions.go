@@ -0,0 +1,267 @@
+// Package factorydecisions defines an Analyzer that reports decisions and
+// computation inside production factories.
+//
+// It complements factorylogic with the remaining factory violations from
+// docs/prompts/standards-compliance: configuration decisions (Violation 3),
+// calculations (Violation 4), loops and switches, and calls to external
+// services such as health checks.
+package factorydecisions
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `report decisions and computation inside production factories
+
+Inside New<Type>ForProduction functions this analyzer reports:
+
+  - conditionals on Config fields (if cfg.StrictMode)
+  - for and range loops
+  - switch and type switch statements
+  - calls to network or API clients (client.HealthCheck(), http.Get)
+  - arithmetic on non-constant values (timeout * 2)
+
+Configuration decisions belong in the config layer, where they can be unit
+tested; the factory should receive their results as plain values.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "factorydecisions",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+	// Parameter types that don't resolve fall back to their names as
+	// written, so the analyzer is useful on code that doesn't compile yet.
+	RunDespiteErrors: true,
+}
+
+// networkFuncs lists, by package path, the top-level functions that perform
+// network I/O when called. Other functions of these packages, such as
+// http.StripPrefix or net.JoinHostPort, only build values.
+var networkFuncs = map[string]map[string]bool{
+	"net": {
+		"Dial": true, "DialTimeout": true, "DialIP": true, "DialTCP": true,
+		"DialUDP": true, "DialUnix": true,
+
+		"Listen": true, "ListenPacket": true, "ListenIP": true, "ListenTCP": true,
+		"ListenUDP": true, "ListenMulticastUDP": true, "ListenUnix": true,
+		"ListenUnixgram": true,
+
+		"LookupAddr": true, "LookupCNAME": true, "LookupHost": true,
+		"LookupIP": true, "LookupMX": true, "LookupNS": true, "LookupPort": true,
+		"LookupSRV": true, "LookupTXT": true,
+	},
+	"net/http": {
+		"Get": true, "Head": true, "Post": true, "PostForm": true,
+		"ListenAndServe": true, "ListenAndServeTLS": true, "Serve": true,
+		"ServeTLS": true,
+	},
+	"net/rpc": {
+		"Dial": true, "DialHTTP": true, "DialHTTPPath": true,
+	},
+	"google.golang.org/grpc": {
+		"Dial": true, "DialContext": true,
+	},
+}
+
+var arithmetic = map[token.Token]bool{
+	token.ADD: true, token.SUB: true, token.MUL: true, token.QUO: true, token.REM: true,
+	token.SHL: true, token.SHR: true,
+	token.ADD_ASSIGN: true, token.SUB_ASSIGN: true, token.MUL_ASSIGN: true,
+	token.QUO_ASSIGN: true, token.REM_ASSIGN: true,
+	token.SHL_ASSIGN: true, token.SHR_ASSIGN: true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		fn := n.(*ast.FuncDecl)
+		if factory.IsProduction(fn) {
+			checkFactory(pass, fn)
+		}
+	})
+	return nil, nil
+}
+
+func checkFactory(pass *analysis.Pass, fn *ast.FuncDecl) {
+	name := fn.Name.Name
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Closures are wired, not executed, by the factory.
+			return false
+		case *ast.IfStmt:
+			if field := configField(pass.TypesInfo, fn, n.Cond); field != "" {
+				pass.Reportf(n.Pos(), "production factory %s branches on configuration field %s; decide in the config layer and inject the result", name, field)
+			}
+		case *ast.ForStmt, *ast.RangeStmt:
+			pass.Reportf(n.Pos(), "production factory %s contains a loop; move building logic into a tested helper", name)
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt:
+			pass.Reportf(n.Pos(), "production factory %s contains a switch; move the decision into a tested helper", name)
+		case *ast.CallExpr:
+			if callee := externalCall(pass.TypesInfo, fn, n); callee != "" {
+				pass.Reportf(n.Pos(), "production factory %s calls external service %s; factories must not perform I/O", name, callee)
+			}
+		case *ast.BinaryExpr:
+			if arithmetic[n.Op] && isComputed(pass.TypesInfo, n) {
+				pass.Reportf(n.Pos(), "production factory %s computes a value (%s); calculate it in the config layer", name, n.Op)
+				return false
+			}
+		case *ast.AssignStmt:
+			if arithmetic[n.Tok] && isNumeric(pass.TypesInfo.TypeOf(n.Lhs[0])) {
+				pass.Reportf(n.Pos(), "production factory %s computes a value (%s); calculate it in the config layer", name, n.Tok)
+			}
+		}
+		return true
+	})
+}
+
+// configField returns the first Config field referenced by cond, formatted
+// as "cfg.Field", or "".
+func configField(info *types.Info, fn *ast.FuncDecl, cond ast.Expr) string {
+	var found string
+	ast.Inspect(cond, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok || found != "" {
+			return found == ""
+		}
+		var typeName string
+		if s := info.Selections[sel]; s != nil {
+			if s.Kind() != types.FieldVal {
+				return true
+			}
+			typeName = factory.TypeNameOf(info.TypeOf(sel.X))
+		} else {
+			typeName = declaredTypeName(info, fn, sel.X)
+		}
+		if strings.HasSuffix(typeName, "Config") {
+			found = types.ExprString(sel)
+		}
+		return true
+	})
+	return found
+}
+
+// declaredTypeName returns the name of x's type as written in fn, or "" if
+// x is neither a parameter of fn nor a variable fn assigns from a New<Type>
+// constructor call. It serves when x's type didn't resolve, as in code that
+// doesn't compile yet.
+func declaredTypeName(info *types.Info, fn *ast.FuncDecl, x ast.Expr) string {
+	id, ok := x.(*ast.Ident)
+	if !ok || info.Uses[id] == nil {
+		return ""
+	}
+	obj := info.Uses[id]
+	for _, field := range fn.Type.Params.List {
+		for _, name := range field.Names {
+			if info.Defs[name] == obj {
+				return typeExprName(field.Type)
+			}
+		}
+	}
+	var found string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		assign, ok := n.(*ast.AssignStmt)
+		if !ok || found != "" || len(assign.Lhs) != len(assign.Rhs) {
+			return found == ""
+		}
+		for i, lhs := range assign.Lhs {
+			if lhs, ok := lhs.(*ast.Ident); ok && info.Defs[lhs] == obj {
+				found = constructedTypeName(assign.Rhs[i])
+			}
+		}
+		return found == ""
+	})
+	return found
+}
+
+// typeExprName returns the type name of a declared type such as T, *T or
+// pkg.T, or "".
+func typeExprName(t ast.Expr) string {
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch t := t.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
+
+// constructedTypeName returns <Type> if expr calls a New<Type> constructor,
+// such as api.NewClient(url), or "".
+func constructedTypeName(expr ast.Expr) string {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return ""
+	}
+	var name string
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		name = fun.Name
+	case *ast.SelectorExpr:
+		name = fun.Sel.Name
+	}
+	if !strings.HasPrefix(name, "New") {
+		return ""
+	}
+	return strings.TrimPrefix(name, "New")
+}
+
+// externalCall returns a description of call if it invokes a network or API
+// client, or "". Clients are recognised by a type name ending in Client or by
+// belonging to a network package. A method whose receiver's type didn't
+// resolve is matched on the type name declared for the receiver in fn.
+func externalCall(info *types.Info, fn *ast.FuncDecl, call *ast.CallExpr) string {
+	if f, ok := typeutil.Callee(info, call).(*types.Func); ok && isNetworkFunc(f) {
+		return f.Pkg().Name() + "." + f.Name()
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || factory.IsPackageQualified(info, sel) {
+		return ""
+	}
+	var typeName string
+	if s := info.Selections[sel]; s != nil {
+		typeName = factory.TypeNameOf(info.TypeOf(sel.X))
+	} else {
+		typeName = declaredTypeName(info, fn, sel.X)
+	}
+	if strings.HasSuffix(typeName, "Client") {
+		return types.ExprString(sel)
+	}
+	return ""
+}
+
+func isNetworkFunc(fn *types.Func) bool {
+	if fn.Pkg() == nil || fn.Type().(*types.Signature).Recv() != nil {
+		return false
+	}
+	return networkFuncs[fn.Pkg().Path()][fn.Name()]
+}
+
+// isComputed reports whether expr is a numeric expression that is not folded
+// to a constant at compile time.
+func isComputed(info *types.Info, expr ast.Expr) bool {
+	tv, ok := info.Types[expr]
+	return ok && tv.Value == nil && isNumeric(tv.Type)
+}
+
+func isNumeric(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&types.IsNumeric != 0
+}
@@ -0,0 +1,15 @@
+package factorydecisions
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_Run(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "orders")
+}
+
+func TestAnalyzer_Run_Unresolved(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "partial")
+}
@@ -0,0 +1,57 @@
+package orders
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+type OrderConfig struct {
+	StrictMode bool
+	Timeout    time.Duration
+	Regions    []string
+}
+
+type PaymentClient struct{}
+
+func (c *PaymentClient) HealthCheck() error { return nil }
+
+type OrderService struct{}
+
+func NewOrderService(timeout time.Duration, client *PaymentClient) *OrderService {
+	return &OrderService{}
+}
+
+const retries = 3
+
+func NewOrderServiceForProduction(cfg OrderConfig) *OrderService {
+	client := &PaymentClient{}
+	if cfg.StrictMode { // want `production factory NewOrderServiceForProduction branches on configuration field cfg.StrictMode; decide in the config layer and inject the result`
+		client = &PaymentClient{}
+	}
+	for range cfg.Regions { // want `production factory NewOrderServiceForProduction contains a loop`
+	}
+	switch len(cfg.Regions) { // want `production factory NewOrderServiceForProduction contains a switch`
+	}
+	_ = client.HealthCheck()       // want `production factory NewOrderServiceForProduction calls external service client.HealthCheck; factories must not perform I/O`
+	_, _ = http.Get("http://x")    // want `production factory NewOrderServiceForProduction calls external service http.Get`
+	timeout := cfg.Timeout * 2     // want `production factory NewOrderServiceForProduction computes a value \(\*\)`
+	timeout += time.Second         // want `production factory NewOrderServiceForProduction computes a value \(\+=\)`
+	_, _ = net.Dial("tcp", "x:80") // want `production factory NewOrderServiceForProduction calls external service net.Dial`
+	_ = retries * 2
+	_ = http.NewRequest
+	_ = http.NotFoundHandler()
+	_ = http.StripPrefix("/orders", http.FileServer(http.Dir("static")))
+	_ = net.JoinHostPort("localhost", "8080")
+	onRetry := func(n int) int { return n * 2 }
+	_ = onRetry
+	return NewOrderService(timeout, client)
+}
+
+// buildOrders is not a production factory, so it may decide.
+func buildOrders(cfg OrderConfig) int {
+	if cfg.StrictMode {
+		return len(cfg.Regions) * 2
+	}
+	return 0
+}
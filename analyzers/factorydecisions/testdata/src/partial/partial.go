@@ -0,0 +1,38 @@
+package partial
+
+// RefundConfig and AuditOptions are not declared, as in code that doesn't
+// compile yet, so the parameters' types don't resolve.
+
+type RefundService struct{}
+
+func NewRefundService(strict bool) *RefundService { return &RefundService{} }
+
+func NewRefundServiceForProduction(cfg RefundConfig) *RefundService {
+	if cfg.StrictMode { // want `production factory NewRefundServiceForProduction branches on configuration field cfg.StrictMode`
+		return NewRefundService(true)
+	}
+	return NewRefundService(false)
+}
+
+func NewAuditServiceForProduction(opts *AuditOptions) *RefundService {
+	if opts.Verbose {
+		return NewRefundService(true)
+	}
+	return NewRefundService(false)
+}
+
+// The api and cache packages aren't imported either, so neither are the
+// types of the values their constructors return.
+
+type WeatherService struct{}
+
+func NewWeatherService(client, cache any) *WeatherService { return &WeatherService{} }
+
+func NewWeatherServiceForProduction() *WeatherService {
+	client := api.NewClient("https://api.weather.com")
+	cache := cache.NewRedisCache()
+	if !client.HealthCheck() { // want `production factory NewWeatherServiceForProduction calls external service client.HealthCheck`
+		cache.Flush()
+	}
+	return NewWeatherService(client, cache)
+}
@@ -0,0 +1,95 @@
+// Package factorylogic defines an Analyzer that reports business logic
+// inside production factories.
+//
+// Production factories (New<Type>ForProduction) exist only to wire
+// dependencies and are excluded from coverage, so any logic placed in them
+// is untested. This analyzer enforces "Violation 1" from
+// docs/prompts/standards-compliance.
+package factorylogic
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const Doc = `report business logic inside production factories
+
+Production factories (functions named New<Type>ForProduction) must only
+wire dependencies and delegate to the primary constructor. This analyzer
+reports, inside such factories:
+
+  - calls to repository methods (e.g. repo.Count(ctx))
+  - conditionals on data values (e.g. if count > 1000)
+  - logging calls, which signal decisions being made during wiring
+
+Move this logic into a service method, where it can be tested with mocks.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "factorylogic",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// logMethods are the method names treated as logging calls when invoked on a
+// logger-typed value.
+var logMethods = map[string]bool{
+	"Debug": true, "Info": true, "Warn": true, "Error": true,
+	"Debugf": true, "Infof": true, "Warnf": true, "Errorf": true,
+	"Print": true, "Printf": true, "Println": true,
+	"Log": true, "Logf": true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		fn := n.(*ast.FuncDecl)
+		if factory.IsProduction(fn) {
+			checkFactory(pass, fn)
+		}
+	})
+	return nil, nil
+}
+
+func checkFactory(pass *analysis.Pass, fn *ast.FuncDecl) {
+	name := fn.Name.Name
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// Closures are wired, not executed, by the factory.
+			return false
+		case *ast.CallExpr:
+			if m := factory.RepositoryMethod(pass.TypesInfo, n); m != "" {
+				pass.Reportf(n.Pos(), "production factory %s calls repository method %s; move business logic into a service method", name, m)
+			} else if m := logMethod(pass.TypesInfo, n); m != "" {
+				pass.Reportf(n.Pos(), "production factory %s logs via %s; logging in a factory signals a business decision", name, m)
+			}
+		case *ast.IfStmt:
+			if v := factory.DataValue(pass.TypesInfo, fn, n.Cond); v != nil {
+				pass.Reportf(n.Pos(), "production factory %s branches on data value %s; move the decision into a service method", name, v.Name())
+			}
+		}
+		return true
+	})
+}
+
+// logMethod returns the method name if call is a logging call on a
+// logger-typed value, or "".
+func logMethod(info *types.Info, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || factory.IsPackageQualified(info, sel) || !logMethods[sel.Sel.Name] {
+		return ""
+	}
+	if !strings.HasSuffix(factory.TypeNameOf(info.TypeOf(sel.X)), "Logger") {
+		return ""
+	}
+	return sel.Sel.Name
+}
@@ -0,0 +1,41 @@
+package users
+
+type UserRepository struct{}
+
+func (r *UserRepository) Count() int { return 0 }
+
+type Logger struct{}
+
+func (l *Logger) Info(msg string) {}
+
+type APIClient struct{}
+
+func (c *APIClient) HealthCheck() error { return nil }
+
+type UserConfig struct{ Limit int }
+
+func (c UserConfig) MaxUsers() int { return c.Limit }
+
+type UserService struct{}
+
+func NewUserService(repo *UserRepository, client *APIClient, max int) *UserService {
+	return &UserService{}
+}
+
+func NewUserServiceForProduction(cfg UserConfig, log *Logger) *UserService {
+	repo := &UserRepository{}
+	client := &APIClient{}
+	count := repo.Count() // want `production factory NewUserServiceForProduction calls repository method Count; move business logic into a service method`
+	if count > 1000 {     // want `production factory NewUserServiceForProduction branches on data value count; move the decision into a service method`
+		log.Info("large") // want `production factory NewUserServiceForProduction logs via Info; logging in a factory signals a business decision`
+	}
+	_ = client.HealthCheck()
+	onStart := func() { _ = repo.Count() }
+	_ = onStart
+	return NewUserService(repo, client, cfg.MaxUsers())
+}
+
+// countUsers is a service helper, where logic belongs.
+func countUsers(repo *UserRepository) bool {
+	return repo.Count() > 1000
+}
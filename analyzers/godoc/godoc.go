@@ -0,0 +1,128 @@
+// Package godoc defines an Analyzer that reports exported declarations
+// without a doc comment.
+//
+// The standards require godoc on every exported function, method and type.
+// Each finding carries a suggested fix that inserts a comment stub beginning
+// with the declaration's name, for the author to complete; stdcheck fix
+// -draft-docs has the configured model draft the text instead.
+package godoc
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const Doc = `report exported declarations without a doc comment
+
+Exported functions, methods on exported types, and exported types must have
+a godoc comment. Test files are not checked.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "godoc",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// StubMarker is the placeholder text left in inserted comment stubs, so
+// unfinished documentation is easy to find.
+const StubMarker = "TODO: document"
+
+// StubName returns the name of the declaration that a comment stub inserted
+// by the suggested fix documents, and whether text is such a stub.
+func StubName(text string) (string, bool) {
+	rest, ok := strings.CutPrefix(text, "// ")
+	if !ok {
+		return "", false
+	}
+	name, rest, ok := strings.Cut(rest, " ")
+	return name, ok && strings.HasPrefix(rest, StubMarker+".\n")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil), (*ast.GenDecl)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		if strings.HasSuffix(pass.Fset.File(n.Pos()).Name(), "_test.go") {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if !n.Name.IsExported() || hasDoc(n.Doc) || !exportedReceiver(n) {
+				return
+			}
+			kind := "function"
+			if n.Recv != nil {
+				kind = "method"
+			}
+			report(pass, n.Pos(), n.Name, kind, "")
+		case *ast.GenDecl:
+			if n.Tok != token.TYPE {
+				return
+			}
+			for _, spec := range n.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if !ts.Name.IsExported() || hasDoc(ts.Doc) || (hasDoc(n.Doc) && !n.Lparen.IsValid()) {
+					continue
+				}
+				if n.Lparen.IsValid() {
+					// Inside a type ( ... ) group the comment goes on the spec.
+					report(pass, ts.Pos(), ts.Name, "type", "\t")
+				} else {
+					report(pass, n.Pos(), ts.Name, "type", "")
+				}
+			}
+		}
+	})
+	return nil, nil
+}
+
+// hasDoc reports whether cg holds documentation. Directive comments such as
+// //go:generate or //stdignore: are not documentation.
+func hasDoc(cg *ast.CommentGroup) bool {
+	return cg != nil && strings.TrimSpace(cg.Text()) != ""
+}
+
+// exportedReceiver reports whether fn is a function, or a method whose
+// receiver type is exported.
+func exportedReceiver(fn *ast.FuncDecl) bool {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return true
+	}
+	t := fn.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	switch t := t.(type) {
+	case *ast.IndexExpr:
+		return identExported(t.X)
+	case *ast.IndexListExpr:
+		return identExported(t.X)
+	default:
+		return identExported(t)
+	}
+}
+
+func identExported(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.IsExported()
+}
+
+func report(pass *analysis.Pass, at token.Pos, name *ast.Ident, kind, indent string) {
+	stub := fmt.Sprintf("// %s %s.\n%s", name.Name, StubMarker, indent)
+	pass.Report(analysis.Diagnostic{
+		Pos:     name.Pos(),
+		Message: fmt.Sprintf("exported %s %s has no doc comment", kind, name.Name),
+		SuggestedFixes: []analysis.SuggestedFix{{
+			Message:   "Add doc comment stub",
+			TextEdits: []analysis.TextEdit{{Pos: at, End: at, NewText: []byte(stub)}},
+		}},
+	})
+}
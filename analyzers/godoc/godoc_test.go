@@ -0,0 +1,31 @@
+package godoc
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_Run(t *testing.T) {
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "docs")
+}
+
+func TestStubName_Stubs(t *testing.T) {
+	tests := []struct {
+		text string
+		name string
+		ok   bool
+	}{
+		{"// Open TODO: document.\n", "Open", true},
+		{"// Value TODO: document.\n\t", "Value", true},
+		{"// Open opens the store.\n", "", false},
+		{"/* Open TODO: document. */", "", false},
+		{"// Open", "", false},
+	}
+	for _, tt := range tests {
+		name, ok := StubName(tt.text)
+		if ok != tt.ok || ok && name != tt.name {
+			t.Errorf("StubName(%q) = %q, %v; want %q, %v", tt.text, name, ok, tt.name, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package docs
+
+// Store is documented.
+type Store struct{}
+
+type Cache struct{} // want `exported type Cache has no doc comment`
+
+type (
+	// Key is documented.
+	Key   string
+	Value []byte // want `exported type Value has no doc comment`
+)
+
+type unexported struct{}
+
+//go:generate echo
+func Open() *Store { return nil } // want `exported function Open has no doc comment`
+
+func (s *Store) Get(k Key) Value { return nil } // want `exported method Get has no doc comment`
+
+// Put is documented.
+func (s *Store) Put(k Key, v Value) {}
+
+func (u unexported) Get() {}
+
+func helper() {}
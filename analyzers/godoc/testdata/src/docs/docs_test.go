@@ -0,0 +1,7 @@
+package docs
+
+import "testing"
+
+func TestOpen(t *testing.T) {}
+
+func Exported() {}
@@ -0,0 +1,83 @@
+// Package factory holds the conventions and heuristics shared by the
+// standards analyzers for primary constructors and production factories.
+package factory
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+)
+
+var productionFactory = regexp.MustCompile(`^New(\w+)ForProduction$`)
+
+// IsProduction reports whether fn is a production factory: a top-level
+// function named New<Type>ForProduction with a body.
+func IsProduction(fn *ast.FuncDecl) bool {
+	return fn.Recv == nil && fn.Body != nil && productionFactory.MatchString(fn.Name.Name)
+}
+
+// TypeName returns the <Type> part of a production factory name, or "" if
+// name is not a production factory name.
+func TypeName(name string) string {
+	m := productionFactory.FindStringSubmatch(name)
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// PrimaryName returns the primary constructor name (New<Type>) matching the
+// production factory name, or "" if name is not a production factory name.
+func PrimaryName(name string) string {
+	if t := TypeName(name); t != "" {
+		return "New" + t
+	}
+	return ""
+}
+
+var containerConstructor = regexp.MustCompile(`^New\w*Container$`)
+
+// IsWiringHelper reports whether fn is dependency-injection wiring other than
+// a production factory: a New*Container function, or an init* method on a
+// Container type.
+func IsWiringHelper(fn *ast.FuncDecl) bool {
+	if fn.Body == nil {
+		return false
+	}
+	if fn.Recv == nil {
+		return containerConstructor.MatchString(fn.Name.Name)
+	}
+	return strings.HasPrefix(fn.Name.Name, "init") && receiverName(fn) == "Container"
+}
+
+// receiverName returns the base type name of fn's receiver.
+func receiverName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return ""
+	}
+	t := fn.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+	if id, ok := t.(*ast.Ident); ok {
+		return id.Name
+	}
+	return ""
+}
+
+// CoverageIgnore is the marker that excludes a function from coverage.
+const CoverageIgnore = "coverage:ignore"
+
+// HasCoverageIgnore reports whether fn's doc comment carries the
+// coverage:ignore marker on a line of its own.
+func HasCoverageIgnore(fn *ast.FuncDecl) bool {
+	if fn.Doc == nil {
+		return false
+	}
+	for _, c := range fn.Doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == CoverageIgnore {
+			return true
+		}
+	}
+	return false
+}
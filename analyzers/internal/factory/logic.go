@@ -0,0 +1,80 @@
+package factory
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// RepositoryMethod returns the method name if call invokes a method on a
+// repository-typed value (a named type ending in Repository or Repo), or "".
+func RepositoryMethod(info *types.Info, call *ast.CallExpr) string {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || IsPackageQualified(info, sel) {
+		return ""
+	}
+	name := TypeNameOf(info.TypeOf(sel.X))
+	if strings.HasSuffix(name, "Repository") || strings.HasSuffix(name, "Repo") {
+		return sel.Sel.Name
+	}
+	return ""
+}
+
+// DataValue returns the first parameter or local variable of fn with basic
+// type (number, string, bool) referenced by cond, or nil. Error checks and
+// fields of structured values (such as Config) are not data values.
+func DataValue(info *types.Info, fn *ast.FuncDecl, cond ast.Expr) *types.Var {
+	var found *types.Var
+	ast.Inspect(cond, func(n ast.Node) bool {
+		if found != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			// Only the base of a selector can be a local; cfg.Field is not.
+			return false
+		case *ast.Ident:
+			v, ok := info.Uses[n].(*types.Var)
+			if !ok || v.IsField() || !declaredIn(fn, v) {
+				return true
+			}
+			if _, basic := v.Type().Underlying().(*types.Basic); basic {
+				found = v
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// declaredIn reports whether v is a parameter or local variable of fn.
+func declaredIn(fn *ast.FuncDecl, v *types.Var) bool {
+	return v.Pos() >= fn.Type.Pos() && v.Pos() <= fn.End()
+}
+
+// IsPackageQualified reports whether sel is a package-qualified identifier
+// such as persistence.NewUserRepository.
+func IsPackageQualified(info *types.Info, sel *ast.SelectorExpr) bool {
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, ok = info.Uses[id].(*types.PkgName)
+	return ok
+}
+
+// TypeNameOf returns the name of t's named type, looking through pointers,
+// or "" if t is not named.
+func TypeNameOf(t types.Type) string {
+	if t == nil {
+		return ""
+	}
+	t = types.Unalias(t)
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if n, ok := t.(*types.Named); ok {
+		return n.Obj().Name()
+	}
+	return ""
+}
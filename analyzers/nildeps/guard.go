@@ -0,0 +1,194 @@
+package nildeps
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+)
+
+// use is a dereference of a receiver field that no nil check guards.
+type use struct {
+	field  *types.Var
+	sel    *ast.SelectorExpr // recv.field
+	method string            // as T.Method
+}
+
+// uncheckedUses returns the dereferences of nilable fields of the method
+// fn's receiver that no nil check guards, at most one per field: calls of
+// methods or func fields, field accesses and indirections.
+func uncheckedUses(pass *analysis.Pass, fn *ast.FuncDecl) []use {
+	if len(fn.Recv.List) == 0 || len(fn.Recv.List[0].Names) == 0 {
+		return nil
+	}
+	recv := pass.TypesInfo.Defs[fn.Recv.List[0].Names[0]]
+	if recv == nil {
+		return nil
+	}
+	method := factory.TypeNameOf(recv.Type()) + "." + fn.Name.Name
+
+	// field returns the receiver field e selects, if it is nilable.
+	field := func(e ast.Expr) (*ast.SelectorExpr, *types.Var) {
+		sel, ok := ast.Unparen(e).(*ast.SelectorExpr)
+		if !ok {
+			return nil, nil
+		}
+		id, ok := sel.X.(*ast.Ident)
+		if !ok || pass.TypesInfo.Uses[id] != recv {
+			return nil, nil
+		}
+		s := pass.TypesInfo.Selections[sel]
+		if s == nil || s.Kind() != types.FieldVal || len(s.Index()) != 1 || !nilable(s.Type()) {
+			return nil, nil
+		}
+		return sel, s.Obj().(*types.Var)
+	}
+
+	var uses []use
+	seen := make(map[*types.Var]bool)
+	var stack []ast.Node
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		var operand ast.Expr
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			operand = n.X
+		case *ast.StarExpr:
+			operand = n.X
+		case *ast.CallExpr:
+			operand = n.Fun
+		}
+		if operand == nil {
+			return true
+		}
+		sel, f := field(operand)
+		if f == nil || seen[f] {
+			return true
+		}
+		if _, isFunc := f.Type().Underlying().(*types.Signature); isFunc != isCall(n) {
+			// A func field is dereferenced by calling it; anything else
+			// by selecting from or indirecting it.
+			return true
+		}
+		if !guarded(stack, types.ExprString(sel)) {
+			seen[f] = true
+			uses = append(uses, use{field: f, sel: sel, method: method})
+		}
+		return true
+	})
+	return uses
+}
+
+func isCall(n ast.Node) bool {
+	_, ok := n.(*ast.CallExpr)
+	return ok
+}
+
+// guarded reports whether a nil check of the expression key guards the last
+// node of stack, the path from a function body down to it: an enclosing if
+// or && that requires key != nil, or an earlier statement that returns or
+// assigns key when it is nil.
+func guarded(stack []ast.Node, key string) bool {
+	for i := len(stack) - 1; i > 0; i-- {
+		child, parent := stack[i], stack[i-1]
+		switch p := parent.(type) {
+		case *ast.IfStmt:
+			if (child == p.Body && implies(p.Cond, key, token.NEQ)) || (child == p.Else && implies(p.Cond, key, token.EQL)) {
+				return true
+			}
+		case *ast.BinaryExpr:
+			if child == p.Y && ((p.Op == token.LAND && implies(p.X, key, token.NEQ)) || (p.Op == token.LOR && implies(p.X, key, token.EQL))) {
+				return true
+			}
+		case *ast.BlockStmt:
+			if checkedBefore(p.List, child, key) {
+				return true
+			}
+		case *ast.CaseClause:
+			if checkedBefore(p.Body, child, key) {
+				return true
+			}
+		case *ast.CommClause:
+			if checkedBefore(p.Body, child, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkedBefore reports whether a statement of list before child is an if
+// statement that, unless key is not nil, leaves the block or sets key.
+func checkedBefore(list []ast.Stmt, child ast.Node, key string) bool {
+	for _, stmt := range list {
+		if stmt == child {
+			return false
+		}
+		ifs, ok := stmt.(*ast.IfStmt)
+		if ok && implies(ifs.Cond, key, token.EQL) && (terminates(ifs.Body) || assigns(ifs.Body, key)) {
+			return true
+		}
+	}
+	return false
+}
+
+// implies reports whether the outcome of cond proves key is not nil: for op
+// !=, that cond holding does (key != nil, or a conjunction with it); for op
+// ==, that cond failing does (key == nil, or a disjunction with it).
+func implies(cond ast.Expr, key string, op token.Token) bool {
+	b, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok {
+		return false
+	}
+	switch {
+	case b.Op == op:
+		x, y := ast.Unparen(b.X), ast.Unparen(b.Y)
+		return (types.ExprString(x) == key && isNilIdent(y)) || (types.ExprString(y) == key && isNilIdent(x))
+	case (b.Op == token.LAND && op == token.NEQ) || (b.Op == token.LOR && op == token.EQL):
+		return implies(b.X, key, op) || implies(b.Y, key, op)
+	}
+	return false
+}
+
+func isNilIdent(e ast.Expr) bool {
+	id, ok := e.(*ast.Ident)
+	return ok && id.Name == "nil"
+}
+
+// terminates reports whether block ends by leaving its function or loop.
+func terminates(block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	switch s := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt, *ast.BranchStmt:
+		return true
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "panic" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// assigns reports whether block assigns to key.
+func assigns(block *ast.BlockStmt, key string) bool {
+	for _, stmt := range block.List {
+		if as, ok := stmt.(*ast.AssignStmt); ok {
+			for _, lhs := range as.Lhs {
+				if types.ExprString(lhs) == key {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
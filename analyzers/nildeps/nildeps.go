@@ -0,0 +1,338 @@
+// Package nildeps defines an Analyzer that reports nil passed for injected
+// dependencies, and optional dependencies used without a nil check.
+//
+// The standards inject every dependency through the primary constructor, so
+// a service can assume its dependencies are set. Wiring that passes nil for
+// one, typically a Logger, breaks that assumption far from where the panic
+// happens. A constructor's facts record which parameters it stores in its
+// struct and which of those a method calls unchecked, so call sites in any
+// importing package can be checked.
+package nildeps
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `report nil passed for injected dependencies and unchecked optional ones
+
+A constructor stores its dependencies in the fields of the struct it
+returns. This analyzer reports:
+
+  - call sites, in any package, that pass nil for a constructor's Logger
+    parameter, or for a dependency that a method of the struct uses without
+    a nil check; pass an implementation, such as a no-op one, instead
+  - methods that use an optional dependency without a nil check, where the
+    dependency is optional because other code in the package checks it for
+    nil, its field comment says it is optional or may be nil, or a call
+    site in the package passes nil for it
+
+Constructors that replace a nil argument with a default are trusted, and
+call sites in test files are not checked.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "nildeps",
+	Doc:       Doc,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	Run:       run,
+	FactTypes: []analysis.Fact{new(injectsFact)},
+}
+
+// injectsFact records the dependencies a constructor injects: the
+// parameters it stores in a field of the struct it returns without
+// substituting a default for nil, sorted by index. A slice rather than a
+// map keeps the fact's encoding deterministic.
+type injectsFact struct {
+	Deps []dep
+}
+
+func (*injectsFact) AFact() {}
+
+// dep returns the dependency injected by the parameter with index i.
+func (f *injectsFact) dep(i int) (dep, bool) {
+	for _, d := range f.Deps {
+		if d.Index == i {
+			return d, true
+		}
+	}
+	return dep{}, false
+}
+
+// String lists the injected parameters, each with the method that uses it
+// unchecked, if any, such as "log, mail (Invoicer.Send)".
+func (f *injectsFact) String() string {
+	parts := make([]string, len(f.Deps))
+	for i, d := range f.Deps {
+		parts[i] = d.Param
+		if d.Unchecked != "" {
+			parts[i] += " (" + d.Unchecked + ")"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dep is a constructor parameter stored in a field.
+type dep struct {
+	Index     int    // of the parameter
+	Param     string // parameter name
+	Logger    bool   // the parameter is a Logger
+	Unchecked string // a method that uses the field without a nil check, as T.Method, or ""
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	// Uses of receiver fields without a nil check, and why fields are
+	// optional, across the package.
+	var unchecked []use
+	optional := make(map[*types.Var]string)
+	local := make(map[*types.Func]map[int]*types.Var) // constructor parameters to fields
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil), (*ast.Field)(nil), (*ast.BinaryExpr)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		if isTest(pass, n.Pos()) {
+			return
+		}
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			if n.Body != nil && n.Recv != nil {
+				unchecked = append(unchecked, uncheckedUses(pass, n)...)
+			}
+		case *ast.Field:
+			if documentedOptional(n) {
+				for _, name := range n.Names {
+					if v, ok := pass.TypesInfo.Defs[name].(*types.Var); ok && v.IsField() {
+						optional[v] = "its field comment says it is optional"
+					}
+				}
+			}
+		case *ast.BinaryExpr:
+			if v := nilCompared(pass.TypesInfo, n); v != nil && optional[v] == "" {
+				optional[v] = "other code checks it for nil"
+			}
+		}
+	})
+
+	firstUse := make(map[*types.Var]use)
+	for _, u := range unchecked {
+		if _, ok := firstUse[u.field]; !ok {
+			firstUse[u.field] = u
+		}
+	}
+	inspect.Preorder([]ast.Node{(*ast.FuncDecl)(nil)}, func(n ast.Node) {
+		fn := n.(*ast.FuncDecl)
+		if fn.Recv != nil || fn.Body == nil {
+			return
+		}
+		obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+		if !ok {
+			return
+		}
+		fields := injected(pass, fn)
+		if len(fields) == 0 {
+			return
+		}
+		local[obj] = fields
+		fact := new(injectsFact)
+		params := obj.Type().(*types.Signature).Params()
+		for i, f := range fields {
+			p := params.At(i)
+			d := dep{Index: i, Param: p.Name(), Logger: strings.HasSuffix(factory.TypeNameOf(p.Type()), "Logger")}
+			if u, ok := firstUse[f]; ok {
+				d.Unchecked = u.method
+			}
+			fact.Deps = append(fact.Deps, d)
+		}
+		sort.Slice(fact.Deps, func(i, j int) bool { return fact.Deps[i].Index < fact.Deps[j].Index })
+		pass.ExportObjectFact(obj, fact)
+	})
+
+	inspect.WithStack([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push || isTest(pass, n.Pos()) {
+			return true
+		}
+		call := n.(*ast.CallExpr)
+		callee := typeutil.StaticCallee(pass.TypesInfo, call)
+		if callee == nil || call.Ellipsis.IsValid() {
+			return true
+		}
+		var fact injectsFact
+		if !pass.ImportObjectFact(callee, &fact) {
+			return true
+		}
+		for i, arg := range call.Args {
+			d, ok := fact.dep(i)
+			if !ok || !isNil(pass.TypesInfo, arg) {
+				continue
+			}
+			if f := local[callee][i]; f != nil && optional[f] == "" {
+				optional[f] = enclosingFunc(stack) + " passes nil for it to " + callee.Name()
+			}
+			switch {
+			case d.Unchecked != "":
+				pass.Reportf(arg.Pos(), "%s is passed nil for %s, but %s uses it without a nil check; pass an implementation, such as a no-op one", callee.Name(), d.Param, d.Unchecked)
+			case d.Logger:
+				pass.Reportf(arg.Pos(), "%s is passed nil for %s; pass a no-op Logger instead so logging never dereferences nil", callee.Name(), d.Param)
+			}
+		}
+		return true
+	})
+
+	sort.Slice(unchecked, func(i, j int) bool { return unchecked[i].sel.Pos() < unchecked[j].sel.Pos() })
+	for _, u := range unchecked {
+		if why := optional[u.field]; why != "" {
+			pass.Reportf(u.sel.Pos(), "%s uses %s without a nil check, but the dependency is optional: %s", u.method, types.ExprString(u.sel), why)
+		}
+	}
+	return nil, nil
+}
+
+func isTest(pass *analysis.Pass, pos token.Pos) bool {
+	return strings.HasSuffix(pass.Fset.File(pos).Name(), "_test.go")
+}
+
+func isNil(info *types.Info, e ast.Expr) bool {
+	tv, ok := info.Types[e]
+	return ok && tv.IsNil()
+}
+
+// enclosingFunc names the innermost function declaration in stack.
+func enclosingFunc(stack []ast.Node) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if fn, ok := stack[i].(*ast.FuncDecl); ok {
+			return fn.Name.Name
+		}
+	}
+	return "package initialization"
+}
+
+// documentedOptional reports whether the comments of a struct field say it
+// may be nil.
+func documentedOptional(field *ast.Field) bool {
+	for _, cg := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if text := strings.ToLower(cg.Text()); strings.Contains(text, "optional") || strings.Contains(text, "may be nil") {
+			return true
+		}
+	}
+	return false
+}
+
+// nilCompared returns the field of a struct in this package that e compares
+// with nil, or nil.
+func nilCompared(info *types.Info, e *ast.BinaryExpr) *types.Var {
+	if e.Op != token.EQL && e.Op != token.NEQ {
+		return nil
+	}
+	x, y := e.X, e.Y
+	if isNil(info, x) {
+		x, y = y, x
+	}
+	if !isNil(info, y) {
+		return nil
+	}
+	sel, ok := ast.Unparen(x).(*ast.SelectorExpr)
+	if !ok {
+		return nil
+	}
+	if s := info.Selections[sel]; s != nil && s.Kind() == types.FieldVal {
+		return s.Obj().(*types.Var)
+	}
+	return nil
+}
+
+// injected returns, by parameter index, the fields in which the constructor
+// fn stores its nilable parameters: as keyed values of a composite literal
+// or by assignment to a field. Parameters fn compares with nil, presumably
+// to substitute a default, are left out.
+func injected(pass *analysis.Pass, fn *ast.FuncDecl) map[int]*types.Var {
+	info := pass.TypesInfo
+	params := make(map[*types.Var]int)
+	i := 0
+	for _, field := range fn.Type.Params.List {
+		if len(field.Names) == 0 {
+			i++
+			continue
+		}
+		for _, name := range field.Names {
+			if v, ok := info.Defs[name].(*types.Var); ok && nilable(v.Type()) {
+				params[v] = i
+			}
+			i++
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+
+	param := func(e ast.Expr) (*types.Var, bool) {
+		id, ok := ast.Unparen(e).(*ast.Ident)
+		if !ok {
+			return nil, false
+		}
+		v, ok := info.Uses[id].(*types.Var)
+		_, isParam := params[v]
+		return v, ok && isParam
+	}
+	fields := make(map[int]*types.Var)
+	defaulted := make(map[*types.Var]bool)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.KeyValueExpr:
+			key, ok := n.Key.(*ast.Ident)
+			if !ok {
+				break
+			}
+			if f, ok := info.Uses[key].(*types.Var); ok && f.IsField() && f.Pkg() == pass.Pkg {
+				if p, ok := param(n.Value); ok {
+					fields[params[p]] = f
+				}
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				sel, ok := lhs.(*ast.SelectorExpr)
+				if !ok || len(n.Lhs) != len(n.Rhs) {
+					continue
+				}
+				if s := info.Selections[sel]; s != nil && s.Kind() == types.FieldVal && s.Obj().Pkg() == pass.Pkg {
+					if p, ok := param(n.Rhs[i]); ok {
+						fields[params[p]] = s.Obj().(*types.Var)
+					}
+				}
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.EQL || n.Op == token.NEQ {
+				for _, side := range []ast.Expr{n.X, n.Y} {
+					if p, ok := param(side); ok {
+						defaulted[p] = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	for p, i := range params {
+		if defaulted[p] {
+			delete(fields, i)
+		}
+	}
+	return fields
+}
+
+// nilable reports whether a nil value of type t panics when used: pointers,
+// interfaces and funcs.
+func nilable(t types.Type) bool {
+	switch t.Underlying().(type) {
+	case *types.Pointer, *types.Interface, *types.Signature:
+		return true
+	}
+	return false
+}
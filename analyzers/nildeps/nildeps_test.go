@@ -0,0 +1,11 @@
+package nildeps
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_Run(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "billing", "shop")
+}
@@ -0,0 +1,65 @@
+package billing
+
+type Logger interface{ Info(msg string) }
+
+type Mailer interface{ Send(to string) error }
+
+type Auditor interface{ Record(event string) }
+
+type Metrics struct{ sent int }
+
+func (m *Metrics) Inc() { m.sent++ }
+
+type Invoicer struct {
+	log     Logger
+	mail    Mailer
+	audit   Auditor // optional
+	metrics *Metrics
+}
+
+func NewInvoicer(log Logger, mail Mailer, audit Auditor, metrics *Metrics) *Invoicer { // want NewInvoicer:"log, mail \\(Invoicer.Send\\), audit \\(Invoicer.Flush\\), metrics \\(Invoicer.Send\\)"
+	return &Invoicer{log: log, mail: mail, audit: audit, metrics: metrics}
+}
+
+func (i *Invoicer) Send(to string) error {
+	if i.log != nil {
+		i.log.Info("sending")
+	}
+	if i.audit != nil {
+		i.audit.Record("send")
+	}
+	i.metrics.Inc() // want `Invoicer.Send uses i.metrics without a nil check, but the dependency is optional: other code checks it for nil`
+	return i.mail.Send(to)
+}
+
+func (i *Invoicer) Flush() {
+	if i.metrics == nil {
+		return
+	}
+	i.metrics.Inc()
+	i.audit.Record("flush") // want `Invoicer.Flush uses i.audit without a nil check, but the dependency is optional: its field comment says it is optional`
+}
+
+type Mailbox struct{}
+
+func (Mailbox) Send(to string) error { return nil }
+
+func NewDefaultInvoicer() *Invoicer {
+	return NewInvoicer(nil, Mailbox{}, nil, nil) // want `NewInvoicer is passed nil for log; pass a no-op Logger instead` `NewInvoicer is passed nil for audit, but Invoicer.Flush uses it without a nil check` `NewInvoicer is passed nil for metrics, but Invoicer.Send uses it without a nil check`
+}
+
+type Quiet struct{ log Logger }
+
+type nopLogger struct{}
+
+func (nopLogger) Info(msg string) {}
+
+// NewQuiet substitutes a default for a nil Logger, so nil is fine.
+func NewQuiet(log Logger) *Quiet {
+	if log == nil {
+		log = nopLogger{}
+	}
+	return &Quiet{log: log}
+}
+
+func (q *Quiet) Run() { q.log.Info("run") }
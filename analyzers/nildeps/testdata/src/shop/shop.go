@@ -0,0 +1,8 @@
+package shop
+
+import "billing"
+
+func wire() {
+	_ = billing.NewInvoicer(nil, nil, nil, &billing.Metrics{}) // want `NewInvoicer is passed nil for log; pass a no-op Logger instead` `NewInvoicer is passed nil for mail, but Invoicer.Send uses it without a nil check; pass an implementation, such as a no-op one` `NewInvoicer is passed nil for audit`
+	_ = billing.NewQuiet(nil)
+}
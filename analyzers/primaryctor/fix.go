@@ -0,0 +1,160 @@
+package primaryctor
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// extractConstructor builds a fix that introduces the primary constructor
+// New<Type> from the struct literal lit built by the production factory fn,
+// and rewrites the factory to call it. Each field set by the literal becomes
+// a constructor parameter of the field's type; the literal's values become
+// the call's arguments. addr is the &lit expression, if the literal's address
+// is taken. It reports false if the literal cannot be converted safely.
+func extractConstructor(pass *analysis.Pass, fn *ast.FuncDecl, typ *types.TypeName, lit *ast.CompositeLit, addr *ast.UnaryExpr) (analysis.SuggestedFix, bool) {
+	st := typ.Type().Underlying().(*types.Struct)
+	file := enclosingFile(pass, fn)
+	if file == nil {
+		return analysis.SuggestedFix{}, false
+	}
+	qualifier := importQualifier(pass, file)
+
+	var params, fields, args []string
+	for i, elt := range lit.Elts {
+		var field *types.Var
+		value := elt
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			id, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				return analysis.SuggestedFix{}, false
+			}
+			field = fieldByName(st, id.Name)
+			value = kv.Value
+		} else if i < st.NumFields() {
+			field = st.Field(i)
+		}
+		if field == nil {
+			return analysis.SuggestedFix{}, false
+		}
+		typeStr, ok := typeString(field.Type(), qualifier)
+		if !ok {
+			return analysis.SuggestedFix{}, false
+		}
+		arg, err := nodeString(pass.Fset, value)
+		if err != nil {
+			return analysis.SuggestedFix{}, false
+		}
+		param := paramName(field.Name())
+		params = append(params, param+" "+typeStr)
+		fields = append(fields, field.Name()+": "+param+",")
+		args = append(args, arg)
+	}
+
+	primaryName := "New" + typ.Name()
+	result, amp := typ.Name(), ""
+	var replaced ast.Node = lit
+	if addr != nil {
+		result, amp, replaced = "*"+typ.Name(), "&", addr
+	}
+
+	var ctor strings.Builder
+	fmt.Fprintf(&ctor, "// %s is the primary constructor for %s; it takes every dependency\n// so tests can inject mocks.\n", primaryName, typ.Name())
+	fmt.Fprintf(&ctor, "func %s(%s) %s {\n", primaryName, strings.Join(params, ", "), result)
+	fmt.Fprintf(&ctor, "\treturn %s%s{\n", amp, typ.Name())
+	for _, f := range fields {
+		fmt.Fprintf(&ctor, "\t\t%s\n", f)
+	}
+	ctor.WriteString("\t}\n}\n\n")
+
+	insertAt := fn.Pos()
+	if fn.Doc != nil {
+		insertAt = fn.Doc.Pos()
+	}
+	return analysis.SuggestedFix{
+		Message: fmt.Sprintf("Extract primary constructor %s", primaryName),
+		TextEdits: []analysis.TextEdit{
+			{Pos: insertAt, End: insertAt, NewText: []byte(ctor.String())},
+			{Pos: replaced.Pos(), End: replaced.End(), NewText: []byte(primaryName + "(" + strings.Join(args, ", ") + ")")},
+		},
+	}, true
+}
+
+func enclosingFile(pass *analysis.Pass, n ast.Node) *ast.File {
+	for _, f := range pass.Files {
+		if f.FileStart <= n.Pos() && n.Pos() < f.FileEnd {
+			return f
+		}
+	}
+	return nil
+}
+
+// importQualifier returns a types.Qualifier naming packages as file imports
+// them. Packages that file does not import are qualified with a NUL-prefixed
+// name so typeString can reject them: the generated code would not compile.
+func importQualifier(pass *analysis.Pass, file *ast.File) types.Qualifier {
+	names := make(map[string]string)
+	for _, imp := range file.Imports {
+		obj := pass.TypesInfo.Implicits[imp]
+		if imp.Name != nil {
+			obj = pass.TypesInfo.Defs[imp.Name]
+		}
+		if pn, ok := obj.(*types.PkgName); ok {
+			names[pn.Imported().Path()] = pn.Name()
+		}
+	}
+	return func(p *types.Package) string {
+		if p == pass.Pkg {
+			return ""
+		}
+		name, ok := names[p.Path()]
+		switch {
+		case !ok:
+			return "\x00" + p.Name()
+		case name == ".":
+			return ""
+		}
+		return name
+	}
+}
+
+func typeString(t types.Type, q types.Qualifier) (string, bool) {
+	s := types.TypeString(t, q)
+	return s, !strings.Contains(s, "\x00")
+}
+
+func fieldByName(st *types.Struct, name string) *types.Var {
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// paramName derives a parameter name from a field name by lowercasing its
+// first letter, avoiding Go keywords.
+func paramName(field string) string {
+	r := []rune(field)
+	r[0] = unicode.ToLower(r[0])
+	name := string(r)
+	if token.IsKeyword(name) {
+		name += "Value"
+	}
+	return name
+}
+
+func nodeString(fset *token.FileSet, n ast.Node) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, n); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,111 @@
+// Package primaryctor defines an Analyzer that reports production factories
+// without a matching primary constructor.
+//
+// Every New<Type>ForProduction factory must have a New<Type> primary
+// constructor taking all dependencies, and must delegate to it instead of
+// building the struct itself. Otherwise tests have no way to inject mocks.
+// This analyzer enforces "Violation 2" from docs/prompts/standards-compliance.
+package primaryctor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+const Doc = `report production factories without a primary constructor
+
+For every struct type T with a production factory NewTForProduction, the
+package must declare a primary constructor NewT, and the factory must
+delegate to it rather than building a T composite literal directly.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "primaryctor",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.FuncDecl)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		fn := n.(*ast.FuncDecl)
+		if factory.IsProduction(fn) {
+			checkFactory(pass, fn)
+		}
+	})
+	return nil, nil
+}
+
+func checkFactory(pass *analysis.Pass, fn *ast.FuncDecl) {
+	name := fn.Name.Name
+	typ := structType(pass.Pkg, factory.TypeName(name))
+	if typ == nil {
+		// Only factories named after a struct in this package are checked.
+		return
+	}
+	primaryName := factory.PrimaryName(name)
+	primary, _ := pass.Pkg.Scope().Lookup(primaryName).(*types.Func)
+
+	delegates := false
+	var builds []*ast.CompositeLit
+	addrOf := make(map[*ast.CompositeLit]*ast.UnaryExpr)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			if id, ok := n.Fun.(*ast.Ident); ok && primary != nil && pass.TypesInfo.Uses[id] == primary {
+				delegates = true
+			}
+		case *ast.UnaryExpr:
+			if lit, ok := n.X.(*ast.CompositeLit); ok && n.Op == token.AND {
+				addrOf[lit] = n
+			}
+		case *ast.CompositeLit:
+			if named, ok := pass.TypesInfo.TypeOf(n).(*types.Named); ok && named.Obj() == typ {
+				builds = append(builds, n)
+				pass.Reportf(n.Pos(), "production factory %s builds %s directly; delegate to primary constructor %s", name, typ.Name(), primaryName)
+			}
+		}
+		return true
+	})
+
+	switch {
+	case primary == nil:
+		d := analysis.Diagnostic{
+			Pos:     fn.Name.Pos(),
+			Message: fmt.Sprintf("production factory %s has no primary constructor %s taking all dependencies", name, primaryName),
+		}
+		if len(builds) == 1 {
+			if fix, ok := extractConstructor(pass, fn, typ, builds[0], addrOf[builds[0]]); ok {
+				d.SuggestedFixes = []analysis.SuggestedFix{fix}
+			}
+		}
+		pass.Report(d)
+	case !delegates && len(builds) == 0:
+		pass.Reportf(fn.Name.Pos(), "production factory %s does not call primary constructor %s", name, primaryName)
+	}
+}
+
+// structType returns the package-level struct type with the given name, or
+// nil if there is none.
+func structType(pkg *types.Package, name string) *types.TypeName {
+	if name == "" {
+		return nil
+	}
+	tn, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if _, ok := tn.Type().Underlying().(*types.Struct); !ok {
+		return nil
+	}
+	return tn
+}
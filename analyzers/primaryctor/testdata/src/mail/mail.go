@@ -0,0 +1,41 @@
+package mail
+
+import "strings"
+
+type Sender struct {
+	host    string
+	builder *strings.Builder
+	retries int
+}
+
+// NewSenderForProduction wires the sender.
+func NewSenderForProduction(host string) *Sender { // want `production factory NewSenderForProduction has no primary constructor NewSender taking all dependencies`
+	return &Sender{host: host, builder: &strings.Builder{}, retries: 3} // want `production factory NewSenderForProduction builds Sender directly; delegate to primary constructor NewSender`
+}
+
+type Queue struct{ size int }
+
+func NewQueue(size int) *Queue { return &Queue{size: size} }
+
+func NewQueueForProduction() *Queue {
+	return NewQueue(10)
+}
+
+type Pool struct{ size int }
+
+func NewPool(size int) *Pool { return &Pool{size: size} }
+
+func NewPoolForProduction() *Pool { // want `production factory NewPoolForProduction does not call primary constructor NewPool`
+	return nil
+}
+
+type Cache struct{ size int }
+
+func NewCache(size int) *Cache { return &Cache{size: size} }
+
+func NewCacheForProduction() *Cache {
+	return &Cache{size: 1} // want `production factory NewCacheForProduction builds Cache directly; delegate to primary constructor NewCache`
+}
+
+// NewClientForProduction has no Client struct to check.
+func NewClientForProduction() *Sender { return NewSenderForProduction("x") }
@@ -0,0 +1,7 @@
+package db
+
+import "testing"
+
+func TestStore(t *testing.T) {
+	_ = NewStoreForProduction()
+}
@@ -0,0 +1,19 @@
+package orders
+
+import "testing"
+
+type Service struct{}
+
+func NewService() *Service { return &Service{} }
+
+func NewServiceForProduction() *Service { return NewService() }
+
+func wire() *Service { return NewServiceForProduction() }
+
+// TestWiring is declared outside a _test.go file, so go test never runs it.
+func TestWiring(t *testing.T) {
+	_ = NewServiceForProduction()
+}
+
+// Testify is not a test function either.
+func Testify(t *testing.T) { _ = NewServiceForProduction() }
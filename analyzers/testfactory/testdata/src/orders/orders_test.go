@@ -0,0 +1,12 @@
+package orders
+
+import "testing"
+
+func TestService(t *testing.T) {
+	_ = NewService()
+	_ = NewServiceForProduction() // want `test calls production factory NewServiceForProduction`
+}
+
+func newFixture() *Service {
+	return NewServiceForProduction() // want `test calls production factory NewServiceForProduction`
+}
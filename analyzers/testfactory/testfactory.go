@@ -0,0 +1,83 @@
+// Package testfactory defines an Analyzer that reports tests constructing
+// services through production factories.
+//
+// Unit tests must build services with the primary constructor and mocks.
+// Calling New<Type>ForProduction from a test pulls in real infrastructure and
+// defeats the point of the primary constructor.
+package testfactory
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const Doc = `report tests that call production factories
+
+Code in _test.go files must construct services with the primary constructor
+(New<Type>) and mocks, never with New<Type>ForProduction. Integration test
+packages that legitimately need real wiring can be exempted with -exempt.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "testfactory",
+	Doc:      Doc,
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// exempt is a comma-separated list of package path patterns excluded from
+// the check. A pattern ending in "/..." also matches every package below it.
+var exempt string
+
+func init() {
+	Analyzer.Flags.StringVar(&exempt, "exempt", "", "comma-separated package patterns (e.g. example.com/app/integration/...) whose tests may use production factories")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if isExempt(pass.Pkg.Path(), exempt) {
+		return nil, nil
+	}
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{(*ast.CallExpr)(nil)}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		if !strings.HasSuffix(pass.Fset.File(call.Pos()).Name(), "_test.go") {
+			return
+		}
+		callee := typeutil.StaticCallee(pass.TypesInfo, call)
+		if callee == nil || callee.Type().(*types.Signature).Recv() != nil {
+			return
+		}
+		if primary := factory.PrimaryName(callee.Name()); primary != "" {
+			pass.Reportf(call.Pos(), "test calls production factory %s; construct with primary constructor %s and mocks instead", callee.Name(), primary)
+		}
+	})
+	return nil, nil
+}
+
+// isExempt reports whether path, or the package it is an external test of,
+// matches one of the comma-separated patterns.
+func isExempt(path, patterns string) bool {
+	path = strings.TrimSuffix(path, "_test")
+	for _, p := range strings.Split(patterns, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if base, ok := strings.CutSuffix(p, "/..."); ok {
+			if path == base || strings.HasPrefix(path, base+"/") {
+				return true
+			}
+		} else if path == p {
+			return true
+		}
+	}
+	return false
+}
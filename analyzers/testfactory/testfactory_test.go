@@ -0,0 +1,19 @@
+package testfactory
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_Run(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "orders")
+}
+
+func TestAnalyzer_Run_Exempt(t *testing.T) {
+	if err := Analyzer.Flags.Set("exempt", "other, integration/..."); err != nil {
+		t.Fatal(err)
+	}
+	defer Analyzer.Flags.Set("exempt", "")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "integration/db")
+}
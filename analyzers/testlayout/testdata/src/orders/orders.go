@@ -0,0 +1,3 @@
+package orders
+
+type Order struct{ Total int }
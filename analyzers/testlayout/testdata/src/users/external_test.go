@@ -0,0 +1,10 @@
+package users_test // want `test file is in package users_test; put tests in package users with the code they test`
+
+import (
+	"testing"
+	"users"
+)
+
+func TestUser_Name(t *testing.T) {
+	_ = users.User{}
+}
@@ -0,0 +1,7 @@
+package users
+
+import "orders"
+
+type User struct{ Name string }
+
+func (u User) Orders() []orders.Order { return nil }
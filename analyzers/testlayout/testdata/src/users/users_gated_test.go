@@ -0,0 +1,7 @@
+//go:build integration
+
+package users
+
+import "testing"
+
+func TestUser_Save_Integration(t *testing.T) {}
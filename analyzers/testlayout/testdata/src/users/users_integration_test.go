@@ -0,0 +1,10 @@
+package users // want `integration test file does not require the "integration" build tag; add //go:build integration`
+
+import (
+	"orders"
+	"testing"
+)
+
+func TestOrder_Total_Integration(t *testing.T) {
+	_ = orders.Order{}
+}
@@ -0,0 +1,20 @@
+package users
+
+import (
+	"orders"
+	"testing"
+)
+
+func TestMain(m *testing.M) {}
+
+func TestUser_Orders(t *testing.T) {}
+
+func TestUser_Orders_Empty(t *testing.T) {}
+
+func TestUserOrders(t *testing.T) {} // want `test TestUserOrders is not named Test<Type>_<Method>\[_<Scenario>\]`
+
+func TestOrder_Total(t *testing.T) { // want `test TestOrder_Total tests orders.Order; move it to the tests of package orders`
+	_ = orders.Order{}
+}
+
+func helper(t *testing.T) {}
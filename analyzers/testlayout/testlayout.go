@@ -0,0 +1,188 @@
+// Package testlayout defines an Analyzer that enforces where tests live and
+// how they are named.
+//
+// The standards name tests Test<Type>_<Method>[_<Scenario>], keep them with
+// the package of the type they test, and gate integration tests behind a
+// build tag so go test ./... stays fast and hermetic.
+package testlayout
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+const Doc = `report misplaced and misnamed tests, and ungated integration tests
+
+In _test.go files this analyzer reports:
+
+  - test files in the wrong package for the -package policy: same (the
+    package under test, the default), external (its _test package), or any
+  - Test functions not named Test<Type>_<Method>, optionally followed by
+    _<Scenario>, and tests of a type declared in another package
+  - integration tests, in an integration directory, in a file named
+    *_integration_test.go or named Test*_Integration, whose file does not
+    require the -integration-tag build tag`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "testlayout",
+	Doc:  Doc,
+	Run:  run,
+}
+
+var (
+	packagePolicy  string
+	integrationTag string
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&packagePolicy, "package", "same", "package for test files: same, external or any")
+	Analyzer.Flags.StringVar(&integrationTag, "integration-tag", "integration", "build tag integration tests must require")
+}
+
+// testName matches Test<Type>_<Method>[_<Scenario>].
+var testName = regexp.MustCompile(`^Test([A-Z][A-Za-z0-9]*)_([A-Z][A-Za-z0-9]*)(_[A-Za-z0-9_]+)?$`)
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	switch packagePolicy {
+	case "same", "external", "any":
+	default:
+		return nil, fmt.Errorf("-package=%q: want same, external or any", packagePolicy)
+	}
+	external := strings.HasSuffix(pass.Pkg.Name(), "_test")
+	home := homePackage(pass.Pkg)
+
+	for _, file := range pass.Files {
+		name := pass.Fset.File(file.Pos()).Name()
+		if !strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		integration := isIntegrationFile(name)
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && strings.HasSuffix(fn.Name.Name, "_Integration") {
+				integration = true
+			}
+		}
+		// Integration tests exercise several packages from their own, so
+		// neither the package policy nor placement applies to them.
+		switch {
+		case integration:
+		case packagePolicy == "same" && external:
+			pass.Reportf(file.Name.Pos(), "test file is in package %s; put tests in package %s with the code they test", pass.Pkg.Name(), strings.TrimSuffix(pass.Pkg.Name(), "_test"))
+		case packagePolicy == "external" && !external:
+			pass.Reportf(file.Name.Pos(), "test file is in package %s; put tests in the external package %s_test", pass.Pkg.Name(), pass.Pkg.Name())
+		}
+
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || !isTest(pass, fn) {
+				continue
+			}
+			checkName(pass, fn, home, integration)
+		}
+		if integration && !requiresTag(file, integrationTag) {
+			pass.Reportf(file.Package, "integration test file does not require the %q build tag; add //go:build %s", integrationTag, integrationTag)
+		}
+	}
+	return nil, nil
+}
+
+// homePackage returns the package under test: pkg itself, or for an
+// external test package the package it tests, if imported.
+func homePackage(pkg *types.Package) *types.Package {
+	under, ok := strings.CutSuffix(pkg.Path(), "_test")
+	if !ok {
+		return pkg
+	}
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == under {
+			return imp
+		}
+	}
+	return nil
+}
+
+// isTest reports whether fn is a test: func TestXxx(*testing.T).
+func isTest(pass *analysis.Pass, fn *ast.FuncDecl) bool {
+	if !strings.HasPrefix(fn.Name.Name, "Test") || fn.Name.Name == "TestMain" {
+		return false
+	}
+	obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return false
+	}
+	params := obj.Type().(*types.Signature).Params()
+	if params.Len() != 1 {
+		return false
+	}
+	ptr, ok := params.At(0).Type().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := ptr.Elem().(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "testing" && named.Obj().Name() == "T"
+}
+
+// checkName reports a test not named Test<Type>_<Method>, or, unless it is
+// an integration test, whose Type is declared not in home but in another
+// imported package.
+func checkName(pass *analysis.Pass, fn *ast.FuncDecl, home *types.Package, integration bool) {
+	m := testName.FindStringSubmatch(fn.Name.Name)
+	if m == nil {
+		pass.Reportf(fn.Name.Pos(), "test %s is not named Test<Type>_<Method>[_<Scenario>]", fn.Name.Name)
+		return
+	}
+	typ := m[1]
+	if integration || home == nil || home.Scope().Lookup(typ) != nil {
+		return
+	}
+	for _, imp := range pass.Pkg.Imports() {
+		if _, ok := imp.Scope().Lookup(typ).(*types.TypeName); ok && imp != home {
+			pass.Reportf(fn.Name.Pos(), "test %s tests %s.%s; move it to the tests of package %s", fn.Name.Name, imp.Name(), typ, imp.Path())
+			return
+		}
+	}
+}
+
+// isIntegrationFile reports whether the test file name is an integration
+// test by its name or directory.
+func isIntegrationFile(name string) bool {
+	if strings.HasSuffix(name, "_integration_test.go") {
+		return true
+	}
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(name)), "/") {
+		if dir == "integration" {
+			return true
+		}
+	}
+	return false
+}
+
+// requiresTag reports whether the build constraint of file excludes it
+// from builds without tag.
+func requiresTag(file *ast.File, tag string) bool {
+	for _, cg := range file.Comments {
+		if cg.Pos() > file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if !constraint.IsGoBuild(c.Text) && !constraint.IsPlusBuild(c.Text) {
+				continue
+			}
+			expr, err := constraint.Parse(c.Text)
+			if err != nil {
+				continue
+			}
+			if !expr.Eval(func(t string) bool { return t != tag }) {
+				return true
+			}
+		}
+	}
+	return false
+}
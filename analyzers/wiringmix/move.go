@@ -0,0 +1,146 @@
+package wiringmix
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// move builds a fix that moves the functions fns, with their doc comments,
+// from f to the end of dest. Imports the moved code needs are added to dest,
+// and imports only the moved code used are removed from f. It reports false
+// if the source can't be read.
+func move(pass *analysis.Pass, f, dest *ast.File, fns []*ast.FuncDecl) (analysis.SuggestedFix, bool) {
+	tok := pass.Fset.File(f.Pos())
+	src, err := pass.ReadFile(tok.Name())
+	if err != nil {
+		return analysis.SuggestedFix{}, false
+	}
+
+	var edits []analysis.TextEdit
+	var moved strings.Builder
+	var spans [][2]token.Pos
+	for _, fn := range fns {
+		start := fn.Pos()
+		if fn.Doc != nil {
+			start = fn.Doc.Pos()
+		}
+		fmt.Fprintf(&moved, "\n%s\n", src[tok.Offset(start):tok.Offset(fn.End())])
+		edits = append(edits, analysis.TextEdit{Pos: start, End: lineEnd(tok, src, fn.End())})
+		spans = append(spans, [2]token.Pos{start, fn.End()})
+	}
+
+	// Which imports of f the moved code uses, and which the rest still does.
+	movedUses := make(map[*types.PkgName]bool)
+	keptUses := make(map[*types.PkgName]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		if pn, ok := pass.TypesInfo.Uses[id].(*types.PkgName); ok {
+			if within(spans, id.Pos()) {
+				movedUses[pn] = true
+			} else {
+				keptUses[pn] = true
+			}
+		}
+		return true
+	})
+
+	var missing []string
+	have := make(map[string]bool)
+	for _, spec := range dest.Imports {
+		if pn := pass.TypesInfo.PkgNameOf(spec); pn != nil {
+			have[pn.Name()+" "+pn.Imported().Path()] = true
+		}
+	}
+	for _, spec := range f.Imports {
+		pn := pass.TypesInfo.PkgNameOf(spec)
+		if pn == nil || !movedUses[pn] {
+			continue
+		}
+		if !have[pn.Name()+" "+pn.Imported().Path()] {
+			line := strconv.Quote(pn.Imported().Path())
+			if spec.Name != nil {
+				line = spec.Name.Name + " " + line
+			}
+			missing = append(missing, line)
+		}
+		if !keptUses[pn] {
+			edits = append(edits, removeImport(tok, src, f, spec))
+		}
+	}
+	if len(missing) > 0 {
+		edits = append(edits, addImports(dest, missing))
+	}
+
+	destTok := pass.Fset.File(dest.Pos())
+	end := destTok.Pos(destTok.Size())
+	edits = append(edits, analysis.TextEdit{Pos: end, End: end, NewText: []byte(moved.String())})
+	return analysis.SuggestedFix{
+		Message:   "Move the wiring to " + wireFile,
+		TextEdits: edits,
+	}, true
+}
+
+// lineEnd returns the position after the newline that ends the line of pos,
+// or the end of the file.
+func lineEnd(tok *token.File, src []byte, pos token.Pos) token.Pos {
+	off := tok.Offset(pos)
+	for off < len(src) && src[off] != '\n' {
+		off++
+	}
+	if off < len(src) {
+		off++
+	}
+	return tok.Pos(off)
+}
+
+func within(spans [][2]token.Pos, pos token.Pos) bool {
+	for _, s := range spans {
+		if s[0] <= pos && pos < s[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// removeImport deletes spec from f: its line within a parenthesized import
+// declaration, or the whole declaration otherwise.
+func removeImport(tok *token.File, src []byte, f *ast.File, spec *ast.ImportSpec) analysis.TextEdit {
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		for _, s := range gd.Specs {
+			if s != spec {
+				continue
+			}
+			if !gd.Lparen.IsValid() || len(gd.Specs) == 1 {
+				return analysis.TextEdit{Pos: gd.Pos(), End: lineEnd(tok, src, gd.End())}
+			}
+			start := tok.LineStart(tok.Line(spec.Pos()))
+			return analysis.TextEdit{Pos: start, End: lineEnd(tok, src, spec.End())}
+		}
+	}
+	return analysis.TextEdit{Pos: spec.Pos(), End: spec.End()}
+}
+
+// addImports adds the import lines to f, inside its first parenthesized
+// import declaration if it has one.
+func addImports(f *ast.File, lines []string) analysis.TextEdit {
+	for _, decl := range f.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT && gd.Lparen.IsValid() {
+			return analysis.TextEdit{Pos: gd.Rparen, End: gd.Rparen, NewText: []byte("\t" + strings.Join(lines, "\n\t") + "\n")}
+		}
+	}
+	text := "\n\nimport (\n\t" + strings.Join(lines, "\n\t") + "\n)"
+	return analysis.TextEdit{Pos: f.Name.End(), End: f.Name.End(), NewText: []byte(text)}
+}
@@ -0,0 +1,20 @@
+package billing
+
+// A file of business methods without wiring is what the standards ask for.
+type Ledger struct{ entries []int }
+
+func (l *Ledger) Add(amount int) {
+	l.entries = append(l.entries, amount)
+}
+
+func (l *Ledger) Balance() int {
+	return sum(l.entries)
+}
+
+func sum(amounts []int) int {
+	total := 0
+	for _, a := range amounts {
+		total += a
+	}
+	return total
+}
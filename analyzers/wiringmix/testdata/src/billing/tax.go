@@ -0,0 +1,13 @@
+package billing
+
+type Tax struct{ rate int }
+
+// A helper method within -max-lines is allowed next to a factory.
+// coverage:ignore
+func NewTaxForProduction() *Tax {
+	return &Tax{rate: 20}
+}
+
+func (t *Tax) Apply(amount int) int {
+	return amount * (100 + t.rate) / 100
+}
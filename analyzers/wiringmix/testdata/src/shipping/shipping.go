@@ -0,0 +1,24 @@
+package shipping
+
+import (
+	"os"
+	"strings"
+)
+
+type Shipper struct{ dir string }
+
+func NewShipper(dir string) *Shipper { return &Shipper{dir: dir} }
+
+// NewShipperForProduction wires the shipper.
+// coverage:ignore
+func NewShipperForProduction() *Shipper { // want `shipping.go mixes wiring \(NewShipperForProduction\) with 6 lines of business methods; move the wiring to wire.go`
+	return NewShipper(os.TempDir())
+}
+
+func (s *Shipper) Label(to string) string {
+	return strings.ToUpper(to)
+}
+
+func (s *Shipper) Dir() string {
+	return s.dir
+}
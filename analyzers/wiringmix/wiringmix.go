@@ -0,0 +1,109 @@
+// Package wiringmix defines an Analyzer that reports files mixing wiring
+// code with business methods.
+//
+// The standards keep infrastructure wiring apart from business logic:
+// production factories and container wiring live in a wire.go file, and
+// services keep their methods elsewhere. A file holding both makes the
+// coverage exclusion of the wiring easy to misapply, and buries wiring
+// changes in logic diffs during review.
+package wiringmix
+
+import (
+	"fmt"
+	"go/ast"
+	"path/filepath"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/internal/factory"
+	"golang.org/x/tools/go/analysis"
+)
+
+const Doc = `report files that mix wiring with business methods
+
+A file that defines production factories (New<Type>ForProduction) or DI
+wiring helpers must not also hold more than -max-lines lines of methods,
+whatever their receiver: the methods of the type a factory builds are
+exactly the business logic to keep apart from its wiring. Keep the wiring in wire.go
+and the business logic in the service's own file. When the package already
+has a wire.go, the suggested fix moves the wiring there, along with the
+imports it needs; otherwise there is no fix, as creating the file is left
+to the author.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "wiringmix",
+	Doc:  Doc,
+	Run:  run,
+}
+
+// wireFile is the file the standards put wiring in.
+const wireFile = "wire.go"
+
+// maxLines is how many lines of business methods a file with wiring may
+// hold, so a small helper method next to a factory isn't flagged.
+var maxLines int
+
+func init() {
+	Analyzer.Flags.IntVar(&maxLines, "max-lines", 40, "lines of business methods allowed in a file that also holds wiring")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	var dest *ast.File
+	for _, f := range pass.Files {
+		if filepath.Base(pass.Fset.File(f.Pos()).Name()) == wireFile {
+			dest = f
+		}
+	}
+
+	for _, f := range pass.Files {
+		name := filepath.Base(pass.Fset.File(f.Pos()).Name())
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		// Every method counts, those of the types the wiring builds too.
+		var wiring []*ast.FuncDecl
+		logic := 0
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			switch {
+			case !ok || fn.Body == nil:
+			case factory.IsProduction(fn) || factory.IsWiringHelper(fn):
+				wiring = append(wiring, fn)
+			case fn.Recv != nil:
+				logic += pass.Fset.Position(fn.End()).Line - pass.Fset.Position(fn.Pos()).Line + 1
+			}
+		}
+		if len(wiring) == 0 || logic <= maxLines {
+			continue
+		}
+
+		if f == dest {
+			pass.Reportf(wiring[0].Name.Pos(), "%s holds %d lines of business methods alongside wiring; move the methods to their service's file", wireFile, logic)
+			continue
+		}
+		d := analysis.Diagnostic{
+			Pos:     wiring[0].Name.Pos(),
+			Message: fmt.Sprintf("%s mixes wiring (%s) with %d lines of business methods; move the wiring to %s", name, names(wiring), logic, wireFile),
+		}
+		if dest != nil {
+			if fix, ok := move(pass, f, dest, wiring); ok {
+				d.SuggestedFixes = []analysis.SuggestedFix{fix}
+			}
+		}
+		pass.Report(d)
+	}
+	return nil, nil
+}
+
+// names lists the first few wiring functions by name.
+func names(fns []*ast.FuncDecl) string {
+	const shown = 3
+	var list []string
+	for i, fn := range fns {
+		if i == shown {
+			list = append(list, "...")
+			break
+		}
+		list = append(list, fn.Name.Name)
+	}
+	return strings.Join(list, ", ")
+}
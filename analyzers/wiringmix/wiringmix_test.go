@@ -0,0 +1,15 @@
+package wiringmix
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer_Run(t *testing.T) {
+	if err := Analyzer.Flags.Set("max-lines", "3"); err != nil {
+		t.Fatal(err)
+	}
+	defer Analyzer.Flags.Set("max-lines", "40")
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), Analyzer, "shipping", "billing")
+}
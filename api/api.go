@@ -0,0 +1,218 @@
+// Package api records the dependency-injection surface of Go packages and
+// compares it with a recorded baseline, so changes that break the packages
+// depending on it are caught before they merge.
+//
+// The surface of a package is the part other teams wire against:
+//
+//   - constructors: exported functions named New*, including production
+//     factories and container constructors
+//   - interfaces: exported interface types and their method sets, which
+//     callers use and implementations (such as test mocks) must satisfy
+//   - container accessors: exported methods of exported *Container types
+//
+// Signatures are recorded without parameter names, qualified by package
+// path, so renaming a parameter is not a change.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// DefaultPath is where the API baseline is recorded, relative to the
+// repository root.
+const DefaultPath = ".stdcheck-api.json"
+
+// Kind is the kind of a surface symbol.
+type Kind string
+
+const (
+	KindConstructor Kind = "constructor"
+	KindInterface   Kind = "interface"
+	KindAccessor    Kind = "accessor"
+)
+
+// Symbol is one element of a package's surface.
+type Symbol struct {
+	Name string `json:"name"` // Type.Method for accessors
+	Kind Kind   `json:"kind"`
+	// Signature is the function type of a constructor or accessor.
+	Signature string `json:"signature,omitempty"`
+	// Methods maps an interface's method names to their signatures.
+	Methods map[string]string `json:"methods,omitempty"`
+}
+
+// Package is the surface of one package.
+type Package struct {
+	Path    string   `json:"path"`
+	Symbols []Symbol `json:"symbols"` // sorted by name
+}
+
+// Lookup returns the symbol named name, if any.
+func (p Package) Lookup(name string) (Symbol, bool) {
+	i := sort.Search(len(p.Symbols), func(i int) bool { return p.Symbols[i].Name >= name })
+	if i < len(p.Symbols) && p.Symbols[i].Name == name {
+		return p.Symbols[i], true
+	}
+	return Symbol{}, false
+}
+
+// Baseline is a recorded surface of a set of packages.
+type Baseline struct {
+	// Version is the module version the surface was released as, if known.
+	// Next recommends the version that follows it.
+	Version  string    `json:"version,omitempty"`
+	Packages []Package `json:"packages"` // sorted by path
+}
+
+// Lookup returns the recorded surface of the package with import path path.
+func (b *Baseline) Lookup(path string) (Package, bool) {
+	i := sort.Search(len(b.Packages), func(i int) bool { return b.Packages[i].Path >= path })
+	if i < len(b.Packages) && b.Packages[i].Path == path {
+		return b.Packages[i], true
+	}
+	return Package{}, false
+}
+
+// NewBaseline returns a baseline of the given package surfaces.
+func NewBaseline(pkgs []Package) *Baseline {
+	b := &Baseline{Packages: append([]Package(nil), pkgs...)}
+	sort.Slice(b.Packages, func(i, j int) bool { return b.Packages[i].Path < b.Packages[j].Path })
+	return b
+}
+
+// ReadBaseline reads the baseline at path. A missing file is an error
+// satisfying errors.Is(err, os.ErrNotExist).
+func ReadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("reading API baseline %s: %w", path, err)
+	}
+	if !sort.SliceIsSorted(b.Packages, func(i, j int) bool { return b.Packages[i].Path < b.Packages[j].Path }) {
+		return nil, fmt.Errorf("reading API baseline %s: packages are not sorted by path", path)
+	}
+	if b.Version != "" && !semver.IsValid(b.Version) {
+		return nil, fmt.Errorf("reading API baseline %s: version %q is not a semantic version", path, b.Version)
+	}
+	return &b, nil
+}
+
+// Write writes b as indented JSON.
+func (b *Baseline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// Extract returns the surface of pkg. Declarations in _test.go files are
+// left out, so a package's test variant has the same surface as the package.
+func Extract(fset *token.FileSet, pkg *types.Package) Package {
+	p := Package{Path: pkg.Path(), Symbols: []Symbol{}}
+	qualifier := func(other *types.Package) string {
+		if other == pkg {
+			return ""
+		}
+		return other.Path()
+	}
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		obj := scope.Lookup(name)
+		if !obj.Exported() || isTest(fset, obj) {
+			continue
+		}
+		switch obj := obj.(type) {
+		case *types.Func:
+			if strings.HasPrefix(name, "New") {
+				p.Symbols = append(p.Symbols, Symbol{Name: name, Kind: KindConstructor, Signature: signature(obj.Signature(), qualifier)})
+			}
+		case *types.TypeName:
+			if obj.IsAlias() {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			if iface, ok := named.Underlying().(*types.Interface); ok {
+				if !iface.IsMethodSet() {
+					continue // a constraint, not an injectable dependency
+				}
+				methods := make(map[string]string)
+				for m := range iface.Methods() {
+					if m.Exported() {
+						methods[m.Name()] = signature(m.Signature(), qualifier)
+					}
+				}
+				p.Symbols = append(p.Symbols, Symbol{Name: name, Kind: KindInterface, Methods: methods})
+				continue
+			}
+			if !strings.HasSuffix(name, "Container") {
+				continue
+			}
+			for m := range named.Methods() {
+				if m.Exported() && !isTest(fset, m) {
+					p.Symbols = append(p.Symbols, Symbol{Name: name + "." + m.Name(), Kind: KindAccessor, Signature: signature(m.Signature(), qualifier)})
+				}
+			}
+		}
+	}
+	sort.Slice(p.Symbols, func(i, j int) bool { return p.Symbols[i].Name < p.Symbols[j].Name })
+	return p
+}
+
+// isTest reports whether obj is declared in a _test.go file.
+func isTest(fset *token.FileSet, obj types.Object) bool {
+	return strings.HasSuffix(fset.Position(obj.Pos()).Filename, "_test.go")
+}
+
+// signature formats sig as a function type without parameter names or
+// receiver, such as func[T any](string, ...int) (*T, error).
+func signature(sig *types.Signature, qualifier types.Qualifier) string {
+	var b strings.Builder
+	b.WriteString("func")
+	if tps := sig.TypeParams(); tps.Len() > 0 {
+		b.WriteString("[")
+		for i := range tps.Len() {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			tp := tps.At(i)
+			b.WriteString(tp.Obj().Name() + " " + types.TypeString(tp.Constraint(), qualifier))
+		}
+		b.WriteString("]")
+	}
+	b.WriteString(tuple(sig.Params(), sig.Variadic(), qualifier))
+	switch res := sig.Results(); res.Len() {
+	case 0:
+	case 1:
+		b.WriteString(" " + types.TypeString(res.At(0).Type(), qualifier))
+	default:
+		b.WriteString(" " + tuple(res, false, qualifier))
+	}
+	return b.String()
+}
+
+func tuple(t *types.Tuple, variadic bool, qualifier types.Qualifier) string {
+	parts := make([]string, t.Len())
+	for i := range t.Len() {
+		typ := t.At(i).Type()
+		if variadic && i == t.Len()-1 {
+			parts[i] = "..." + types.TypeString(typ.(*types.Slice).Elem(), qualifier)
+			continue
+		}
+		parts[i] = types.TypeString(typ, qualifier)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
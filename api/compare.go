@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Change is a difference between a recorded surface and the current one.
+type Change struct {
+	Package string
+	Kind    Kind
+	Name    string // of the symbol; Interface.Method for interface methods
+	// Old and New are the recorded and current signatures. Old is empty for
+	// an addition and New for a removal; both are empty for a whole
+	// interface added or removed.
+	Old, New string
+	Added    bool // the symbol or interface method is new
+	Removed  bool // the symbol or interface method is gone
+	// Breaking reports whether code written against the recorded surface
+	// may no longer compile against the current one.
+	Breaking bool
+}
+
+// String describes the change in a sentence, such as "constructor NewStore
+// func() *Store was removed".
+func (c Change) String() string {
+	what := string(c.Kind)
+	if c.Kind == KindAccessor {
+		what = "container accessor"
+	}
+	switch {
+	case c.Kind == KindInterface && c.Old == "" && c.New == "" && c.Added:
+		return fmt.Sprintf("interface %s was added", c.Name)
+	case c.Kind == KindInterface && c.Old == "" && c.New == "" && c.Removed:
+		return fmt.Sprintf("interface %s was removed", c.Name)
+	case c.Kind == KindInterface && c.Added:
+		return fmt.Sprintf("interface method %s %s was added, which existing implementations lack", c.Name, c.New)
+	case c.Kind == KindInterface && c.Removed:
+		return fmt.Sprintf("interface method %s %s was removed, which existing callers may use", c.Name, c.Old)
+	case c.Added:
+		return fmt.Sprintf("%s %s %s was added", what, c.Name, c.New)
+	case c.Removed:
+		return fmt.Sprintf("%s %s %s was removed", what, c.Name, c.Old)
+	}
+	if c.Kind == KindInterface {
+		what = "interface method"
+	}
+	return fmt.Sprintf("%s %s changed from %s to %s", what, c.Name, c.Old, c.New)
+}
+
+// Compare returns the changes from the recorded surface old to the current
+// surface cur of the same package, sorted by symbol name. Removing or
+// changing a constructor or accessor breaks its callers; any change to an
+// interface's method set breaks either its callers or its implementations.
+// Additions of other symbols are compatible.
+func Compare(old, cur Package) []Change {
+	var changes []Change
+	add := func(c Change) {
+		c.Package = cur.Path
+		changes = append(changes, c)
+	}
+	for _, o := range old.Symbols {
+		n, ok := cur.Lookup(o.Name)
+		if ok && n.Kind != o.Kind {
+			// A constructor turned into something else reads as a removal
+			// and an addition.
+			ok = false
+		}
+		if !ok {
+			add(Change{Kind: o.Kind, Name: o.Name, Old: o.Signature, Removed: true, Breaking: true})
+			continue
+		}
+		if o.Kind == KindInterface {
+			for _, m := range sortedKeys(o.Methods, n.Methods) {
+				name := o.Name + "." + m
+				oldSig, had := o.Methods[m]
+				newSig, has := n.Methods[m]
+				switch {
+				case !has:
+					add(Change{Kind: KindInterface, Name: name, Old: oldSig, Removed: true, Breaking: true})
+				case !had:
+					add(Change{Kind: KindInterface, Name: name, New: newSig, Added: true, Breaking: true})
+				case oldSig != newSig:
+					add(Change{Kind: KindInterface, Name: name, Old: oldSig, New: newSig, Breaking: true})
+				}
+			}
+			continue
+		}
+		if o.Signature != n.Signature {
+			add(Change{Kind: o.Kind, Name: o.Name, Old: o.Signature, New: n.Signature, Breaking: true})
+		}
+	}
+	for _, n := range cur.Symbols {
+		if o, ok := old.Lookup(n.Name); !ok || o.Kind != n.Kind {
+			add(Change{Kind: n.Kind, Name: n.Name, New: n.Signature, Added: true})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// sortedKeys returns the keys of a and b, sorted.
+func sortedKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
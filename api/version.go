@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/mod/semver"
+)
+
+// Bump is the semantic version increment that a set of changes calls for.
+type Bump int
+
+const (
+	Patch Bump = iota // the surface is unchanged
+	Minor             // only compatible additions
+	Major             // at least one breaking change
+)
+
+// String returns the name of the increment: patch, minor or major.
+func (b Bump) String() string {
+	switch b {
+	case Patch:
+		return "patch"
+	case Minor:
+		return "minor"
+	case Major:
+		return "major"
+	}
+	return "Bump(" + strconv.Itoa(int(b)) + ")"
+}
+
+// Classify returns the increment that changes call for: Major if any is
+// breaking, Minor if there are only compatible ones, and Patch if there are
+// none.
+func Classify(changes []Change) Bump {
+	b := Patch
+	for _, c := range changes {
+		if c.Breaking {
+			return Major
+		}
+		b = Minor
+	}
+	return b
+}
+
+// Next returns the version that follows the released version for an
+// increment of b. As Go modules allow, a v0 module may break compatibility
+// in a minor release, so there Major bumps the minor version and Minor the
+// patch version. A prerelease is followed by its release when that is a
+// large enough increment, so v2.0.0-rc.1 is followed by v2.0.0 even for a
+// breaking change. Build metadata is dropped.
+func Next(version string, b Bump) (string, error) {
+	if !semver.IsValid(version) {
+		return "", fmt.Errorf("version %q is not a semantic version such as v1.2.3", version)
+	}
+	canonical := strings.TrimSuffix(semver.Canonical(version), semver.Build(version))
+	pre := semver.Prerelease(canonical)
+	parts := strings.SplitN(strings.TrimPrefix(strings.TrimSuffix(canonical, pre), "v"), ".", 3)
+	var n [3]int
+	for i, p := range parts {
+		n[i], _ = strconv.Atoi(p) // valid semver has numeric parts
+	}
+	major, minor, patch := n[0], n[1], n[2]
+	if major == 0 && b > Patch {
+		b--
+	}
+	if pre != "" {
+		// The release itself is an increment of the size its zero parts allow.
+		released := Patch
+		switch {
+		case minor == 0 && patch == 0 && major > 0:
+			released = Major
+		case patch == 0:
+			released = Minor
+		}
+		if b <= released {
+			return fmt.Sprintf("v%d.%d.%d", major, minor, patch), nil
+		}
+	}
+	switch b {
+	case Major:
+		return fmt.Sprintf("v%d.0.0", major+1), nil
+	case Minor:
+		return fmt.Sprintf("v%d.%d.0", major, minor+1), nil
+	}
+	return fmt.Sprintf("v%d.%d.%d", major, minor, patch+1), nil
+}
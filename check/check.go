@@ -0,0 +1,82 @@
+// Package check holds the steps from the analyzers' findings to the
+// reported ones that stdcheck and the stdcheck-lsp language server share, so
+// an editor shows what a check run would report. Settle applies the project
+// configuration to the findings, and Filter then drops those suppressed
+// inline or accepted by the baseline.
+package check
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/config"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/suppress"
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultBaseline is the baseline file read when none is named.
+const DefaultBaseline = ".stdcheck-baseline.json"
+
+// Settle sorts and fingerprints findings, sets the severity cfg configures
+// for each, and drops those of rules cfg disables for their file. If keep is
+// not nil, it also drops the findings keep rejects, such as those outside
+// the changed regions of a diff-only run. findings is reused.
+func Settle(findings []report.Finding, cfg *config.Config, keep func(report.Finding) bool) []report.Finding {
+	report.Sort(findings)
+	report.Fingerprint(findings)
+
+	// Fingerprints are computed over every finding so they don't change
+	// when configuration or a diff-only run hides some.
+	kept := findings[:0]
+	for _, f := range findings {
+		if keep != nil && !keep(f) {
+			continue
+		}
+		if setting := cfg.For(f.Rule, f.File); setting.Enabled {
+			f.Severity = setting.Severity
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// Result is what Filter makes of a run's settled findings.
+type Result struct {
+	Findings   []report.Finding     // to report
+	Suppressed []report.Suppressed  // suppressed by a directive
+	Invalid    []suppress.Directive // lacking a rule or a reason; they suppress nothing
+	Known      []report.Finding     // accepted by the baseline
+}
+
+// Filter drops the findings that directives suppress and those that
+// baseline accepts.
+func Filter(findings []report.Finding, directives []suppress.Directive, baseline *report.Baseline) Result {
+	var r Result
+	r.Findings, r.Suppressed, r.Invalid = suppress.Apply(findings, directives)
+	r.Findings, r.Known = baseline.Filter(r.Findings)
+	return r
+}
+
+// LoadErrors joins the errors of every loaded package, if any.
+func LoadErrors(pkgs []*packages.Package) error {
+	var errs []error
+	packages.Visit(pkgs, nil, func(p *packages.Package) {
+		for _, e := range p.Errors {
+			errs = append(errs, e)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Relative returns path relative to dir, slash-separated, when it lies
+// beneath dir, and path slash-separated otherwise. Findings name their
+// files this way.
+func Relative(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,151 @@
+package check
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/config"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/suppress"
+	"golang.org/x/tools/go/packages"
+)
+
+func parseConfig(t *testing.T, yaml string) *config.Config {
+	t.Helper()
+	cfg, err := config.Parse(strings.NewReader(yaml))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestSettle_Config(t *testing.T) {
+	cfg := parseConfig(t, `
+rules:
+  godoc:
+    severity: warn
+  envaccess:
+    enabled: false
+overrides:
+  - dir: legacy
+    rules:
+      godoc:
+        enabled: false
+`)
+	findings := []report.Finding{
+		{Rule: "godoc", File: "svc/b.go", Line: 7, Message: "m"},
+		{Rule: "envaccess", File: "svc/a.go", Line: 3, Message: "m"},
+		{Rule: "godoc", File: "legacy/c.go", Line: 1, Message: "m"},
+		{Rule: "nildeps", File: "svc/a.go", Line: 9, Message: "m"},
+		{Rule: "godoc", File: "svc/a.go", Line: 2, Message: "m"},
+	}
+	got := Settle(findings, cfg, nil)
+
+	type kept struct {
+		rule, file string
+		severity   report.Severity
+	}
+	var summary []kept
+	for _, f := range got {
+		summary = append(summary, kept{f.Rule, f.File, f.Severity})
+		if f.Fingerprint == "" {
+			t.Errorf("%v has no fingerprint", f)
+		}
+	}
+	want := []kept{
+		{"godoc", "svc/a.go", report.SeverityWarn},
+		{"nildeps", "svc/a.go", report.SeverityError},
+		{"godoc", "svc/b.go", report.SeverityWarn},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("Settle() kept %v, want %v", summary, want)
+	}
+}
+
+func TestSettle_Keep(t *testing.T) {
+	cfg := parseConfig(t, "")
+	findings := []report.Finding{
+		{Rule: "godoc", File: "a.go", Line: 1, Message: "m"},
+		{Rule: "godoc", File: "a.go", Line: 5, Message: "m"},
+	}
+	all := Settle(append([]report.Finding(nil), findings...), cfg, nil)
+	got := Settle(findings, cfg, func(f report.Finding) bool { return f.Line > 1 })
+	if len(got) != 1 || got[0].Line != 5 {
+		t.Fatalf("Settle() = %v, want the finding on line 5", got)
+	}
+	// Hiding a finding doesn't change the fingerprints of the rest.
+	if got[0].Fingerprint != all[1].Fingerprint {
+		t.Errorf("fingerprint = %s, want %s as in the full run", got[0].Fingerprint, all[1].Fingerprint)
+	}
+}
+
+func TestFilter_DirectivesAndBaseline(t *testing.T) {
+	findings := Settle([]report.Finding{
+		{Rule: "godoc", File: "a.go", Line: 2, Message: "m"},
+		{Rule: "nildeps", File: "a.go", Line: 8, Message: "m"},
+		{Rule: "godoc", File: "b.go", Line: 4, Message: "m"},
+		{Rule: "envaccess", File: "b.go", Line: 6, Message: "m"},
+	}, parseConfig(t, ""), nil)
+	directives := []suppress.Directive{
+		{File: "a.go", Line: 1, Rule: "godoc", Reason: "generated"},
+		{File: "b.go", Line: 6, Rule: "envaccess"},
+	}
+	var accepted []report.Finding
+	for _, f := range findings {
+		if f.Rule == "nildeps" {
+			accepted = append(accepted, f)
+		}
+	}
+
+	r := Filter(findings, directives, report.NewBaseline(accepted))
+
+	rules := func(fs []report.Finding) []string {
+		var names []string
+		for _, f := range fs {
+			names = append(names, f.File+":"+f.Rule)
+		}
+		return names
+	}
+	if got, want := rules(r.Findings), []string{"b.go:godoc", "b.go:envaccess"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Findings = %v, want %v", got, want)
+	}
+	if len(r.Suppressed) != 1 || r.Suppressed[0].Rule != "godoc" || r.Suppressed[0].Reason != "generated" {
+		t.Errorf("Suppressed = %v, want the godoc finding in a.go", r.Suppressed)
+	}
+	if len(r.Invalid) != 1 || r.Invalid[0].Rule != "envaccess" {
+		t.Errorf("Invalid = %v, want the envaccess directive without a reason", r.Invalid)
+	}
+	if got, want := rules(r.Known), []string{"a.go:nildeps"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Known = %v, want %v", got, want)
+	}
+}
+
+func TestLoadErrors_Imports(t *testing.T) {
+	bad := &packages.Package{PkgPath: "example.com/bad", Errors: []packages.Error{{Msg: "undefined: x"}}}
+	root := &packages.Package{PkgPath: "example.com/root", Imports: map[string]*packages.Package{"example.com/bad": bad}}
+	if err := LoadErrors([]*packages.Package{root}); err == nil || !strings.Contains(err.Error(), "undefined: x") {
+		t.Errorf("LoadErrors() = %v, want the imported package's error", err)
+	}
+	if err := LoadErrors([]*packages.Package{{PkgPath: "example.com/ok"}}); err != nil {
+		t.Errorf("LoadErrors() = %v, want nil", err)
+	}
+}
+
+func TestRelative_Paths(t *testing.T) {
+	root := filepath.FromSlash("/repo")
+	tests := []struct {
+		path, want string
+	}{
+		{filepath.FromSlash("/repo/svc/a.go"), "svc/a.go"},
+		{filepath.FromSlash("/repo/a.go"), "a.go"},
+		{filepath.FromSlash("/other/a.go"), "/other/a.go"},
+		{filepath.FromSlash("/repository/a.go"), "/repository/a.go"},
+	}
+	for _, tt := range tests {
+		if got := Relative(root, tt.path); got != tt.want {
+			t.Errorf("Relative(%q, %q) = %q, want %q", root, tt.path, got, tt.want)
+		}
+	}
+}
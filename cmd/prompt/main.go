@@ -0,0 +1,193 @@
+// Command prompt renders the AI review prompts from their templates, to
+// debug them or to feed the review pipeline.
+//
+// Usage:
+//
+//	prompt render [flags] name[@version]
+//	prompt list [-dir directory]
+//
+// render executes a prompt template (see package prompt) and writes the
+// prompt to stdout. The prompt is the latest release of name unless pinned
+// with @, as in standards-compliance/review@v1.0.0 or @v1. -file names the
+// file under review; its content is read from disk.
+// -diff reads a unified diff of the changes under review from a file, or
+// from stdin with -diff=-. -standards replaces the standards text the
+// template would otherwise include, and -section narrows it to the sections
+// under the given headings. -schema replaces the findings schema. -var
+// name=value sets further variables, and may be repeated.
+//
+// list prints every prompt version with the content hash that identifies
+// it.
+//
+// prompt exits 0 on success and 2 on usage or rendering errors.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/prompt"
+)
+
+const (
+	exitOK    = 0
+	exitError = 2
+)
+
+// defaultDir is the template root, relative to the repository root.
+const defaultDir = "docs/prompts"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "render":
+			return runRender(args[1:], stdin, stdout, stderr)
+		case "list":
+			return runList(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "usage: prompt render [flags] name[@version]\n       prompt list [-dir directory]\n")
+	return exitError
+}
+
+func runRender(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("prompt render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", defaultDir, "template root `directory`")
+	file := fs.String("file", "", "`path` of the file under review")
+	diffPath := fs.String("diff", "", "unified diff `file` of the changes under review, or - for stdin")
+	standardsPath := fs.String("standards", "", "standards `file` to use instead of the template's")
+	var sections, vars listFlag
+	fs.Var(&sections, "section", "only the standards section under `heading` (repeatable)")
+	schemaPath := fs.String("schema", "", "findings JSON schema `file` to use instead of the template's")
+	fs.Var(&vars, "var", "further variable as `name=value` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: prompt render [flags] name[@version]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	data, err := renderData(*file, *diffPath, *standardsPath, sections, *schemaPath, vars, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "prompt: %v\n", err)
+		return exitError
+	}
+	catalog, err := prompt.Load(os.DirFS(*dir))
+	if err != nil {
+		fmt.Fprintf(stderr, "prompt: %v\n", err)
+		return exitError
+	}
+	if _, err := catalog.Render(stdout, fs.Arg(0), data); err != nil {
+		fmt.Fprintf(stderr, "prompt: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// renderData reads the variables named by the render flags.
+func renderData(file, diffPath, standardsPath string, sections []string, schemaPath string, vars []string, stdin io.Reader) (prompt.Data, error) {
+	var data prompt.Data
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return data, err
+		}
+		data.File = filepath.ToSlash(file)
+		data.Content = string(content)
+	}
+	switch diffPath {
+	case "":
+	case "-":
+		d, err := io.ReadAll(stdin)
+		if err != nil {
+			return data, fmt.Errorf("reading diff: %w", err)
+		}
+		data.Diff = string(d)
+	default:
+		d, err := os.ReadFile(diffPath)
+		if err != nil {
+			return data, err
+		}
+		data.Diff = string(d)
+	}
+	if standardsPath != "" {
+		s, err := os.ReadFile(standardsPath)
+		if err != nil {
+			return data, err
+		}
+		data.Standards = string(s)
+		if len(sections) > 0 {
+			var parts []string
+			for _, heading := range sections {
+				part, err := prompt.Section(data.Standards, heading)
+				if err != nil {
+					return data, fmt.Errorf("%s: %w", standardsPath, err)
+				}
+				parts = append(parts, part)
+			}
+			data.Standards = strings.Join(parts, "\n\n")
+		}
+	} else if len(sections) > 0 {
+		return data, fmt.Errorf("-section needs -standards")
+	}
+	if schemaPath != "" {
+		s, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return data, err
+		}
+		data.Schema = string(s)
+	}
+	for _, v := range vars {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok || name == "" {
+			return data, fmt.Errorf("-var %q: want name=value", v)
+		}
+		if data.Vars == nil {
+			data.Vars = make(map[string]string)
+		}
+		data.Vars[name] = value
+	}
+	return data, nil
+}
+
+func runList(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("prompt list", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("dir", defaultDir, "template root `directory`")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	catalog, err := prompt.Load(os.DirFS(*dir))
+	if err != nil {
+		fmt.Fprintf(stderr, "prompt: %v\n", err)
+		return exitError
+	}
+	for _, e := range catalog.Entries() {
+		fmt.Fprintf(stdout, "%s\t%s\t%s\n", e.Name, e.Version, e.Hash)
+	}
+	return exitOK
+}
+
+// listFlag is a flag that may be repeated, collecting its values.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ", ") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/config"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/skip"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/suppress"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// snapshot is the state of the workspace an analysis runs against: the
+// content of every open document, which takes precedence over the file on
+// disk, and the version of each.
+type snapshot struct {
+	root     string
+	overlay  map[string][]byte // by absolute filename
+	versions map[string]int
+}
+
+// fileResult is what the server publishes for one file.
+type fileResult struct {
+	version     *int // of the open document analyzed, nil if not open
+	diagnostics []diagnostic
+	fixes       []quickFix
+}
+
+// quickFix is a suggested fix of the diagnostic it resolves, converted to a
+// workspace edit.
+type quickFix struct {
+	diagnostic diagnostic
+	title      string
+	edit       workspaceEdit
+}
+
+// pending is a finding before fingerprinting, with what is needed to
+// convert it to a diagnostic.
+type pending struct {
+	fset  *token.FileSet
+	end   token.Pos
+	fixes []analysis.SuggestedFix
+}
+
+// analyzeFile runs the analyzers over the packages containing the file
+// name, and their test variants, and returns results for every file of
+// those packages, including those without findings so stale diagnostics are
+// cleared. Findings are filtered as stdcheck filters them: by the project's
+// .standards.yaml, the skip policy, //stdignore directives and the baseline
+// at the workspace root.
+func analyzeFile(snap snapshot, name string) (map[string]*fileResult, error) {
+	cfg, err := config.Load(filepath.Join(snap.root, config.DefaultPath))
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.CheckBinary(version()); err != nil {
+		return nil, fmt.Errorf("%s: %w", config.DefaultPath, err)
+	}
+	baseline, err := report.ReadBaseline(filepath.Join(snap.root, check.DefaultBaseline))
+	if err != nil {
+		return nil, err
+	}
+	var enabled []*analysis.Analyzer
+	for _, a := range analyzers.All {
+		if cfg.MaybeEnabled(a.Name) {
+			enabled = append(enabled, a)
+		}
+	}
+
+	lc := &packages.Config{
+		Mode:    packages.LoadSyntax,
+		Tests:   true,
+		Dir:     snap.root,
+		Overlay: snap.overlay,
+	}
+	loaded, err := packages.Load(lc, "file="+name)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %w", err)
+	}
+	if err := check.LoadErrors(loaded); err != nil {
+		return nil, err
+	}
+	var roots []*packages.Package
+	var names []string
+	for _, p := range loaded {
+		if len(p.GoFiles) > 0 && !strings.HasSuffix(p.PkgPath, ".test") {
+			roots = append(roots, p)
+			names = append(names, p.GoFiles...)
+		}
+	}
+	policy := skip.Default()
+	policy.Filter = cfg.Excluded
+	skipped, _, err := policy.Files(snap.root, names)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := checker.Analyze(enabled, roots, &checker.Options{Sequential: true})
+	if err != nil {
+		return nil, fmt.Errorf("running analyzers: %w", err)
+	}
+
+	// The test and non-test variants of a package report the same findings
+	// and contain the same files; keep one of each.
+	extra := make(map[report.Finding]pending)
+	var findings []report.Finding
+	var directives []suppress.Directive
+	results := make(map[string]*fileResult)
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", act.Analyzer.Name, act.Package.PkgPath, act.Err)
+		}
+		fset := act.Package.Fset
+		for _, file := range act.Package.Syntax {
+			fname := fset.File(file.Pos()).Name()
+			if results[fname] != nil {
+				continue
+			}
+			results[fname] = &fileResult{}
+			if !skipped[fname] {
+				directives = append(directives, suppress.Parse(fset, file, check.Relative(snap.root, fname))...)
+			}
+		}
+		for _, d := range act.Diagnostics {
+			pos := fset.Position(d.Pos)
+			if skipped[pos.Filename] {
+				continue
+			}
+			f := report.Finding{
+				Rule:    act.Analyzer.Name,
+				File:    check.Relative(snap.root, pos.Filename),
+				Line:    pos.Line,
+				Column:  pos.Column,
+				Message: d.Message,
+			}
+			if _, ok := extra[f]; !ok {
+				extra[f] = pending{fset: fset, end: d.End, fixes: d.SuggestedFixes}
+				findings = append(findings, f)
+			}
+		}
+	}
+	findings = check.Filter(check.Settle(findings, cfg, nil), directives, baseline).Findings
+
+	byRel := make(map[string]string, len(results))
+	for name, res := range results {
+		byRel[check.Relative(snap.root, name)] = name
+		if v, ok := snap.versions[name]; ok {
+			res.version = &v
+		}
+	}
+	texts := &contents{snap: snap, lines: make(map[string][]string)}
+	for _, f := range findings {
+		key := f
+		key.Severity, key.Fingerprint = "", ""
+		p := extra[key]
+		name := byRel[f.File]
+		res := results[name]
+		if res == nil {
+			continue
+		}
+		start := texts.position(token.Position{Filename: name, Line: f.Line, Column: f.Column})
+		d := diagnostic{
+			Range:    lspRange{Start: start, End: start},
+			Severity: lspSeverity(f.Severity),
+			Code:     f.Rule,
+			Source:   "stdcheck",
+			Message:  f.Message,
+		}
+		if p.end.IsValid() {
+			d.Range.End = texts.position(p.fset.Position(p.end))
+		}
+		res.diagnostics = append(res.diagnostics, d)
+		for _, sf := range p.fixes {
+			res.fixes = append(res.fixes, quickFix{
+				diagnostic: d,
+				title:      sf.Message,
+				edit:       texts.workspaceEdit(p.fset, sf.TextEdits),
+			})
+		}
+	}
+	return results, nil
+}
+
+// lspSeverity maps a finding's severity to a diagnostic severity.
+func lspSeverity(s report.Severity) int {
+	switch s {
+	case report.SeverityWarn:
+		return severityWarning
+	case report.SeverityInfo:
+		return severityInformation
+	default:
+		return severityError
+	}
+}
+
+// contents converts token positions to protocol positions, reading each file
+// from the snapshot's overlay or, failing that, from disk.
+type contents struct {
+	snap  snapshot
+	lines map[string][]string
+}
+
+func (c *contents) position(p token.Position) position {
+	lines, ok := c.lines[p.Filename]
+	if !ok {
+		data, ok := c.snap.overlay[p.Filename]
+		if !ok {
+			data, _ = os.ReadFile(p.Filename)
+		}
+		lines = strings.SplitAfter(string(data), "\n")
+		c.lines[p.Filename] = lines
+	}
+	if p.Line < 1 {
+		return position{}
+	}
+	if p.Line > len(lines) {
+		return position{Line: p.Line - 1}
+	}
+	line := lines[p.Line-1]
+	col := min(max(p.Column-1, 0), len(line))
+	return position{Line: p.Line - 1, Character: utf16Len(line[:col])}
+}
+
+func (c *contents) workspaceEdit(fset *token.FileSet, edits []analysis.TextEdit) workspaceEdit {
+	we := workspaceEdit{Changes: make(map[string][]textEdit)}
+	for _, te := range edits {
+		start := fset.Position(te.Pos)
+		end := start
+		if te.End.IsValid() {
+			end = fset.Position(te.End)
+		}
+		uri := pathToURI(start.Filename)
+		we.Changes[uri] = append(we.Changes[uri], textEdit{
+			Range:   lspRange{Start: c.position(start), End: c.position(end)},
+			NewText: string(te.NewText),
+		})
+	}
+	return we
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 && r <= utf8.MaxRune {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
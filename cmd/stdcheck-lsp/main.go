@@ -0,0 +1,65 @@
+// Command stdcheck-lsp is a language server that reports the standards
+// analyzers' findings as diagnostics while code is edited, instead of at
+// review time.
+//
+// Usage:
+//
+//	stdcheck-lsp [-pprof address]
+//
+// The server speaks the Language Server Protocol over stdin and stdout.
+// Point the editor's generic LSP client at it for Go files, alongside gopls;
+// for example, in VS Code with a generic LSP extension, or in GoLand with
+// the LSP4IJ plugin. The workspace root is the directory stdcheck would run
+// in: its .standards.yaml, .stdcheck-baseline.json and //stdignore
+// directives are honored exactly as stdcheck honors them.
+//
+// When a Go file is opened, edited or saved, the packages containing it and
+// their tests are analyzed, using the editor's unsaved content, and
+// diagnostics are published for every file of those packages. Severities
+// follow the configuration: error, warn and info findings are shown as
+// errors, warnings and information. Findings with a suggested fix, such as
+// extracting a missing primary constructor or marking a factory
+// coverage:ignore, offer it as a quick fix code action.
+//
+// Saving .standards.yaml or the baseline re-analyzes every open file. Load
+// and type errors are left for gopls to report; while a package has them,
+// its previous diagnostics stay in place.
+//
+// To diagnose a slow server, -pprof serves the net/http/pprof profiles on an
+// address such as localhost:6060 while it runs. Anyone who can reach the
+// address can read them, so keep it on localhost.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	_ "net/http/pprof" // registers the profile handlers on http.DefaultServeMux
+	"os"
+	"runtime/debug"
+)
+
+func main() {
+	pprofAddr := flag.String("pprof", "", "serve net/http/pprof profiles on `address`, such as localhost:6060")
+	flag.Parse()
+	if *pprofAddr != "" {
+		// Listen before serving, so a bad address fails at startup rather
+		// than when someone comes to profile.
+		l, err := net.Listen("tcp", *pprofAddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "stdcheck-lsp: %v\n", err)
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "stdcheck-lsp: serving profiles on http://%s/debug/pprof/\n", l.Addr())
+		go http.Serve(l, nil)
+	}
+	os.Exit(newServer(os.Stdin, os.Stdout).serve())
+}
+
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return ""
+}
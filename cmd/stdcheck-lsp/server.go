@@ -0,0 +1,335 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/config"
+)
+
+// debounce is how long the server waits after an edit before analyzing, so
+// typing doesn't start an analysis per keystroke.
+const debounce = 500 * time.Millisecond
+
+// Message types of window/logMessage.
+const (
+	messageError = 1
+	messageInfo  = 3
+)
+
+// document is an open text document.
+type document struct {
+	version int
+	text    []byte
+}
+
+// server holds the state of one client session. Messages are handled one at
+// a time on the reading goroutine; analyses run on a timer goroutine, one at
+// a time, and publish their results as they finish.
+type server struct {
+	conn *conn
+
+	mu       sync.Mutex
+	root     string
+	docs     map[string]*document   // by absolute filename
+	results  map[string]*fileResult // by absolute filename, from the last analysis
+	pending  map[string]bool        // files awaiting analysis
+	timer    *time.Timer
+	shutdown bool
+
+	analyzing sync.Mutex
+}
+
+func newServer(r io.Reader, w io.Writer) *server {
+	return &server{
+		conn:    newConn(r, w),
+		docs:    make(map[string]*document),
+		results: make(map[string]*fileResult),
+		pending: make(map[string]bool),
+	}
+}
+
+// serve handles messages until the client exits, and returns the process
+// exit code the protocol asks for: 0 after an orderly shutdown, 1 otherwise.
+func (s *server) serve() int {
+	for {
+		m, err := s.conn.read()
+		if err == io.EOF {
+			return 1
+		}
+		if err != nil {
+			if re, ok := err.(*rpcError); ok {
+				s.conn.reply(nil, nil, re)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "stdcheck-lsp: %v\n", err)
+			return 1
+		}
+		if m.Method == "exit" {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+		result, err := s.handle(m)
+		if m.ID != nil {
+			s.conn.reply(m.ID, result, err)
+		}
+	}
+}
+
+// handle dispatches a request or notification. Unknown notifications are
+// ignored, as the protocol requires.
+func (s *server) handle(m *message) (any, error) {
+	switch m.Method {
+	case "initialize":
+		var p initializeParams
+		if err := unmarshalParams(m, &p); err != nil {
+			return nil, err
+		}
+		return s.initialize(p), nil
+	case "shutdown":
+		s.mu.Lock()
+		s.shutdown = true
+		s.mu.Unlock()
+		return nil, nil
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := unmarshalParams(m, &p); err != nil {
+			return nil, err
+		}
+		s.open(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := unmarshalParams(m, &p); err != nil {
+			return nil, err
+		}
+		if n := len(p.ContentChanges); n > 0 {
+			// With full sync the last change holds the whole document.
+			s.open(p.TextDocument.URI, p.TextDocument.Version, p.ContentChanges[n-1].Text)
+		}
+	case "textDocument/didSave":
+		var p didSaveParams
+		if err := unmarshalParams(m, &p); err != nil {
+			return nil, err
+		}
+		s.saved(uriToPath(p.TextDocument.URI))
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := unmarshalParams(m, &p); err != nil {
+			return nil, err
+		}
+		s.close(uriToPath(p.TextDocument.URI))
+	case "textDocument/codeAction":
+		var p codeActionParams
+		if err := unmarshalParams(m, &p); err != nil {
+			return nil, err
+		}
+		return s.codeActions(p), nil
+	default:
+		if m.ID != nil {
+			return nil, &rpcError{Code: codeMethodNotFound, Message: "method not supported: " + m.Method}
+		}
+	}
+	return nil, nil
+}
+
+func unmarshalParams(m *message, v any) error {
+	if err := json.Unmarshal(m.Params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("%s: %v", m.Method, err)}
+	}
+	return nil
+}
+
+func (s *server) initialize(p initializeParams) initializeResult {
+	root := p.RootPath
+	switch {
+	case p.RootURI != "":
+		root = uriToPath(p.RootURI)
+	case len(p.WorkspaceFolders) > 0:
+		root = uriToPath(p.WorkspaceFolders[0].URI)
+	}
+	if root == "" {
+		root, _ = os.Getwd()
+	}
+	s.mu.Lock()
+	s.root = root
+	s.mu.Unlock()
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			TextDocumentSync:   textDocumentSyncOptions{OpenClose: true, Change: syncFull, Save: saveOptions{}},
+			CodeActionProvider: codeActionOptions{CodeActionKinds: []string{codeActionQuickFix}},
+		},
+		ServerInfo: serverInfo{Name: "stdcheck-lsp", Version: version()},
+	}
+}
+
+// open records the content of an opened or edited document and schedules
+// its analysis.
+func (s *server) open(uri string, version int, text string) {
+	name := uriToPath(uri)
+	if !strings.HasSuffix(name, ".go") {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[name] = &document{version: version, text: []byte(text)}
+	s.scheduleLocked(name)
+}
+
+// saved re-analyzes after a save. Saving the configuration or the baseline
+// changes what every file reports, so all open documents are re-analyzed.
+func (s *server) saved(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch filepath.Base(name) {
+	case config.DefaultPath, check.DefaultBaseline:
+		for doc := range s.docs {
+			s.scheduleLocked(doc)
+		}
+	default:
+		if strings.HasSuffix(name, ".go") {
+			s.scheduleLocked(name)
+		}
+	}
+}
+
+// close forgets a document. Its unsaved edits are discarded with it, so the
+// file is re-analyzed as it is on disk.
+func (s *server) close(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.docs[name]; !ok {
+		return
+	}
+	delete(s.docs, name)
+	if _, err := os.Stat(name); err == nil {
+		s.scheduleLocked(name)
+	}
+}
+
+func (s *server) scheduleLocked(name string) {
+	s.pending[name] = true
+	if s.timer == nil {
+		s.timer = time.AfterFunc(debounce, s.analyzePending)
+	} else {
+		s.timer.Reset(debounce)
+	}
+}
+
+// analyzePending analyzes the files scheduled since the last run. A file
+// whose package was already analyzed in this run is not analyzed again.
+func (s *server) analyzePending() {
+	s.analyzing.Lock()
+	defer s.analyzing.Unlock()
+
+	s.mu.Lock()
+	snap := snapshot{root: s.root, overlay: make(map[string][]byte), versions: make(map[string]int)}
+	for name, doc := range s.docs {
+		snap.overlay[name] = doc.text
+		snap.versions[name] = doc.version
+	}
+	names := make([]string, 0, len(s.pending))
+	for name := range s.pending {
+		names = append(names, name)
+	}
+	clear(s.pending)
+	s.mu.Unlock()
+
+	done := make(map[string]bool)
+	for _, name := range names {
+		if done[name] {
+			continue
+		}
+		results, err := analyzeFile(snap, name)
+		if err != nil {
+			// Leave the previous diagnostics in place; they are most
+			// likely still right, and the editor's Go support reports
+			// the load or type errors itself.
+			s.logMessage(messageError, fmt.Sprintf("%s: %v", check.Relative(snap.root, name), err))
+			continue
+		}
+		for file, res := range results {
+			done[file] = true
+			s.publish(file, res)
+		}
+	}
+}
+
+func (s *server) publish(name string, res *fileResult) {
+	s.mu.Lock()
+	s.results[name] = res
+	s.mu.Unlock()
+	diags := res.diagnostics
+	if diags == nil {
+		diags = []diagnostic{}
+	}
+	s.conn.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         pathToURI(name),
+		Version:     res.version,
+		Diagnostics: diags,
+	})
+}
+
+// codeActions returns the quick fixes of the diagnostics in the requested
+// range. Fixes are only offered while the document is unchanged since it was
+// analyzed, as their edits would otherwise land in the wrong place.
+func (s *server) codeActions(p codeActionParams) []codeAction {
+	name := uriToPath(p.TextDocument.URI)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	actions := []codeAction{}
+	res, doc := s.results[name], s.docs[name]
+	if res == nil || doc == nil || res.version == nil || *res.version != doc.version {
+		return actions
+	}
+	for _, fix := range res.fixes {
+		if fix.diagnostic.Range.overlaps(p.Range) {
+			actions = append(actions, codeAction{
+				Title:       fix.title,
+				Kind:        codeActionQuickFix,
+				Diagnostics: []diagnostic{fix.diagnostic},
+				Edit:        fix.edit,
+			})
+		}
+	}
+	return actions
+}
+
+func (s *server) logMessage(typ int, msg string) {
+	s.conn.notify("window/logMessage", map[string]any{"type": typ, "message": msg})
+}
+
+// uriToPath converts a file URI to a filename. Other URIs are returned
+// unchanged, and match no file.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	path := u.Path
+	if runtime.GOOS == "windows" {
+		// file:///C:/dir parses to the path /C:/dir.
+		path = strings.TrimPrefix(path, "/")
+	}
+	return filepath.Clean(filepath.FromSlash(path))
+}
+
+func pathToURI(name string) string {
+	path := filepath.ToSlash(name)
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// client is the editor's end of a session with a server that runs over
+// in-memory pipes.
+type client struct {
+	t       *testing.T
+	conn    *conn
+	msgs    chan *message
+	backlog []*message // received but not yet waited for
+	nextID  int
+	exit    chan int // the server's exit code
+}
+
+func startServer(t *testing.T) *client {
+	t.Helper()
+	toServer, fromClient := io.Pipe()
+	toClient, fromServer := io.Pipe()
+	c := &client{t: t, conn: newConn(toClient, fromClient), msgs: make(chan *message), exit: make(chan int, 1)}
+	go func() {
+		c.exit <- newServer(toServer, fromServer).serve()
+		fromServer.Close()
+	}()
+	go func() {
+		defer close(c.msgs)
+		for {
+			m, err := c.conn.read()
+			if err != nil {
+				return
+			}
+			c.msgs <- m
+		}
+	}()
+	t.Cleanup(func() { fromClient.Close() })
+	return c
+}
+
+func (c *client) send(m *message) {
+	c.t.Helper()
+	if err := c.conn.write(m); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *client) notify(method string, params any) {
+	c.t.Helper()
+	data, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.send(&message{Method: method, Params: data})
+}
+
+// call sends a request and decodes its result into result.
+func (c *client) call(method string, params, result any) {
+	c.t.Helper()
+	c.nextID++
+	id := json.RawMessage(strconv.Itoa(c.nextID))
+	data, err := json.Marshal(params)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.send(&message{ID: &id, Method: method, Params: data})
+	m := c.wait(func(m *message) bool { return m.ID != nil && string(*m.ID) == string(id) })
+	if m.Error != nil {
+		c.t.Fatalf("%s: %v", method, m.Error)
+	}
+	raw, err := json.Marshal(m.Result)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, result); err != nil {
+		c.t.Fatalf("%s result %s: %v", method, raw, err)
+	}
+}
+
+// diagnostics waits for the diagnostics published for the file name.
+func (c *client) diagnostics(name string) publishDiagnosticsParams {
+	c.t.Helper()
+	var p publishDiagnosticsParams
+	c.wait(func(m *message) bool {
+		if m.Method != "textDocument/publishDiagnostics" {
+			return false
+		}
+		p = publishDiagnosticsParams{}
+		return json.Unmarshal(m.Params, &p) == nil && p.URI == pathToURI(name)
+	})
+	return p
+}
+
+// wait returns the first message, received or still to come, that match
+// accepts.
+func (c *client) wait(match func(*message) bool) *message {
+	c.t.Helper()
+	for i, m := range c.backlog {
+		if match(m) {
+			c.backlog = append(c.backlog[:i], c.backlog[i+1:]...)
+			return m
+		}
+	}
+	timeout := time.After(time.Minute)
+	for {
+		select {
+		case m, ok := <-c.msgs:
+			if !ok {
+				c.t.Fatal("the server closed the connection")
+			}
+			if match(m) {
+				return m
+			}
+			c.backlog = append(c.backlog, m)
+		case <-timeout:
+			for _, m := range c.backlog {
+				c.t.Logf("unmatched %s %s", m.Method, m.Params)
+			}
+			c.t.Fatal("timed out waiting for the server")
+		}
+	}
+}
+
+// workspace writes a module holding a.go, documented, and a configuration
+// that lowers godoc to a warning, and returns the module's directory.
+func workspace(t *testing.T) string {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"go.mod":          "module example.com/w\n\ngo 1.22\n",
+		"a.go":            documented,
+		".standards.yaml": "rules:\n  godoc:\n    severity: warn\n",
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+const (
+	documented   = "// Package a is a test workspace.\npackage a\n\n// A does nothing.\nfunc A() {}\n"
+	undocumented = "// Package a is a test workspace.\npackage a\n\nfunc A() {}\n"
+)
+
+func TestServe_Session(t *testing.T) {
+	root := workspace(t)
+	name := filepath.Join(root, "a.go")
+	uri := pathToURI(name)
+	c := startServer(t)
+
+	var init initializeResult
+	c.call("initialize", initializeParams{RootURI: pathToURI(root)}, &init)
+	if init.ServerInfo.Name != "stdcheck-lsp" || init.Capabilities.TextDocumentSync.Change != syncFull ||
+		!reflect.DeepEqual(init.Capabilities.CodeActionProvider.CodeActionKinds, []string{codeActionQuickFix}) {
+		t.Errorf("initialize = %+v", init)
+	}
+	c.notify("initialized", struct{}{})
+
+	// The editor's unsaved content is analyzed, not the documented file on
+	// disk.
+	c.notify("textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: uri, LanguageID: "go", Version: 1, Text: undocumented}})
+	p := c.diagnostics(name)
+	if p.Version == nil || *p.Version != 1 {
+		t.Errorf("diagnostics version = %v, want 1", p.Version)
+	}
+	at := lspRange{Start: position{Line: 3, Character: 5}, End: position{Line: 3, Character: 5}}
+	want := []diagnostic{{Range: at, Severity: severityWarning, Code: "godoc", Source: "stdcheck", Message: "exported function A has no doc comment"}}
+	if !reflect.DeepEqual(p.Diagnostics, want) {
+		t.Fatalf("diagnostics = %+v, want %+v", p.Diagnostics, want)
+	}
+
+	var actions []codeAction
+	c.call("textDocument/codeAction", codeActionParams{TextDocument: textDocumentIdentifier{URI: uri}, Range: at}, &actions)
+	if len(actions) != 1 {
+		t.Fatalf("codeAction = %+v, want one quick fix", actions)
+	}
+	fix := actions[0]
+	insert := lspRange{Start: position{Line: 3}, End: position{Line: 3}}
+	if fix.Kind != codeActionQuickFix || fix.Title != "Add doc comment stub" || !reflect.DeepEqual(fix.Diagnostics, want) {
+		t.Errorf("codeAction = %+v", fix)
+	}
+	if edits := fix.Edit.Changes[uri]; len(edits) != 1 || edits[0].Range != insert || edits[0].NewText == "" {
+		t.Errorf("codeAction edits = %+v, want an insertion at %+v", fix.Edit.Changes, insert)
+	}
+	c.call("textDocument/codeAction", codeActionParams{TextDocument: textDocumentIdentifier{URI: uri}, Range: lspRange{}}, &actions)
+	if len(actions) != 0 {
+		t.Errorf("codeAction on line 1 = %+v, want none", actions)
+	}
+
+	// Once edited, the fix is stale until the document is analyzed again,
+	// and the analysis clears the diagnostic.
+	c.notify("textDocument/didChange", didChangeParams{
+		TextDocument:   versionedTextDocumentIdentifier{URI: uri, Version: 2},
+		ContentChanges: []contentChange{{Text: documented}},
+	})
+	c.call("textDocument/codeAction", codeActionParams{TextDocument: textDocumentIdentifier{URI: uri}, Range: at}, &actions)
+	if len(actions) != 0 {
+		t.Errorf("codeAction after an edit = %+v, want none", actions)
+	}
+	p = c.diagnostics(name)
+	if p.Version == nil || *p.Version != 2 || p.Diagnostics == nil || len(p.Diagnostics) != 0 {
+		t.Errorf("diagnostics after the fix = %+v, want an empty list for version 2", p)
+	}
+
+	var result any
+	c.call("shutdown", nil, &result)
+	c.notify("exit", nil)
+	select {
+	case code := <-c.exit:
+		if code != 0 {
+			t.Errorf("serve() = %d after shutdown, want 0", code)
+		}
+	case <-time.After(time.Minute):
+		t.Fatal("the server didn't exit")
+	}
+}
+
+func TestServe_UnknownMethod(t *testing.T) {
+	c := startServer(t)
+	c.nextID++
+	id := json.RawMessage("1")
+	c.send(&message{ID: &id, Method: "workspace/symbol", Params: json.RawMessage("{}")})
+	m := c.wait(func(m *message) bool { return m.ID != nil })
+	if m.Error == nil || m.Error.Code != codeMethodNotFound {
+		t.Errorf("reply = %+v, want a method not found error", m)
+	}
+	// Exiting without a shutdown request is an error.
+	c.notify("exit", nil)
+	if code := <-c.exit; code != 1 {
+		t.Errorf("serve() = %d, want 1", code)
+	}
+}
@@ -0,0 +1,500 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/config"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm/memo"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm/tokens"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/prompt"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/skip"
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultPrompt is the review prompt rendered when the configuration names
+// none.
+const defaultPrompt = "standards-compliance/review"
+
+// reviewReply is the JSON the review prompt asks the model for: a report
+// in the -format=json shape.
+type reviewReply struct {
+	Rules    []report.Rule    `json:"rules"`
+	Findings []report.Finding `json:"findings"`
+}
+
+// runReview has the configured model review each file with the rendered
+// review prompt, and reports the findings like a check.
+func runReview(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stdcheck review", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configPath := fs.String("config", config.DefaultPath, "project configuration `file`")
+	format := fs.String("format", "text", "output format: "+strings.Join(formatNames(), ", "))
+	promptDir := fs.String("prompts", "docs/prompts", "prompt template root `directory`")
+	promptRef := fs.String("prompt", "", "prompt to render, as name[@version] (default: review.prompt in the config, or "+defaultPrompt+")")
+	provider := fs.String("provider", "", "model provider, overriding review.provider: "+strings.Join(llm.Providers, ", "))
+	model := fs.String("model", "", "`model`, overriding review.model")
+	stream := fs.Bool("stream", false, "print each finding as soon as the model has written it, in text format and the order reported")
+	costReport := fs.Bool("cost-report", false, "print the tokens and estimated cost of each file, each rule and the run on stderr")
+	maxCost := fs.Float64("max-cost", 0, "stop the run when its estimated cost would exceed this many US `dollars` (0: no limit)")
+	useCache := fs.Bool("cache", true, "reuse the verdicts of unchanged files from the store configured under review.cache")
+	skipDirs := fs.String("skip-dirs", strings.Join(skip.DefaultDirs, ","), "comma-separated directory names to skip")
+	maxKB := fs.Int64("max-file-kb", skip.DefaultMaxKB, "skip files larger than this many KB (0: no limit)")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: stdcheck review [flags] files...\n\nReviews each Go file with the AI review prompt and the model configured under\nreview in %s.\n\nFlags:\n", config.DefaultPath)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	reporter, ok := reporters[*format]
+	if !ok {
+		fmt.Fprintf(stderr, "stdcheck: unknown format %q\n", *format)
+		return exitError
+	}
+	if *stream && *format != "text" {
+		fmt.Fprintf(stderr, "stdcheck: -stream prints text; it can't be combined with -format=%s\n", *format)
+		return exitError
+	}
+	if *maxCost < 0 {
+		fmt.Fprintf(stderr, "stdcheck: -max-cost must not be negative\n")
+		return exitError
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	// Files the check would skip are not reviewed either.
+	wd, _ := os.Getwd()
+	var names []string
+	for _, file := range fs.Args() {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		names = append(names, abs)
+	}
+	policy := skip.Policy{Dirs: splitList(*skipDirs), MaxKB: *maxKB, Filter: cfg.Excluded}
+	skipped, items, err := policy.Files(wd, names)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: applying skip policy: %v\n", err)
+		return exitError
+	}
+	summarizeSkipped(stderr, items)
+	var files []string
+	for _, name := range names {
+		if !skipped[name] {
+			files = append(files, name)
+		}
+	}
+
+	rc := cfg.Review
+	if *provider != "" {
+		rc.Provider = *provider
+	}
+	if *model != "" {
+		rc.Model = *model
+	}
+	client, err := reviewClient(rc)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: review: %v\n", err)
+		return exitError
+	}
+	catalog, err := prompt.Load(os.DirFS(*promptDir))
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	ref := firstNonEmpty(*promptRef, rc.Prompt, defaultPrompt)
+	used, err := catalog.Lookup(ref)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	var spend *costs
+	if *costReport || *maxCost > 0 {
+		price, ok := tokens.Lookup(rc.Provider, rc.Model)
+		if p := rc.Price; p != nil {
+			price, ok = tokens.Price{Input: p.Input, Output: p.Output}, true
+		}
+		if !ok {
+			fmt.Fprintf(stderr, "stdcheck: review: no price is known for %s model %s; set review.price in %s\n", rc.Provider, rc.Model, *configPath)
+			return exitError
+		}
+		spend = newCosts(rc.Provider, price, *maxCost)
+		if *costReport {
+			// Printed however the run ends, so an aborted run's spend shows.
+			defer spend.write(stderr, rc.Model)
+		}
+	}
+
+	var verdicts memo.Store
+	if *useCache {
+		verdicts = openVerdicts(rc.Cache)
+		if c, ok := verdicts.(io.Closer); ok {
+			defer c.Close()
+		}
+	}
+
+	ctx := context.Background()
+	var rules []report.Rule
+	var findings []report.Finding
+	var unreviewed []string // files the provider was unavailable for
+	partial := 0            // files whose streamed review broke off
+	cached := 0
+	for _, file := range files {
+		rel := check.Relative(wd, file)
+		content, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		var text bytes.Buffer
+		if used, err = catalog.Render(&text, ref, prompt.Data{File: rel, Content: string(content)}); err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		req := llm.UserPrompt(text.String())
+		req.MaxTokens = rc.MaxTokens
+		req.Temperature = rc.Temperature
+		// keep attributes a finding to the file under review, which the
+		// model may abbreviate, sets the severity the configuration gives
+		// its rule there, and reports whether the rule is enabled.
+		keep := func(f report.Finding) (report.Finding, bool) {
+			f.File = rel
+			setting := cfg.For(f.Rule, f.File)
+			f.Severity = setting.Severity
+			return f, setting.Enabled
+		}
+		key := reviewKey(used, rc, content)
+		if reply, ok, err := recall(ctx, verdicts, key); err != nil {
+			fmt.Fprintf(stderr, "stdcheck: review cache: %v; continuing without it\n", err)
+			verdicts = nil
+		} else if ok {
+			cached++
+			rules = mergeRules(rules, reply.Rules)
+			for _, f := range reply.Findings {
+				if f, ok := keep(f); ok {
+					findings = append(findings, f)
+					if *stream {
+						fmt.Fprintln(stdout, f)
+					}
+				}
+			}
+			continue
+		}
+		if spend != nil && !spend.affords(req) {
+			fmt.Fprintf(stderr, "stdcheck: review: stopped before %s: its prompt and longest allowed response could exceed the -max-cost budget of %s, with %s spent\n", rel, dollars(*maxCost), dollars(spend.spent()))
+			return exitError
+		}
+		var reply reviewReply
+		var resp llm.Response
+		var streamed []report.Finding // printed as the model wrote them
+		if *stream {
+			elems := &llm.Elements{Key: "findings", Emit: func(raw json.RawMessage) error {
+				var f report.Finding
+				if err := json.Unmarshal(raw, &f); err != nil {
+					return fmt.Errorf("decoding finding: %w", err)
+				}
+				if f, ok := keep(f); ok {
+					streamed = append(streamed, f)
+					_, err := fmt.Fprintln(stdout, f)
+					return err
+				}
+				return nil
+			}}
+			resp, err = client.StreamJSON(ctx, req, &reply, elems.Feed)
+		} else {
+			resp, err = client.CompleteJSON(ctx, req, &reply)
+		}
+		// The provider bills every response it returns, usable or not, so a
+		// failed call's response counts too.
+		if spend != nil && resp != (llm.Response{}) {
+			var charged []report.Finding
+			if err == nil {
+				charged = reply.Findings
+			}
+			spend.add(rel, req, resp, charged)
+			if spend.overBudget() {
+				fmt.Fprintf(stderr, "stdcheck: review: stopped after %s: %s spent, over the -max-cost budget of %s\n", rel, dollars(spend.spent()), dollars(*maxCost))
+				return exitError
+			}
+		}
+		if err == nil && resp.Truncated() {
+			err = fmt.Errorf("response reached the %d token limit; raise review.max_tokens", req.MaxTokens)
+		}
+		if err != nil && llm.Transient(err) && rc.Fallback != "none" && len(streamed) > 0 {
+			// Printed findings can't be taken back, and the static
+			// analyzers' would be printed on top of them, so the file keeps
+			// what the model reported before the stream broke off.
+			fmt.Fprintf(stderr, "stdcheck: reviewing %s: %v; keeping the %d finding(s) already printed\n", rel, err, len(streamed))
+			findings = append(findings, streamed...)
+			partial++
+			continue
+		}
+		if err != nil && llm.Transient(err) && rc.Fallback != "none" {
+			fmt.Fprintf(stderr, "stdcheck: reviewing %s: %v; falling back to the static analyzers\n", rel, err)
+			unreviewed = append(unreviewed, file)
+			continue
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: reviewing %s: %v\n", rel, err)
+			return exitError
+		}
+		if err := remember(ctx, verdicts, key, reply); err != nil {
+			fmt.Fprintf(stderr, "stdcheck: review cache: %v; continuing without it\n", err)
+			verdicts = nil
+		}
+		rules = mergeRules(rules, reply.Rules)
+		for _, f := range reply.Findings {
+			if f, ok := keep(f); ok {
+				findings = append(findings, f)
+			}
+		}
+	}
+	if len(unreviewed) > 0 {
+		static, staticRules, err := staticFindings(unreviewed, *configPath, *skipDirs, *maxKB, stderr)
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		rules = mergeRules(rules, staticRules)
+		findings = append(findings, static...)
+		if *stream {
+			for _, f := range static {
+				fmt.Fprintln(stdout, f)
+			}
+		}
+	}
+	report.Sort(findings)
+	report.Fingerprint(findings)
+	fmt.Fprintf(stderr, "reviewed %d file(s) with %s (%s) on %s %s", len(files)-len(unreviewed), used.Ref(), used.Hash, rc.Provider, rc.Model)
+	if cached > 0 {
+		fmt.Fprintf(stderr, ", %d of them unchanged since a cached review", cached)
+	}
+	fmt.Fprintln(stderr)
+	if partial > 0 {
+		fmt.Fprintf(stderr, "stdcheck: the provider broke off the review of %d file(s); only the findings it reported before are included\n", partial)
+	}
+	if len(unreviewed) > 0 {
+		fmt.Fprintf(stderr, "stdcheck: the provider was unavailable for %d file(s); they were checked by the static analyzers only\n", len(unreviewed))
+	}
+
+	// With -stream the findings were printed as they arrived.
+	if !*stream {
+		if err := reporter.Report(stdout, rules, findings); err != nil {
+			fmt.Fprintf(stderr, "stdcheck: writing report: %v\n", err)
+			return exitError
+		}
+	}
+	for _, f := range findings {
+		if f.Failing() {
+			return exitFindings
+		}
+	}
+	return exitClean
+}
+
+// reviewCacheVersion changes whenever the layout of cached verdicts, or what
+// a review does with them, changes.
+const reviewCacheVersion = "stdcheck-review-v1"
+
+// openVerdicts returns the store of review verdicts c configures, or nil if
+// the cache is off.
+func openVerdicts(c config.Cache) memo.Store {
+	switch c.Backend {
+	case "off":
+		return nil
+	case "redis":
+		// The run reports a failing cache once; the client would log each
+		// failed dial besides.
+		redis.SetLogger(discardLog{})
+		return &memo.Redis{Addr: c.Address, Password: c.Password(), DB: c.DB, Prefix: "stdcheck:review:", TTL: c.TTL}
+	case "bolt":
+		path := c.Path
+		if path == "" {
+			if base := defaultCacheDir(); base != "" {
+				path = filepath.Join(base, "review.db")
+			}
+		}
+		if path == "" {
+			return nil
+		}
+		return memo.Bolt(path)
+	}
+	dir := c.Dir
+	if dir == "" {
+		if base := defaultCacheDir(); base != "" {
+			dir = filepath.Join(base, "review")
+		}
+	}
+	if dir == "" {
+		return nil
+	}
+	return memo.Dir(dir)
+}
+
+// discardLog drops what the Redis client logs.
+type discardLog struct{}
+
+func (discardLog) Printf(context.Context, string, ...any) {}
+
+// reviewKey returns the cache key of the verdict on a file: a hash of the
+// prompt, the model and its settings, and the file's content. A verdict is
+// reused wherever the same content turns up, whatever the file's name.
+func reviewKey(used prompt.Entry, rc config.Review, content []byte) string {
+	temp := "default"
+	if t := rc.Temperature; t != nil {
+		temp = strconv.FormatFloat(*t, 'g', -1, 64)
+	}
+	sum := sha256.Sum256(content)
+	return memo.Key(reviewCacheVersion, used.Hash, rc.Provider, rc.Model, strconv.Itoa(rc.MaxTokens), temp, hex.EncodeToString(sum[:]))
+}
+
+// recall returns the verdict cached under key. An entry that doesn't decode
+// is a miss.
+func recall(ctx context.Context, s memo.Store, key string) (reviewReply, bool, error) {
+	var reply reviewReply
+	if s == nil {
+		return reply, false, nil
+	}
+	data, ok, err := s.Get(ctx, key)
+	if err != nil || !ok {
+		return reply, false, err
+	}
+	if err := json.Unmarshal(data, &reply); err != nil {
+		return reviewReply{}, false, nil
+	}
+	return reply, true, nil
+}
+
+// remember caches reply under key.
+func remember(ctx context.Context, s memo.Store, key string, reply reviewReply) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	return s.Put(ctx, key, data)
+}
+
+// reviewClient returns the client of the model rc configures, calling it
+// with the review's policy.
+func reviewClient(rc config.Review) (llm.Client, error) {
+	client, err := llm.New(llm.Config{Provider: rc.Provider, Model: rc.Model, BaseURL: rc.BaseURL, APIKey: rc.APIKey()}, nil)
+	if err != nil {
+		if name := rc.APIKeyVar(); name != "" && rc.APIKey() == "" {
+			err = fmt.Errorf("%w; set $%s", err, name)
+		}
+		return nil, err
+	}
+	return llm.WithPolicy(client, reviewPolicy(rc)), nil
+}
+
+// reviewPolicy returns how the review calls its provider: llm.DefaultPolicy
+// as rc adjusts it.
+func reviewPolicy(rc config.Review) llm.Policy {
+	p := llm.DefaultPolicy
+	p.RequestsPerMinute = rc.RequestsPerMinute
+	if rc.MaxRetries != nil {
+		p.MaxRetries = *rc.MaxRetries
+	}
+	if rc.BreakAfter != nil {
+		p.BreakAfter = *rc.BreakAfter
+	}
+	return p
+}
+
+// staticFindings runs the static analyzers over the packages of files, as
+// stdcheck does with the configuration at configPath and the skip policy of
+// skipDirs and maxKB, and returns their findings in files together with the
+// rules that ran.
+func staticFindings(files []string, configPath, skipDirs string, maxKB int64, stderr io.Writer) ([]report.Finding, []report.Rule, error) {
+	opts := &options{
+		configPath: configPath,
+		skipDirs:   skipDirs,
+		maxKB:      maxKB,
+		useCache:   true,
+		cacheDir:   defaultCacheDir(),
+		parallel:   runtime.GOMAXPROCS(0),
+	}
+	st, err := opts.setup()
+	if err != nil {
+		return nil, nil, err
+	}
+	wd, _ := os.Getwd()
+	wanted := make(map[string]bool)
+	seen := make(map[string]bool)
+	var patterns []string
+	for _, file := range files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		wanted[check.Relative(wd, abs)] = true
+		if dir := filepath.Dir(abs); !seen[dir] {
+			seen[dir] = true
+			patterns = append(patterns, dir)
+		}
+	}
+	all, err := reported(patterns, st, check.DefaultBaseline, false, stderr)
+	if err != nil {
+		return nil, nil, err
+	}
+	var findings []report.Finding
+	for _, f := range all {
+		if wanted[f.File] {
+			findings = append(findings, f)
+		}
+	}
+	return findings, analyzers.Rules(st.analyzers), nil
+}
+
+// mergeRules adds the rules of more not already in rules, keeping them
+// sorted by ID.
+func mergeRules(rules, more []report.Rule) []report.Rule {
+	for _, r := range more {
+		i := sort.Search(len(rules), func(i int) bool { return rules[i].ID >= r.ID })
+		if i < len(rules) && rules[i].ID == r.ID {
+			continue
+		}
+		rules = append(rules, report.Rule{})
+		copy(rules[i+1:], rules[i:])
+		rules[i] = r
+	}
+	return rules
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
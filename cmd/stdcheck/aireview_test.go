@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm/memo"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+// ollamaChunks returns the lines of a streamed Ollama response that writes
+// text in fragments of n bytes.
+func ollamaChunks(text string, n int) string {
+	var b strings.Builder
+	for len(text) > 0 {
+		frag := text[:min(n, len(text))]
+		text = text[len(frag):]
+		fmt.Fprintf(&b, "{\"model\": \"m1\", \"message\": {\"role\": \"assistant\", \"content\": %q}}\n", frag)
+	}
+	return b.String()
+}
+
+// reviewFixture writes a Go file and a configuration that points the review
+// at the Ollama server url, and returns their paths.
+func reviewFixture(t *testing.T, url string) (file, config string) {
+	t.Helper()
+	dir := t.TempDir()
+	file = filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n\nfunc A() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	config = filepath.Join(dir, ".standards.yaml")
+	yaml := "review:\n  provider: ollama\n  model: m1\n  base_url: " + url + "\n  max_retries: 0\n"
+	if err := os.WriteFile(config, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return file, config
+}
+
+func TestRunReview_Stream_BrokenOff(t *testing.T) {
+	// The model writes one finding and part of a second before the
+	// connection drops.
+	reply := `{"rules": [], "findings": [{"rule": "godoc", "line": 3, "message": "A has no doc comment"}, {"rule": "godoc", "line": 4, "mess`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Reading the request first makes the close a clean end of stream
+		// rather than a reset.
+		io.Copy(io.Discard, r.Body)
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		body := ollamaChunks(reply, 16)
+		fmt.Fprintf(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/x-ndjson\r\nContent-Length: %d\r\n\r\n%s", len(body)+100, body)
+		buf.Flush()
+	}))
+	defer srv.Close()
+	file, config := reviewFixture(t, srv.URL)
+
+	var stdout, stderr bytes.Buffer
+	code := runReview([]string{"-config", config, "-prompts", "../../docs/prompts", "-cache=false", "-stream", file}, &stdout, &stderr)
+
+	if code != exitFindings {
+		t.Errorf("runReview() = %d, want %d; stderr:\n%s", code, exitFindings, stderr.String())
+	}
+	if got := strings.Count(stdout.String(), "\n"); got != 1 || !strings.Contains(stdout.String(), ":3:0: A has no doc comment (godoc)") {
+		t.Errorf("stdout = %q, want the one complete finding", stdout.String())
+	}
+	for _, want := range []string{
+		"keeping the 1 finding(s) already printed",
+		"the provider broke off the review of 1 file(s)",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("stderr doesn't say %q:\n%s", want, stderr.String())
+		}
+	}
+	if strings.Contains(stderr.String(), "falling back") {
+		t.Errorf("the file fell back to the static analyzers after findings were printed:\n%s", stderr.String())
+	}
+}
+
+func TestStaticFindings_SkipPolicy(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	file := filepath.Join("testdata", "third_party", "legacy", "legacy.go")
+	config := filepath.Join(t.TempDir(), ".standards.yaml")
+	tests := []struct {
+		name     string
+		skipDirs string
+		want     int
+	}{
+		{"default dirs skip third_party", "vendor,third_party", 0},
+		{"the caller's dirs", "vendor", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stderr bytes.Buffer
+			findings, _, err := staticFindings([]string{file}, config, tt.skipDirs, 512, &stderr)
+			if err != nil {
+				t.Fatalf("staticFindings() error = %v; stderr:\n%s", err, stderr.String())
+			}
+			if len(findings) != tt.want {
+				t.Errorf("staticFindings() = %v, want %d finding(s)", findings, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecall_Dir(t *testing.T) {
+	ctx := context.Background()
+	s := memo.Dir(t.TempDir())
+	reply := reviewReply{Findings: []report.Finding{{Rule: "godoc", File: "a.go", Line: 3, Message: "m"}}}
+	if err := remember(ctx, s, memo.Key("a"), reply); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := recall(ctx, s, memo.Key("a")); err != nil || !ok || len(got.Findings) != 1 || got.Findings[0] != reply.Findings[0] {
+		t.Errorf("recall() = %+v, %v, %v; want %+v", got, ok, err, reply)
+	}
+	if _, ok, err := recall(ctx, s, memo.Key("b")); err != nil || ok {
+		t.Errorf("recall() of a new key = %v, %v; want a miss", ok, err)
+	}
+	// A truncated entry, as a crash mid-write on a filesystem without
+	// atomic renames might leave, is a miss rather than an error.
+	if err := s.Put(ctx, memo.Key("a"), []byte(`{"findings": [{"rule": "god`)); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok, err := recall(ctx, s, memo.Key("a")); err != nil || ok {
+		t.Errorf("recall() of a corrupt entry = %+v, %v, %v; want a miss", got, ok, err)
+	}
+}
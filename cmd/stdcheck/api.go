@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/api"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"golang.org/x/mod/semver"
+	"golang.org/x/tools/go/packages"
+)
+
+func runAPI(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "diff":
+			return runAPIDiff(args[1:], stdout, stderr)
+		case "baseline":
+			return runAPIBaseline(args[1:], stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "usage: stdcheck api diff [-baseline file] [-version v] [packages]\n       stdcheck api baseline [-baseline file] [-version v] [-dry-run] [packages]\n")
+	return exitError
+}
+
+func runAPIDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stdcheck api diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	baselinePath := fs.String("baseline", api.DefaultPath, "API baseline `file`")
+	version := fs.String("version", "", "released `version` the changes follow (default: the later of the baseline's version and the latest v* git tag)")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: stdcheck api diff [-baseline file] [-version v] [packages]\n\nLists the changes to the exported constructors, interfaces and container\naccessors since the API baseline was recorded, classifies them as a major,\nminor or patch change, and recommends the next module version.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *version != "" && !semver.IsValid(*version) {
+		fmt.Fprintf(stderr, "stdcheck: version %q is not a semantic version such as v1.2.3\n", *version)
+		return exitError
+	}
+
+	recorded, err := api.ReadBaseline(*baselinePath)
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(stderr, "stdcheck: no API baseline at %s; record one with stdcheck api baseline\n", *baselinePath)
+		return exitError
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	current, err := loadSurfaces(packagePatterns(fs))
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	gone, err := removedPackages(recorded, current)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+
+	var all []api.Change
+	breaking, changed := 0, 0
+	for _, p := range api.NewBaseline(append(current, gone...)).Packages {
+		old, _ := recorded.Lookup(p.Path)
+		changes := api.Compare(old, p)
+		if len(changes) == 0 {
+			continue
+		}
+		all = append(all, changes...)
+		changed++
+		fmt.Fprintf(stdout, "%s:\n", p.Path)
+		for _, c := range changes {
+			label := "compatible"
+			if c.Breaking {
+				label = "breaking"
+				breaking++
+			}
+			fmt.Fprintf(stdout, "  %s: %s\n", label, c)
+		}
+	}
+
+	bump := api.Classify(all)
+	released := *version
+	if released == "" {
+		released = laterVersion(recorded.Version, latestTag())
+	}
+	if released == "" {
+		fmt.Fprintf(stdout, "%s change; no released version is known, so tag a release or record one with stdcheck api baseline -version\n", bump)
+	} else {
+		next, err := api.Next(released, bump)
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "%s change since %s: next version %s\n", bump, released, next)
+	}
+	if breaking > 0 {
+		fmt.Fprintf(stderr, "%d breaking change(s) in %d changed package(s)\n", breaking, changed)
+		return exitFindings
+	}
+	return exitClean
+}
+
+func runAPIBaseline(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stdcheck api baseline", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	baselinePath := fs.String("baseline", api.DefaultPath, "API baseline `file` to write")
+	version := fs.String("version", "", "module `version` the surface is released as (default: the latest v* git tag)")
+	dryRun := fs.Bool("dry-run", false, "print the baseline instead of writing it")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: stdcheck api baseline [-baseline file] [-version v] [-dry-run] [packages]\n\nRecords the exported constructors, interfaces and container accessors of\nthe packages, and the module version they are released as, as the API\nbaseline.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	current, err := loadSurfaces(packagePatterns(fs))
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	if *version != "" && !semver.IsValid(*version) {
+		fmt.Fprintf(stderr, "stdcheck: version %q is not a semantic version such as v1.2.3\n", *version)
+		return exitError
+	}
+	b := api.NewBaseline(current)
+	b.Version = firstNonEmpty(*version, latestTag())
+	if *dryRun {
+		if err := b.Write(stdout); err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stderr, "would write the API of %d package(s) to %s\n", len(b.Packages), *baselinePath)
+		return exitClean
+	}
+	f, err := os.Create(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	err = b.Write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: writing API baseline: %v\n", err)
+		return exitError
+	}
+	if b.Version != "" {
+		fmt.Fprintf(stdout, "wrote the API of %d package(s) at %s to %s\n", len(b.Packages), b.Version, *baselinePath)
+	} else {
+		fmt.Fprintf(stdout, "wrote the API of %d package(s) to %s\n", len(b.Packages), *baselinePath)
+	}
+	return exitClean
+}
+
+// loadSurfaces loads the packages matching patterns and returns the
+// surfaces of those that have one. Commands have none.
+func loadSurfaces(patterns []string) ([]api.Package, error) {
+	fset := token.NewFileSet()
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes,
+		Fset: fset,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("loading packages: %w", err)
+	}
+	if err := check.LoadErrors(pkgs); err != nil {
+		return nil, err
+	}
+	var surfaces []api.Package
+	for _, p := range pkgs {
+		if p.Name == "main" {
+			continue
+		}
+		if s := api.Extract(fset, p.Types); len(s.Symbols) > 0 {
+			surfaces = append(surfaces, s)
+		}
+	}
+	return surfaces, nil
+}
+
+// removedPackages returns an empty surface for each package of the
+// baseline that no longer exists, so comparing reports all of it removed.
+// Recorded packages that exist but were not loaded are left out.
+func removedPackages(recorded *api.Baseline, loaded []api.Package) ([]api.Package, error) {
+	seen := make(map[string]bool, len(loaded))
+	for _, p := range loaded {
+		seen[p.Path] = true
+	}
+	var missing []string
+	for _, p := range recorded.Packages {
+		if !seen[p.Path] {
+			missing = append(missing, p.Path)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles}, missing...)
+	if err != nil {
+		return nil, fmt.Errorf("listing packages: %w", err)
+	}
+	var gone []api.Package
+	for _, p := range pkgs {
+		if len(p.Errors) > 0 || len(p.GoFiles) == 0 {
+			gone = append(gone, api.Package{Path: p.PkgPath})
+		}
+	}
+	return gone, nil
+}
+
+// latestTag returns the highest semantic version tagged in the current
+// repository, or "" if there is none or git can't list the tags.
+func latestTag() string {
+	out, err := exec.CommandContext(context.Background(), "git", "tag", "--list", "v*").Output()
+	if err != nil {
+		return ""
+	}
+	latest := ""
+	for _, tag := range strings.Fields(string(out)) {
+		if semver.IsValid(tag) && semver.Build(tag) == "" {
+			latest = laterVersion(latest, tag)
+		}
+	}
+	return latest
+}
+
+// laterVersion returns the higher of two semantic versions, either of which
+// may be "".
+func laterVersion(a, b string) string {
+	if a == "" || (b != "" && semver.Compare(b, a) > 0) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheVersion changes whenever the layout of cached results changes.
+const cacheVersion = "stdcheck-cache-v1"
+
+// cache is an on-disk store of per-directory analysis results. Entries are
+// keyed by a hash of everything that can affect a result: the stdcheck
+// binary, the analyzers, their flags and the files they read, the content
+// of the directory's packages, and transitively the content of their
+// dependencies. An entry is therefore never stale, and the cache needs no
+// invalidation; deleting the directory is always safe.
+type cache struct {
+	dir  string
+	salt []byte
+	keys map[string][]byte // package ID to content key, memoized
+}
+
+// defaultCacheDir returns the cache directory under the user's cache
+// directory.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "stdcheck")
+}
+
+// openCache returns a cache in dir for results of the analyzers as.
+func openCache(dir string, as []*analysis.Analyzer) (*cache, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("no cache directory; set -cache-dir")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache: %w", err)
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, cacheVersion)
+	if err := hashExecutable(h); err != nil {
+		return nil, fmt.Errorf("hashing stdcheck binary: %w", err)
+	}
+	for _, a := range as {
+		fmt.Fprintln(h, "analyzer", a.Name)
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(h, "flag %s=%s\n", f.Name, f.Value)
+		})
+		for _, name := range analyzers.Inputs(a) {
+			fmt.Fprintln(h, "input", name)
+			if err := hashFile(h, name); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("hashing %s: %w", name, err)
+			}
+		}
+	}
+	return &cache{dir: dir, salt: h.Sum(nil), keys: make(map[string][]byte)}, nil
+}
+
+// hashExecutable writes the running binary's content to h, so rebuilding
+// stdcheck with changed analyzers invalidates every entry.
+func hashExecutable(h io.Writer) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(exe)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// key returns the cache key for the packages pkgs in dir. Skipped files are
+// part of the key because results leave them out.
+func (c *cache) key(wd, dir string, pkgs []*packages.Package, skipped map[string]bool) (string, error) {
+	h := sha256.New()
+	h.Write(c.salt)
+	fmt.Fprintln(h, "dir", check.Relative(wd, dir))
+	sorted := append([]*packages.Package(nil), pkgs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for _, p := range sorted {
+		k, err := c.packageKey(p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "package %s %x\n", p.ID, k)
+		for _, name := range p.GoFiles {
+			if skipped[name] {
+				fmt.Fprintln(h, "skipped", filepath.Base(name))
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// packageKey hashes the content of p and, recursively, of its imports.
+// Dependencies from the module cache are identified by module version
+// rather than hashed, since their content cannot change.
+func (c *cache) packageKey(p *packages.Package) ([]byte, error) {
+	if k, ok := c.keys[p.ID]; ok {
+		return k, nil
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, "id", p.ID)
+	if m := p.Module; m != nil && !m.Main && m.Replace == nil && m.Version != "" {
+		fmt.Fprintf(h, "module %s@%s\n", m.Path, m.Version)
+	} else {
+		files := append(append([]string(nil), p.GoFiles...), p.OtherFiles...)
+		for _, name := range files {
+			if err := hashFile(h, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	paths := make([]string, 0, len(p.Imports))
+	for path := range p.Imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		k, err := c.packageKey(p.Imports[path])
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(h, "import %s %x\n", path, k)
+	}
+	k := h.Sum(nil)
+	c.keys[p.ID] = k
+	return k, nil
+}
+
+func hashFile(h hash.Hash, name string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fmt.Fprintln(h, "file", filepath.Base(name))
+	_, err = io.Copy(h, f)
+	return err
+}
+
+func (c *cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the cached result for key, if any. Unreadable entries are
+// treated as misses.
+func (c *cache) get(key string) (*dirResult, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var dr dirResult
+	if err := json.Unmarshal(data, &dr); err != nil {
+		return nil, false
+	}
+	return &dr, true
+}
+
+// put stores the result for key. It writes to a temporary file and renames
+// it, so concurrent runs never read a partial entry.
+func (c *cache) put(key string, dr *dirResult) error {
+	data, err := json.Marshal(dr)
+	if err != nil {
+		return err
+	}
+	name := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
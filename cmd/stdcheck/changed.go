@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/diff"
+	"golang.org/x/tools/go/packages"
+)
+
+// changes restricts a run to the code touched by a diff. A changed line
+// inside a declaration marks the whole declaration, including its doc
+// comment, as changed: findings anywhere in a function that was edited are
+// reported, so an edit can't hide behind an untouched line of the same
+// function.
+type changes struct {
+	files   map[string]diff.File    // by path relative to the working directory
+	regions map[string][]diff.Range // by path; filled in by addDecls
+}
+
+// changesSince resolves the git changes in the working directory since it
+// diverged from base, including uncommitted ones and untracked files.
+func changesSince(base string) (*changes, error) {
+	files, err := diff.Git{Base: base, Relative: true}.Changes(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("resolving changes since %s: %w", base, err)
+	}
+	c := &changes{
+		files:   make(map[string]diff.File, len(files)),
+		regions: make(map[string][]diff.Range),
+	}
+	for _, f := range files {
+		c.files[f.Path] = f
+	}
+	return c, nil
+}
+
+// selectPackages keeps the packages in directories that contain changed
+// files, so only those are fully loaded and analyzed.
+func (c *changes) selectPackages(pkgs []*packages.Package, wd string) []*packages.Package {
+	dirs := make(map[string]bool)
+	for _, p := range pkgs {
+		for _, name := range p.GoFiles {
+			if _, ok := c.files[check.Relative(wd, name)]; ok {
+				dirs[filepath.Dir(name)] = true
+			}
+		}
+	}
+	var selected []*packages.Package
+	for _, p := range pkgs {
+		if dir := packageDir(p); dir != "" && dirs[dir] {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
+
+// addDecls records the changed regions of the file at rel, given the line
+// spans of its top-level declarations.
+func (c *changes) addDecls(rel string, decls []diff.Range) {
+	df, ok := c.files[rel]
+	if !ok {
+		return
+	}
+	if _, done := c.regions[rel]; done {
+		return
+	}
+	// Changed lines outside any declaration (package clause, imports,
+	// comments) count as themselves.
+	regions := append([]diff.Range{}, df.Lines...)
+	for _, r := range decls {
+		if df.Overlaps(r.Start, r.End) {
+			regions = append(regions, r)
+		}
+	}
+	c.regions[rel] = regions
+}
+
+// declSpans returns the line spans of file's top-level declarations,
+// including their doc comments.
+func declSpans(fset *token.FileSet, file *ast.File) []diff.Range {
+	var spans []diff.Range
+	for _, decl := range file.Decls {
+		start := decl.Pos()
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Doc != nil {
+			start = fn.Doc.Pos()
+		}
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Doc != nil {
+			start = gd.Doc.Pos()
+		}
+		spans = append(spans, diff.Range{Start: fset.Position(start).Line, End: fset.Position(decl.End()).Line})
+	}
+	return spans
+}
+
+// touches reports whether line of the file at rel lies in a changed region.
+func (c *changes) touches(rel string, line int) bool {
+	for _, r := range c.regions[rel] {
+		if r.Start <= line && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/diff"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/skip"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/suppress"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// checkPackages runs the analyzers over the packages matching patterns. Findings are
+// de-duplicated across the test and non-test variants of a package and
+// returned in position order with fingerprints and severities set, together
+// with the inline suppression directives found in the analyzed files and the
+// files excluded by policy. Findings of rules the configuration disables for
+// their file, and in diff-only runs findings outside the changed regions, are
+// dropped.
+func checkPackages(patterns []string, st setup) ([]report.Finding, []suppress.Directive, []skip.Item, error) {
+	res, err := analyze(patterns, st)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	seen := make(map[report.Finding]bool)
+	var findings []report.Finding
+	var directives []suppress.Directive
+	for _, dr := range res.dirs {
+		directives = append(directives, dr.Directives...)
+		for _, f := range dr.Findings {
+			if !seen[f] {
+				seen[f] = true
+				findings = append(findings, f)
+			}
+		}
+		if st.changed != nil {
+			for rel, spans := range dr.Decls {
+				st.changed.addDecls(rel, spans)
+			}
+		}
+	}
+	return settle(findings, st), directives, res.skippedItems, nil
+}
+
+// settle applies the configuration to findings and, in diff-only runs,
+// drops those outside the changed regions.
+func settle(findings []report.Finding, st setup) []report.Finding {
+	var keep func(report.Finding) bool
+	if st.changed != nil {
+		keep = func(f report.Finding) bool { return st.changed.touches(f.File, f.Line) }
+	}
+	return check.Settle(findings, st.config, keep)
+}
+
+// dirResult is the outcome of analyzing the packages in one directory: a
+// package and its test variants. It is the unit the cache stores.
+type dirResult struct {
+	Findings   []report.Finding        `json:"findings"` // File relative to the working directory
+	Directives []suppress.Directive    `json:"directives"`
+	Decls      map[string][]diff.Range `json:"decls"` // top-level declaration spans by file
+}
+
+// result is the outcome of running the analyzers over a set of packages.
+type result struct {
+	// roots holds the root actions of the packages analyzed in this run;
+	// packages whose results came from the cache are not in it.
+	roots []*checker.Action
+	// dirs holds the results of every directory, analyzed or cached.
+	dirs []*dirResult
+	// skipped holds the filenames excluded by the skip policy; diagnostics
+	// in them are dropped.
+	skipped map[string]bool
+	// skippedItems lists the skipped files for the run summary.
+	skippedItems []skip.Item
+}
+
+// analyze lists the packages matching patterns, including their tests, and
+// runs the selected analyzers over them. Packages whose files are all
+// excluded by policy are not analyzed at all, nor in diff-only runs are
+// packages without changed files. When st has a cache, directories whose
+// packages and dependencies are unchanged since a cached run are not loaded
+// or analyzed; their cached results are used instead.
+func analyze(patterns []string, st setup) (*result, error) {
+	wd, _ := os.Getwd()
+	pkgs, err := listPackages(patterns)
+	if err != nil {
+		return nil, err
+	}
+	if st.changed != nil {
+		pkgs = st.changed.selectPackages(pkgs, wd)
+	}
+
+	var names []string
+	for _, p := range pkgs {
+		names = append(names, p.GoFiles...)
+	}
+	skipped, items, err := st.policy.Files(wd, names)
+	if err != nil {
+		return nil, fmt.Errorf("applying skip policy: %w", err)
+	}
+	res := &result{skipped: skipped, skippedItems: items}
+
+	byDir := make(map[string][]*packages.Package)
+	var dirs []string
+	for _, p := range pkgs {
+		dir := packageDir(p)
+		if dir == "" || !hasUnskipped(p, skipped) {
+			continue
+		}
+		if byDir[dir] == nil {
+			dirs = append(dirs, dir)
+		}
+		byDir[dir] = append(byDir[dir], p)
+	}
+	sort.Strings(dirs)
+
+	keys := make(map[string]string)
+	var missing []string
+	for _, dir := range dirs {
+		if st.cache != nil {
+			key, err := st.cache.key(wd, dir, byDir[dir], skipped)
+			if err != nil {
+				return nil, err
+			}
+			if dr, ok := st.cache.get(key); ok {
+				res.dirs = append(res.dirs, dr)
+				st.metrics.fromCache()
+				continue
+			}
+			keys[dir] = key
+		}
+		missing = append(missing, dir)
+	}
+	if len(missing) == 0 {
+		return res, nil
+	}
+
+	fresh, roots, err := analyzeDirs(missing, st, skipped, wd)
+	if err != nil {
+		return nil, err
+	}
+	res.roots = roots
+	for _, dir := range missing {
+		dr := fresh[dir]
+		if dr == nil {
+			dr = new(dirResult)
+		}
+		res.dirs = append(res.dirs, dr)
+		if st.cache != nil {
+			// The cache is an optimisation; failing to write it is not
+			// worth failing the run.
+			_ = st.cache.put(keys[dir], dr)
+		}
+	}
+	return res, nil
+}
+
+// maxBatch is the most directories loaded and analyzed together. Loading in
+// batches bounds memory, since only the syntax and types of the batches in
+// flight are held at once.
+const maxBatch = 16
+
+// analyzeDirs loads and analyzes the packages in dirs, in batches, running at
+// most st.parallel batches at a time. Results do not depend on scheduling:
+// they are merged in dirs order, and the reported error is that of the first
+// failing batch in that order.
+func analyzeDirs(dirs []string, st setup, skipped map[string]bool, wd string) (map[string]*dirResult, []*checker.Action, error) {
+	parallel := max(st.parallel, 1)
+	size := min(maxBatch, (len(dirs)+parallel-1)/parallel)
+	var batches [][]string
+	for len(dirs) > 0 {
+		n := min(size, len(dirs))
+		batches = append(batches, dirs[:n])
+		dirs = dirs[n:]
+	}
+
+	type outcome struct {
+		results map[string]*dirResult
+		roots   []*checker.Action
+		err     error
+	}
+	outcomes := make([]outcome, len(batches))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			o := &outcomes[i]
+			o.results, o.roots, o.err = analyzeBatch(batch, st, skipped, wd)
+		}()
+	}
+	wg.Wait()
+
+	results := make(map[string]*dirResult)
+	var roots []*checker.Action
+	for _, o := range outcomes {
+		if o.err != nil {
+			return nil, nil, o.err
+		}
+		for dir, dr := range o.results {
+			results[dir] = dr
+		}
+		roots = append(roots, o.roots...)
+	}
+	return results, roots, nil
+}
+
+// analyzeBatch loads the packages in dirs, including their tests, and runs
+// the selected analyzers over them sequentially; concurrency comes from
+// running batches side by side.
+func analyzeBatch(dirs []string, st setup, skipped map[string]bool, wd string) (map[string]*dirResult, []*checker.Action, error) {
+	cfg := &packages.Config{Mode: packages.LoadSyntax, Tests: true}
+	loaded, err := packages.Load(cfg, dirs...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading packages: %w", err)
+	}
+	if err := check.LoadErrors(loaded); err != nil {
+		return nil, nil, err
+	}
+	var roots []*packages.Package
+	for _, p := range loaded {
+		if packageDir(p) != "" && hasUnskipped(p, skipped) {
+			roots = append(roots, p)
+		}
+	}
+
+	graph, err := checker.Analyze(st.analyzers, roots, &checker.Options{Sequential: true})
+	if err != nil {
+		return nil, nil, fmt.Errorf("running analyzers: %w", err)
+	}
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return nil, nil, fmt.Errorf("%s: %s: %w", act.Analyzer.Name, act.Package.PkgPath, act.Err)
+		}
+	}
+	return collect(graph.Roots, wd, skipped), graph.Roots, nil
+}
+
+// collect groups the diagnostics, suppression directives and declaration
+// spans of the analyzed packages by directory, leaving out skipped files.
+func collect(roots []*checker.Action, wd string, skipped map[string]bool) map[string]*dirResult {
+	out := make(map[string]*dirResult)
+	parsed := make(map[string]bool)
+	for _, act := range roots {
+		dir := packageDir(act.Package)
+		dr := out[dir]
+		if dr == nil {
+			dr = &dirResult{Decls: make(map[string][]diff.Range)}
+			out[dir] = dr
+		}
+		fset := act.Package.Fset
+		for _, file := range act.Package.Syntax {
+			name := fset.File(file.Pos()).Name()
+			if parsed[name] || skipped[name] {
+				continue
+			}
+			parsed[name] = true
+			dr.Directives = append(dr.Directives, suppress.Parse(fset, file, check.Relative(wd, name))...)
+			dr.Decls[check.Relative(wd, name)] = declSpans(fset, file)
+		}
+		for _, d := range act.Diagnostics {
+			pos := fset.Position(d.Pos)
+			if skipped[pos.Filename] {
+				continue
+			}
+			dr.Findings = append(dr.Findings, report.Finding{
+				Rule:    act.Analyzer.Name,
+				File:    check.Relative(wd, pos.Filename),
+				Line:    pos.Line,
+				Column:  pos.Column,
+				Message: d.Message,
+			})
+		}
+	}
+	return out
+}
+
+// listPackages lists the packages matching patterns, with their tests and
+// dependencies, without parsing or type-checking them.
+func listPackages(patterns []string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedModule,
+		Tests: true,
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("listing packages: %w", err)
+	}
+	if err := check.LoadErrors(pkgs); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// packageDir returns the directory of p's source files, or "" for packages
+// without any, such as generated test mains.
+func packageDir(p *packages.Package) string {
+	if len(p.GoFiles) == 0 || strings.HasSuffix(p.PkgPath, ".test") {
+		return ""
+	}
+	return filepath.Dir(p.GoFiles[0])
+}
+
+// hasUnskipped reports whether any of p's files escaped the skip policy.
+func hasUnskipped(p *packages.Package, skipped map[string]bool) bool {
+	for _, name := range p.GoFiles {
+		if !skipped[name] {
+			return true
+		}
+	}
+	return false
+}
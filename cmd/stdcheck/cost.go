@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm/tokens"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+// overheadRule is the row of the per-rule cost report holding what no
+// finding accounts for: the prompt, and output other than findings.
+const overheadRule = "(prompt and other output)"
+
+// fileCost is what reviewing one file cost.
+type fileCost struct {
+	File  string
+	Usage llm.Usage
+	Cost  float64
+}
+
+// costs tallies the spend of a review run against its budget.
+type costs struct {
+	provider string
+	price    tokens.Price
+	limit    float64 // in US dollars; 0 means no limit
+	files    []fileCost
+	rules    map[string]float64
+	findings map[string]int
+}
+
+func newCosts(provider string, price tokens.Price, limit float64) *costs {
+	return &costs{provider: provider, price: price, limit: limit, rules: make(map[string]float64), findings: make(map[string]int)}
+}
+
+// spent returns the cost of the files reviewed so far.
+func (c *costs) spent() float64 {
+	total := 0.0
+	for _, f := range c.files {
+		total += f.Cost
+	}
+	return total
+}
+
+// affords reports whether sending req stays within the budget. The output
+// can't be known in advance, so it reserves the longest response req allows
+// besides the estimated input.
+func (c *costs) affords(req llm.Request) bool {
+	if c.limit == 0 {
+		return true
+	}
+	output := req.MaxTokens
+	if output <= 0 {
+		output = llm.DefaultMaxTokens
+	}
+	worst := c.price.Cost(llm.Usage{InputTokens: tokens.EstimateRequest(c.provider, req), OutputTokens: output})
+	return c.spent()+worst <= c.limit
+}
+
+// overBudget reports whether the spend so far exceeds the budget.
+func (c *costs) overBudget() bool {
+	return c.limit > 0 && c.spent() > c.limit
+}
+
+// add records the cost of reviewing file, answering req with resp. Each
+// finding is charged the output tokens its JSON takes, and the rest of the
+// file's cost goes to overheadRule.
+func (c *costs) add(file string, req llm.Request, resp llm.Response, findings []report.Finding) {
+	u := tokens.Count(c.provider, req, resp)
+	fc := fileCost{File: file, Usage: u, Cost: c.price.Cost(u)}
+	c.files = append(c.files, fc)
+
+	rest := fc.Cost
+	for _, f := range findings {
+		data, _ := json.Marshal(f)
+		share := min(c.price.Cost(llm.Usage{OutputTokens: tokens.Estimate(c.provider, string(data))}), rest)
+		c.rules[f.Rule] += share
+		c.findings[f.Rule]++
+		rest -= share
+	}
+	c.rules[overheadRule] += rest
+}
+
+// write prints the cost of each file, of each rule, and of the run.
+func (c *costs) write(w io.Writer, model string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "cost of %s at $%.2f/$%.2f per million input/output tokens:\n", model, c.price.Input, c.price.Output)
+	fmt.Fprintf(tw, "file\tinput\toutput\tcost\n")
+	var total llm.Usage
+	for _, f := range c.files {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", f.File, f.Usage.InputTokens, f.Usage.OutputTokens, dollars(f.Cost))
+		total.InputTokens += f.Usage.InputTokens
+		total.OutputTokens += f.Usage.OutputTokens
+	}
+	fmt.Fprintf(tw, "total\t%d\t%d\t%s\n", total.InputTokens, total.OutputTokens, dollars(c.spent()))
+	tw.Flush()
+
+	rules := make([]string, 0, len(c.rules))
+	for r := range c.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if c.rules[rules[i]] != c.rules[rules[j]] {
+			return c.rules[rules[i]] > c.rules[rules[j]]
+		}
+		return rules[i] < rules[j]
+	})
+	if len(rules) > 0 {
+		fmt.Fprintf(tw, "\nrule\tfindings\tcost\n")
+		for _, r := range rules {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", r, c.findings[r], dollars(c.rules[r]))
+		}
+		tw.Flush()
+	}
+	if c.limit > 0 {
+		fmt.Fprintf(w, "\nspent %s of the %s budget\n", dollars(c.spent()), dollars(c.limit))
+	}
+}
+
+func dollars(v float64) string {
+	return fmt.Sprintf("$%.4f", v)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm/tokens"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+func TestCosts_Affords(t *testing.T) {
+	// $1 per million tokens either way, so a dollar buys a million tokens.
+	price := tokens.Price{Input: 1, Output: 1}
+	// 4,000 characters are 1,000 input tokens, and the message framing a
+	// few more.
+	prompt := llm.UserPrompt(strings.Repeat("x", 4000))
+	input := tokens.EstimateRequest(llm.OpenAI, prompt)
+	// budget is the cost of the prompt and a response of output tokens.
+	budget := func(output int) float64 {
+		return price.Cost(llm.Usage{InputTokens: input, OutputTokens: output})
+	}
+	withMax := func(n int) llm.Request {
+		req := prompt
+		req.MaxTokens = n
+		return req
+	}
+	tests := []struct {
+		name  string
+		limit float64
+		spent llm.Usage
+		req   llm.Request
+		want  bool
+	}{
+		{"no limit", 0, llm.Usage{InputTokens: 1e9}, withMax(1000), true},
+		{"input and output fit", budget(1000), llm.Usage{}, withMax(1000), true},
+		{"input fits but output may not", budget(1000), llm.Usage{}, withMax(1001), false},
+		{"default max tokens reserved", budget(1000), llm.Usage{}, withMax(0), false},
+		{"default max tokens fit", budget(llm.DefaultMaxTokens), llm.Usage{}, withMax(0), true},
+		{"spend counts", budget(2000), llm.Usage{InputTokens: 1001}, withMax(1000), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCosts(llm.OpenAI, price, tt.limit)
+			if tt.spent != (llm.Usage{}) {
+				c.add("a.go", prompt, llm.Response{Usage: tt.spent}, nil)
+			}
+			if got := c.affords(tt.req); got != tt.want {
+				t.Errorf("affords() = %v, want %v (limit $%g, spent $%g)", got, tt.want, tt.limit, c.spent())
+			}
+		})
+	}
+}
+
+func TestCosts_Add(t *testing.T) {
+	c := newCosts(llm.OpenAI, tokens.Price{Input: 1, Output: 2}, 0.0015)
+	findings := []report.Finding{{Rule: "godoc", File: "a.go", Line: 1, Message: "m"}}
+	c.add("a.go", llm.UserPrompt("x"), llm.Response{Usage: llm.Usage{InputTokens: 500, OutputTokens: 250}}, findings)
+	if got, want := c.spent(), 0.001; got != want {
+		t.Errorf("spent() = %v, want %v", got, want)
+	}
+	if c.overBudget() {
+		t.Error("overBudget() after $0.001 of $0.0015")
+	}
+	if c.rules["godoc"] <= 0 || c.findings["godoc"] != 1 {
+		t.Errorf("godoc charged $%g for %d finding(s), want a share for 1", c.rules["godoc"], c.findings["godoc"])
+	}
+	if sum := c.rules["godoc"] + c.rules[overheadRule]; sum < 0.001-1e-12 || sum > 0.001+1e-12 {
+		t.Errorf("rules are charged $%g in all, want the file's $0.001", sum)
+	}
+
+	c.add("b.go", llm.UserPrompt("x"), llm.Response{Usage: llm.Usage{InputTokens: 500, OutputTokens: 250}}, nil)
+	if !c.overBudget() {
+		t.Error("overBudget() = false after $0.002 of $0.0015")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers/godoc"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/config"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/prompt"
+)
+
+// draftPrompt is the prompt that drafts a doc comment.
+const draftPrompt = "standards-compliance/godoc"
+
+// drafter replaces the comment stubs that the godoc fix inserts with
+// comments the model configured under review drafts.
+type drafter struct {
+	client  llm.Client
+	catalog *prompt.Catalog
+	rc      config.Review
+	warn    io.Writer
+	failed  bool // set after the first failure; later stubs are left as they are
+}
+
+// draft replaces the stub in pf, a fix to src, with a drafted comment. If
+// the model can't draft one, the stub is kept and a warning written.
+func (d *drafter) draft(ctx context.Context, pf pendingFix, src []byte, rel string) pendingFix {
+	if d.failed || pf.rule != godoc.Analyzer.Name {
+		return pf
+	}
+	name := pf.files[0]
+	edits := pf.edits[name]
+	if len(edits) != 1 {
+		return pf
+	}
+	e := edits[0]
+	decl, ok := godoc.StubName(e.text)
+	if !ok {
+		return pf
+	}
+	indent := e.text[strings.LastIndex(e.text, "\n")+1:]
+	line := bytes.Count(src[:e.start], []byte("\n")) + 1
+
+	var text bytes.Buffer
+	data := prompt.Data{File: filepath.ToSlash(rel), Content: string(src), Vars: map[string]string{"name": decl, "line": strconv.Itoa(line)}}
+	if _, err := d.catalog.Render(&text, draftPrompt, data); err != nil {
+		d.fail(decl, rel, err)
+		return pf
+	}
+	req := llm.UserPrompt(text.String())
+	req.MaxTokens = d.rc.MaxTokens
+	req.Temperature = d.rc.Temperature
+	resp, err := d.client.Complete(ctx, req)
+	if err == nil && resp.Truncated() {
+		err = fmt.Errorf("response reached the %d token limit; raise review.max_tokens", req.MaxTokens)
+	}
+	if err != nil {
+		d.fail(decl, rel, err)
+		return pf
+	}
+	comment := docComment(resp.Text, indent)
+	if comment == "" {
+		d.fail(decl, rel, fmt.Errorf("the response is empty"))
+		return pf
+	}
+	e.text = comment
+	pf.edits = map[string][]edit{name: {e}}
+	pf.message = "Add drafted doc comment"
+	return pf
+}
+
+func (d *drafter) fail(decl, rel string, err error) {
+	fmt.Fprintf(d.warn, "stdcheck: drafting the doc comment of %s in %s: %v; leaving comment stubs\n", decl, rel, err)
+	d.failed = true
+}
+
+// docComment formats a model's drafted text as a line comment for a
+// declaration indented by indent. A code fence or comment markers around
+// the text are dropped. It returns "" if there is no text.
+func docComment(text, indent string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		_, text, _ = strings.Cut(text, "\n")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+		if line == "" {
+			b.WriteString("//\n" + indent)
+		} else {
+			b.WriteString("// " + line + "\n" + indent)
+		}
+	}
+	if strings.TrimSpace(b.String()) == "//" {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/evals"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"golang.org/x/tools/go/analysis"
+)
+
+// defaultCorpus is the annotated sample corpus, relative to the repository
+// root.
+var defaultCorpus = []string{
+	"docs/prompts/standards-compliance/sample-correct.go",
+	"docs/prompts/standards-compliance/sample-violations.go",
+}
+
+func runEval(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stdcheck eval", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	rules := fs.String("rules", "", "comma-separated rules to score (default: every rule the corpus expects)")
+	input := fs.String("input", "", "score the findings of this JSON report `file`, such as the AI review's, instead of running the analyzers")
+	golden := fs.String("golden", "", "compare the scores with this golden `file`")
+	update := fs.Bool("update", false, "rewrite the -golden file with the scores")
+	minPrecision := fs.Float64("min-precision", 0, "fail if the total precision is below this `fraction`")
+	minRecall := fs.Float64("min-recall", 0, "fail if the total recall is below this `fraction`")
+	verbose := fs.Bool("v", false, "also list the findings the corpus makes no claim about")
+	registerAnalyzerFlags(fs, analyzers.All)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: stdcheck eval [flags] [files]\n\nScores the analyzers, or a JSON report, against the annotated sample corpus.\nFiles default to %s.\n\nFlags:\n", defaultCorpus)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if *update && *golden == "" {
+		fmt.Fprintf(stderr, "stdcheck: -update needs -golden\n")
+		return exitError
+	}
+
+	files := defaultCorpus
+	if fs.NArg() > 0 {
+		files = nil
+		for _, f := range fs.Args() {
+			files = append(files, filepath.ToSlash(f))
+		}
+	}
+	corpus, err := evals.Load(".", files)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	scored := splitList(*rules)
+	if len(scored) == 0 {
+		scored = corpus.Rules()
+	}
+
+	var findings []report.Finding
+	if *input != "" {
+		_, findings, err = readReport(*input)
+		findings = corpus.Resolve(findings)
+	} else {
+		var selected []*analysis.Analyzer
+		if selected, err = analyzers.Select(scored); err == nil {
+			findings, err = corpus.Analyze(selected)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+
+	result := evals.Evaluate(corpus.Annotations, findings, scored)
+	var out bytes.Buffer
+	if err := result.WriteText(&out, *verbose); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	if _, err := stdout.Write(out.Bytes()); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+
+	code := exitClean
+	if *golden != "" {
+		if *update {
+			if err := os.WriteFile(*golden, out.Bytes(), 0o644); err != nil {
+				fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+				return exitError
+			}
+		} else {
+			want, err := os.ReadFile(*golden)
+			if err != nil {
+				fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+				return exitError
+			}
+			if !bytes.Equal(want, out.Bytes()) {
+				fmt.Fprintf(stderr, "stdcheck: scores differ from %s; rerun with -update if the change is intended\n", *golden)
+				code = exitFindings
+			}
+		}
+	}
+	total := result.Total()
+	if total.Precision() < *minPrecision {
+		fmt.Fprintf(stderr, "stdcheck: precision %.2f is below %.2f\n", total.Precision(), *minPrecision)
+		code = exitFindings
+	}
+	if total.Recall() < *minRecall {
+		fmt.Fprintf(stderr, "stdcheck: recall %.2f is below %.2f\n", total.Recall(), *minRecall)
+		code = exitFindings
+	}
+	return code
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/diff"
+)
+
+// edit is a text replacement at byte offsets within one file.
+type edit struct {
+	start, end int
+	text       string
+}
+
+// pendingFix is a suggested fix, with its edits resolved to byte offsets.
+// Most fixes edit one file; some, such as moving declarations, edit several.
+type pendingFix struct {
+	rule    string
+	message string
+	files   []string          // edited files, the one the fix is reported in first
+	edits   map[string][]edit // by filename
+}
+
+// fix runs the analyzers over the packages matching patterns and applies
+// their suggested fixes. A fix is skipped if any of its edits overlaps an
+// edit already accepted for the same file. Files excluded by policy are
+// never rewritten, and fixes for rules the configuration disables for a file
+// they edit are not applied. In diff-only runs only fixes for diagnostics in
+// the changed regions are applied. Every rewritten file is gofmt'd. It
+// returns the number of fixes applied and the changes made, as a unified
+// diff; on error, the changes made before it. With dryRun set, no file is
+// written; the changes are written to out as a unified diff. With rv set,
+// each fix is presented for review and applied only if accepted. With dr
+// set, the godoc fix's comment stubs are replaced with drafted comments
+// first.
+func fix(patterns []string, st setup, dryRun bool, rv *reviewer, dr *drafter, out io.Writer) (int, string, error) {
+	res, err := analyze(patterns, st)
+	if err != nil {
+		return 0, "", err
+	}
+
+	wd, _ := os.Getwd()
+	if st.changed != nil {
+		for _, dr := range res.dirs {
+			for rel, spans := range dr.Decls {
+				st.changed.addDecls(rel, spans)
+			}
+		}
+	}
+
+	var fixes []pendingFix
+	seen := make(map[string]bool)
+	for _, act := range res.roots {
+		fset := act.Package.Fset
+	diagnostics:
+		for _, d := range act.Diagnostics {
+			if len(d.SuggestedFixes) == 0 {
+				continue
+			}
+			sf := d.SuggestedFixes[0]
+			if len(sf.TextEdits) == 0 {
+				continue
+			}
+			if st.changed != nil {
+				pos := fset.Position(d.Pos)
+				if !st.changed.touches(check.Relative(wd, pos.Filename), pos.Line) {
+					continue
+				}
+			}
+			pf := pendingFix{rule: act.Analyzer.Name, message: sf.Message, edits: make(map[string][]edit)}
+			for _, te := range sf.TextEdits {
+				start, end := fset.Position(te.Pos), fset.Position(te.End)
+				if !te.End.IsValid() {
+					end = start
+				}
+				name := start.Filename
+				if _, ok := pf.edits[name]; !ok {
+					if res.skipped[name] || !st.config.For(pf.rule, check.Relative(wd, name)).Enabled {
+						continue diagnostics
+					}
+					pf.files = append(pf.files, name)
+				}
+				pf.edits[name] = append(pf.edits[name], edit{start.Offset, end.Offset, string(te.NewText)})
+			}
+			// Count the fix in the file it is reported in.
+			if home := fset.Position(d.Pos).Filename; pf.edits[home] != nil {
+				pf.files = append([]string{home}, slices.DeleteFunc(pf.files, func(n string) bool { return n == home })...)
+			}
+			// The test and non-test variants of a package report the same fix.
+			key := fmt.Sprintf("%v", pf.edits)
+			if !seen[key] {
+				seen[key] = true
+				fixes = append(fixes, pf)
+			}
+		}
+	}
+	// Review and apply fixes file by file, in diagnostic order within a file.
+	sort.SliceStable(fixes, func(i, j int) bool { return fixes[i].files[0] < fixes[j].files[0] })
+
+	ws := &workspace{src: make(map[string][]byte), accepted: make(map[string][]edit), edited: make(map[string][]byte)}
+	counts := make(map[string]int)
+	for _, pf := range fixes {
+		if rv != nil && rv.quit {
+			break
+		}
+		if dr != nil {
+			src, err := ws.read(pf.files[0])
+			if err != nil {
+				return 0, "", err
+			}
+			pf = dr.draft(context.Background(), pf, src, check.Relative(wd, pf.files[0]))
+		}
+		ok, err := ws.add(pf, wd, rv)
+		if err != nil {
+			return 0, "", err
+		}
+		if ok {
+			counts[pf.files[0]]++
+		}
+	}
+
+	applied := 0
+	var patch strings.Builder
+	for _, name := range ws.changed() {
+		rel := check.Relative(wd, name)
+		p, err := ws.write(name, rel, dryRun)
+		if err != nil {
+			return applied, patch.String(), err
+		}
+		applied += counts[name]
+		patch.WriteString(p)
+		if dryRun {
+			if _, err := io.WriteString(out, p); err != nil {
+				return applied, patch.String(), err
+			}
+		} else if p != "" && counts[name] > 0 {
+			fmt.Fprintf(out, "%s: applied %d fix(es)\n", rel, counts[name])
+		} else if p != "" {
+			fmt.Fprintf(out, "%s: updated by fixes in other files\n", rel)
+		}
+	}
+	return applied, patch.String(), nil
+}
+
+// workspace accumulates the accepted fixes for each file.
+type workspace struct {
+	src      map[string][]byte // original content, read on first use
+	accepted map[string][]edit
+	edited   map[string][]byte // files the reviewer rewrote by hand
+}
+
+func (ws *workspace) read(name string) ([]byte, error) {
+	if src, ok := ws.src[name]; ok {
+		return src, nil
+	}
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	ws.src[name] = src
+	return src, nil
+}
+
+// add accepts pf unless it overlaps a fix already accepted, or touches a
+// file the reviewer edited by hand. With rv set, it asks the reviewer first.
+// It reports whether the fix was accepted.
+func (ws *workspace) add(pf pendingFix, wd string, rv *reviewer) (bool, error) {
+	for name, edits := range pf.edits {
+		if ws.edited[name] != nil || overlapsAny(edits, ws.accepted[name]) {
+			return false, nil
+		}
+	}
+	if rv != nil {
+		var patch strings.Builder
+		after := make(map[string][]byte)
+		for _, name := range pf.files {
+			src, err := ws.read(name)
+			if err != nil {
+				return false, err
+			}
+			before, err := render(src, ws.accepted[name])
+			if err != nil {
+				return false, fmt.Errorf("%s: fixed source does not parse: %w", name, err)
+			}
+			if after[name], err = render(src, append(append([]edit(nil), ws.accepted[name]...), pf.edits[name]...)); err != nil {
+				return false, fmt.Errorf("%s: fixed source does not parse: %w", name, err)
+			}
+			patch.WriteString(diff.Unified(check.Relative(wd, name), before, after[name]))
+		}
+		d, err := rv.review(pf, patch.String())
+		if err != nil {
+			return false, err
+		}
+		switch d {
+		case decisionSkip:
+			return false, nil
+		case decisionEdit:
+			// Later fixes' offsets are meaningless in an edited file; the
+			// next run proposes them again.
+			for _, name := range pf.files {
+				if ws.edited[name], err = rv.edit(check.Relative(wd, name), after[name]); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		}
+	}
+	for name, edits := range pf.edits {
+		ws.accepted[name] = append(ws.accepted[name], edits...)
+	}
+	return true, nil
+}
+
+// changed returns the files with accepted fixes, sorted.
+func (ws *workspace) changed() []string {
+	var names []string
+	for name := range ws.accepted {
+		names = append(names, name)
+	}
+	for name := range ws.edited {
+		if _, ok := ws.accepted[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// write rewrites the named file with its accepted fixes, gofmt'd, and
+// returns the change as a unified diff against rel. With dryRun set, the
+// file is not rewritten.
+func (ws *workspace) write(name, rel string, dryRun bool) (string, error) {
+	src, err := ws.read(name)
+	if err != nil {
+		return "", err
+	}
+	fixed := ws.edited[name]
+	if fixed == nil {
+		if fixed, err = render(src, ws.accepted[name]); err != nil {
+			return "", fmt.Errorf("%s: fixed source does not parse: %w", name, err)
+		}
+	}
+	if bytes.Equal(fixed, src) {
+		return "", nil
+	}
+	patch := diff.Unified(rel, src, fixed)
+	if dryRun {
+		return patch, nil
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(name, fixed, info.Mode().Perm()); err != nil {
+		return "", err
+	}
+	return patch, nil
+}
+
+// render applies the non-overlapping edits to src and gofmts the result.
+func render(src []byte, edits []edit) ([]byte, error) {
+	sorted := append([]edit(nil), edits...)
+	// Apply from the end of the file so earlier offsets stay valid.
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].start > sorted[j].start })
+	out := append([]byte(nil), src...)
+	for _, e := range sorted {
+		out = append(out[:e.start:e.start], append([]byte(e.text), out[e.end:]...)...)
+	}
+	return format.Source(out)
+}
+
+func overlapsAny(edits, accepted []edit) bool {
+	for _, e := range edits {
+		for _, a := range accepted {
+			if e.start < a.end && a.start < e.end || e.start == a.start {
+				return true
+			}
+		}
+	}
+	return false
+}
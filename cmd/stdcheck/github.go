@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report/github"
+)
+
+// runGitHub checks the packages, or reads findings from a JSON report, and
+// publishes them as a GitHub check run. It exits like a check, so the same
+// step can gate the build.
+func runGitHub(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("stdcheck github", "stdcheck github [flags] [packages]", stderr)
+	baselinePath := fs.String("baseline", check.DefaultBaseline, "baseline `file` of accepted findings")
+	showSuppressed := fs.Bool("show-suppressed", false, "list suppressed and baselined findings on stderr")
+	fs.StringVar(&opts.changedSince, "changed-since", "", "only check code changed since git `revision`")
+	registerCacheFlags(fs, opts)
+	input := fs.String("input", "", "publish the findings of this JSON report `file` (as written by -format=json) instead of checking packages")
+	repo := fs.String("repo", os.Getenv("GITHUB_REPOSITORY"), "`owner/name` of the repository")
+	sha := fs.String("sha", headSHA(), "`commit` to attach the check run to (default: the pull request head in GitHub Actions)")
+	name := fs.String("name", "stdcheck", "check run `name`")
+	apiURL := fs.String("api-url", envOr("GITHUB_API_URL", github.DefaultAPIURL), "GitHub REST API `URL`")
+	appID := fs.Int64("app-id", 0, "authenticate as this GitHub App `ID` instead of with $GITHUB_TOKEN")
+	installationID := fs.Int64("app-installation-id", 0, "GitHub App installation `ID`")
+	appKey := fs.String("app-key", "", "GitHub App private key PEM `file`")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	stopProfile, err := opts.profile(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	defer stopProfile()
+	if *repo == "" || *sha == "" {
+		fmt.Fprintf(stderr, "stdcheck: -repo and -sha are required outside GitHub Actions\n")
+		return exitError
+	}
+
+	ctx := context.Background()
+	token, err := githubToken(ctx, *apiURL, *appID, *installationID, *appKey)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+
+	var rules []report.Rule
+	var findings []report.Finding
+	if *input != "" {
+		rules, findings, err = readReport(*input)
+	} else {
+		var st setup
+		if st, err = opts.setup(); err == nil {
+			rules = analyzers.Rules(st.analyzers)
+			findings, err = reported(packagePatterns(fs), st, *baselinePath, *showSuppressed, stderr)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+
+	client := &github.Client{APIURL: *apiURL, Token: token}
+	run := github.CheckRun{Repo: *repo, HeadSHA: *sha, Name: *name, Rules: rules}
+	url, err := client.Publish(ctx, run, findings)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "published %d finding(s) to %s\n", len(findings), url)
+	for _, f := range findings {
+		if f.Failing() {
+			return exitFindings
+		}
+	}
+	return exitClean
+}
+
+// githubToken returns an installation token if a GitHub App is configured,
+// and $GITHUB_TOKEN otherwise. Tokens are never taken as flags, which would
+// expose them in the process list.
+func githubToken(ctx context.Context, apiURL string, appID, installationID int64, keyFile string) (string, error) {
+	if appID == 0 {
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			return token, nil
+		}
+		return "", fmt.Errorf("set $GITHUB_TOKEN, or -app-id, -app-installation-id and -app-key")
+	}
+	if installationID == 0 || keyFile == "" {
+		return "", fmt.Errorf("-app-id needs -app-installation-id and -app-key")
+	}
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+	return github.App{ID: appID, InstallationID: installationID, PrivateKey: key}.Token(ctx, apiURL)
+}
+
+// headSHA returns the commit under test in GitHub Actions. For pull request
+// events GITHUB_SHA is a merge commit the PR diff doesn't show, so the head
+// of the pull request is used instead.
+func headSHA() string {
+	if path := os.Getenv("GITHUB_EVENT_PATH"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			var event struct {
+				PullRequest struct {
+					Head struct {
+						SHA string `json:"sha"`
+					} `json:"head"`
+				} `json:"pull_request"`
+			}
+			if json.Unmarshal(data, &event) == nil && event.PullRequest.Head.SHA != "" {
+				return event.PullRequest.Head.SHA
+			}
+		}
+	}
+	return os.Getenv("GITHUB_SHA")
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func readReport(path string) ([]report.Rule, []report.Finding, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return report.ReadJSON(f)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies a pre-commit hook written by stdcheck, so install
+// may replace it but not a hook of the user's own.
+const hookMarker = "# Installed by stdcheck hook install."
+
+// preCommitHook runs the staged check. A commit is blocked only when the
+// check fails; a missing stdcheck binary is reported but does not block it.
+const preCommitHook = `#!/bin/sh
+` + hookMarker + `
+# Checks the Go files staged for commit. Bypass with git commit --no-verify.
+if ! command -v stdcheck >/dev/null 2>&1; then
+	echo "stdcheck not found on PATH; skipping the standards check" >&2
+	exit 0
+fi
+exec stdcheck -staged
+`
+
+func runHook(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stdcheck hook install", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dryRun := fs.Bool("dry-run", false, "print the hook and where it would be written instead of writing it")
+	force := fs.Bool("force", false, "replace an existing pre-commit hook not installed by stdcheck")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: stdcheck hook install [-dry-run] [-force]\n\nInstalls a git pre-commit hook that runs stdcheck -staged.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "install" {
+		fs.Usage()
+		return exitError
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitError
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return exitError
+	}
+
+	name, err := hookPath("pre-commit")
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	old, err := os.ReadFile(name)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	case string(old) == preCommitHook:
+		fmt.Fprintf(stdout, "%s is up to date\n", name)
+		return exitClean
+	case !bytes.Contains(old, []byte(hookMarker)) && !*force:
+		fmt.Fprintf(stderr, "stdcheck: %s exists and was not installed by stdcheck; add \"stdcheck -staged\" to it, or replace it with -force\n", name)
+		return exitError
+	}
+
+	if *dryRun {
+		fmt.Fprintf(stderr, "would write %s:\n", name)
+		fmt.Fprint(stdout, preCommitHook)
+		return exitClean
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: creating hooks directory: %v\n", err)
+		return exitError
+	}
+	if err := os.WriteFile(name, []byte(preCommitHook), 0o755); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(name, 0o755); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "installed %s\n", name)
+	return exitClean
+}
+
+// hookPath returns the path of the named git hook in the current
+// repository, honoring core.hooksPath.
+func hookPath(hook string) (string, error) {
+	cmd := exec.CommandContext(context.Background(), "git", "rev-parse", "--git-path", "hooks/"+hook)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("locating git hooks: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return filepath.Abs(strings.TrimSpace(string(out)))
+}
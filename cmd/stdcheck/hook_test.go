@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// gitRepo makes a new git repository the working directory.
+func gitRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	gitRun(t, "init", "-q")
+	gitRun(t, "config", "user.email", "dev@example.com")
+	gitRun(t, "config", "user.name", "Dev")
+}
+
+func gitRun(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestRunHook_Install(t *testing.T) {
+	gitRepo(t)
+	name, err := filepath.Abs(filepath.Join(".git", "hooks", "pre-commit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runHook([]string{"install", "-dry-run"}, &stdout, &stderr); code != exitClean || stdout.String() != preCommitHook {
+		t.Errorf("runHook(-dry-run) = %d, %q; want the hook printed", code, stdout.String())
+	}
+	if _, err := os.Stat(name); !os.IsNotExist(err) {
+		t.Errorf("runHook(-dry-run) wrote the hook: %v", err)
+	}
+
+	stdout.Reset()
+	if code := runHook([]string{"install"}, &stdout, &stderr); code != exitClean || stdout.String() != "installed "+name+"\n" {
+		t.Fatalf("runHook() = %d, %q; stderr:\n%s", code, stdout.String(), stderr.String())
+	}
+	data, err := os.ReadFile(name)
+	if err != nil || string(data) != preCommitHook {
+		t.Errorf("hook = %q, %v; want the stdcheck hook", data, err)
+	}
+	if info, err := os.Stat(name); err != nil || runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+		t.Errorf("hook mode = %v, %v; want it executable", info.Mode(), err)
+	}
+
+	stdout.Reset()
+	if code := runHook([]string{"install"}, &stdout, &stderr); code != exitClean || !strings.HasSuffix(stdout.String(), " is up to date\n") {
+		t.Errorf("runHook() again = %d, %q; want it up to date", code, stdout.String())
+	}
+}
+
+func TestRunHook_Install_Existing(t *testing.T) {
+	gitRepo(t)
+	name := filepath.Join(".git", "hooks", "pre-commit")
+	own := "#!/bin/sh\nmake lint\n"
+	if err := os.WriteFile(name, []byte(own), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runHook([]string{"install"}, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "was not installed by stdcheck") {
+		t.Errorf("runHook() over the user's hook = %d; stderr:\n%s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(name); string(data) != own {
+		t.Errorf("the user's hook was changed to %q", data)
+	}
+
+	// An older stdcheck hook is replaced, and -force replaces any.
+	for _, tt := range []struct {
+		old  string
+		args []string
+	}{
+		{"#!/bin/sh\n" + hookMarker + "\nexec stdcheck\n", []string{"install"}},
+		{own, []string{"install", "-force"}},
+	} {
+		if err := os.WriteFile(name, []byte(tt.old), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if code := runHook(tt.args, &stdout, &stderr); code != exitClean {
+			t.Errorf("runHook(%q) = %d; stderr:\n%s", tt.args, code, stderr.String())
+		}
+		data, _ := os.ReadFile(name)
+		info, _ := os.Stat(name)
+		if string(data) != preCommitHook || runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0 {
+			t.Errorf("runHook(%q) left %q, mode %v", tt.args, data, info.Mode())
+		}
+	}
+}
+
+func TestRunHook_Install_HooksPath(t *testing.T) {
+	gitRepo(t)
+	gitRun(t, "config", "core.hooksPath", ".githooks")
+	var stdout, stderr bytes.Buffer
+	if code := runHook([]string{"install"}, &stdout, &stderr); code != exitClean {
+		t.Fatalf("runHook() = %d; stderr:\n%s", code, stderr.String())
+	}
+	if data, err := os.ReadFile(filepath.Join(".githooks", "pre-commit")); err != nil || string(data) != preCommitHook {
+		t.Errorf(".githooks/pre-commit = %q, %v; want the stdcheck hook", data, err)
+	}
+}
+
+func TestPreCommitHook_NoBinary(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	name := filepath.Join(t.TempDir(), "pre-commit")
+	if err := os.WriteFile(name, []byte(preCommitHook), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// Without stdcheck on PATH the hook lets the commit through.
+	cmd := exec.Command(sh, name)
+	cmd.Env = []string{"PATH=" + t.TempDir()}
+	out, err := cmd.CombinedOutput()
+	if err != nil || !strings.Contains(string(out), "stdcheck not found on PATH") {
+		t.Errorf("hook = %v, %q; want exit 0 with a warning", err, out)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/diff"
+)
+
+// journalDir holds a record of every change stdcheck fix makes, relative to
+// the working directory. Each change is a unified diff named by its ID, so
+// it can be reviewed, or applied with git apply, as well as undone.
+const journalDir = ".stdcheck/journal"
+
+// change is a journaled set of fixes.
+type change struct {
+	ID    string
+	Time  time.Time
+	Fixes int
+	Files []string
+	Patch []byte
+}
+
+// journalHeader starts every journal entry. git apply ignores it.
+const journalHeader = "stdcheck fix: %d fix(es) at %s\n"
+
+// record journals patch, the changes made by n fixes, and returns its ID.
+// IDs sort in the order changes were made.
+func record(patch string, n int) (string, error) {
+	now := time.Now().UTC()
+	sum := sha256.Sum256([]byte(patch))
+	id := now.Format("20060102-150405") + "-" + hex.EncodeToString(sum[:3])
+	if err := os.MkdirAll(journalDir, 0o755); err != nil {
+		return "", fmt.Errorf("creating journal: %w", err)
+	}
+	entry := fmt.Sprintf(journalHeader, n, now.Format(time.RFC3339)) + patch
+	if err := os.WriteFile(journalPath(id), []byte(entry), 0o644); err != nil {
+		return "", fmt.Errorf("writing journal: %w", err)
+	}
+	return id, nil
+}
+
+func journalPath(id string) string {
+	return filepath.Join(journalDir, id+".patch")
+}
+
+// readChange reads the journal entry id.
+func readChange(id string) (*change, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid change ID %q", id)
+	}
+	data, err := os.ReadFile(journalPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no change %s in %s", id, journalDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &change{ID: id, Patch: data}
+	var stamp string
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	fmt.Sscanf(string(header)+"\n", journalHeader, &c.Fixes, &stamp)
+	c.Time, _ = time.Parse(time.RFC3339, stamp)
+	patches, err := diff.ParsePatch(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("change %s: %w", id, err)
+	}
+	for _, p := range patches {
+		c.Files = append(c.Files, p.Path)
+	}
+	return c, nil
+}
+
+// journal returns the journaled changes, oldest first.
+func journal() ([]*change, error) {
+	entries, err := os.ReadDir(journalDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var changes []*change
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".patch")
+		if !ok || e.IsDir() {
+			continue
+		}
+		c, err := readChange(id)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+	return changes, nil
+}
+
+// undo reverts change c by applying its patch in reverse, and returns the
+// new content of each file, by path. Hunks that have moved because of later
+// edits are still reverted; if any hunk's lines have themselves been edited
+// since, nothing is reverted and the error names every such hunk.
+func undo(c *change) (map[string][]byte, error) {
+	patches, err := diff.ParsePatch(bytes.NewReader(c.Patch))
+	if err != nil {
+		return nil, fmt.Errorf("change %s: %w", c.ID, err)
+	}
+	out := make(map[string][]byte)
+	var errs []error
+	for _, p := range patches {
+		src, err := os.ReadFile(filepath.FromSlash(p.Path))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reverted, err := p.Reverse().Apply(src)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		out[p.Path] = reverted
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+const undocumented = "// Package a is a test module.\npackage a\n\nfunc A() {}\n\nfunc B() {}\n"
+
+// fixed writes a module whose a.go lacks doc comments to a new working
+// directory, runs stdcheck fix over it and returns the journaled change.
+func fixed(t *testing.T) *change {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	for name, data := range map[string]string{"go.mod": "module example.com/a\n\ngo 1.22\n", "a.go": undocumented} {
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runFix(nil, &stdout, &stderr); code != exitClean {
+		t.Fatalf("runFix() = %d; stderr:\n%s", code, stderr.String())
+	}
+	changes, err := journal()
+	if err != nil || len(changes) != 1 {
+		t.Fatalf("journal() = %v, %v; want the one change", changes, err)
+	}
+	c := changes[0]
+	if c.Fixes != 2 || strings.Join(c.Files, " ") != "a.go" || c.Time.IsZero() {
+		t.Errorf("change = %d fix(es) in %v at %v, want 2 in a.go", c.Fixes, c.Files, c.Time)
+	}
+	if got := readFile(t, "a.go"); got == undocumented {
+		t.Fatal("runFix() left a.go as it was")
+	}
+	return c
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunUndo_Revert(t *testing.T) {
+	c := fixed(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := runUndo(nil, &stdout, &stderr); code != exitClean || !strings.HasPrefix(stdout.String(), c.ID+"  2 fix(es)  a.go\n") {
+		t.Errorf("runUndo() listed %q, %d; want the change", stdout.String(), code)
+	}
+
+	stdout.Reset()
+	if code := runUndo([]string{"-dry-run", c.ID}, &stdout, &stderr); code != exitClean || !strings.Contains(stdout.String(), "-// A ") {
+		t.Errorf("runUndo(-dry-run) = %d, %q; want a diff removing the stubs", code, stdout.String())
+	}
+	if readFile(t, "a.go") == undocumented {
+		t.Error("runUndo(-dry-run) reverted a.go")
+	}
+
+	stdout.Reset()
+	if code := runUndo([]string{c.ID}, &stdout, &stderr); code != exitClean {
+		t.Fatalf("runUndo() = %d; stderr:\n%s", code, stderr.String())
+	}
+	if got := readFile(t, "a.go"); got != undocumented {
+		t.Errorf("a.go after undo = %q, want %q", got, undocumented)
+	}
+	if _, err := os.Stat(journalPath(c.ID)); !os.IsNotExist(err) {
+		t.Errorf("the journal entry is still there: %v", err)
+	}
+}
+
+func TestRunUndo_Moved(t *testing.T) {
+	c := fixed(t)
+	// Lines added above the fixes since move their hunks.
+	const header = "// Code owners: the platform team.\n\n"
+	if err := os.WriteFile("a.go", []byte(header+readFile(t, "a.go")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runUndo([]string{c.ID}, &stdout, &stderr); code != exitClean {
+		t.Fatalf("runUndo() = %d; stderr:\n%s", code, stderr.String())
+	}
+	if got, want := readFile(t, "a.go"), header+undocumented; got != want {
+		t.Errorf("a.go after undo = %q, want %q", got, want)
+	}
+}
+
+func TestRunUndo_Stale(t *testing.T) {
+	c := fixed(t)
+	// The stub for B has been written up since.
+	edited := strings.Replace(readFile(t, "a.go"), "// B ", "// B returns nothing. ", 1)
+	if err := os.WriteFile("a.go", []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runUndo([]string{c.ID}, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "nothing was changed") {
+		t.Errorf("runUndo() = %d; stderr:\n%s", code, stderr.String())
+	}
+	// Neither the file, not even A's stub, nor the journal changes.
+	if got := readFile(t, "a.go"); got != edited {
+		t.Errorf("a.go = %q, want it as edited", got)
+	}
+	if _, err := os.Stat(journalPath(c.ID)); err != nil {
+		t.Errorf("the journal entry is gone: %v", err)
+	}
+}
+
+func TestRunUndo_Missing(t *testing.T) {
+	t.Chdir(t.TempDir())
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"20260101-000000-abcdef"}, "no change 20260101-000000-abcdef in .stdcheck/journal"},
+		{[]string{"../a"}, `invalid change ID "../a"`},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		if code := runUndo(tt.args, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), tt.want) {
+			t.Errorf("runUndo(%q) = %d, stderr %q; want an error saying %q", tt.args, code, stderr.String(), tt.want)
+		}
+	}
+	// With no journal, there is nothing to list.
+	var stdout, stderr bytes.Buffer
+	if code := runUndo(nil, &stdout, &stderr); code != exitClean || stdout.Len() != 0 {
+		t.Errorf("runUndo() = %d, %q; want an empty list", code, stdout.String())
+	}
+}
@@ -0,0 +1,775 @@
+// Command stdcheck runs the standards analyzers over Go packages.
+//
+// Usage:
+//
+//	stdcheck [flags] [packages]
+//	stdcheck fix [flags] [packages]
+//	stdcheck baseline [flags] [packages]
+//	stdcheck config validate [-config file]
+//	stdcheck config migrate [-config file] [-dry-run]
+//	stdcheck undo [-dry-run] [change-id]
+//	stdcheck github [flags] [packages]
+//	stdcheck hook install [-dry-run] [-force]
+//	stdcheck eval [flags] [files]
+//	stdcheck api diff [-baseline file] [-version v] [packages]
+//	stdcheck api baseline [-baseline file] [-version v] [-dry-run] [packages]
+//	stdcheck review [flags] files...
+//
+// Packages default to ./... . stdcheck exits 0 when no findings are
+// reported, 1 when there are findings, and 2 on usage or load errors.
+//
+// The fix subcommand applies the analyzers' suggested fixes, such as
+// extracting a missing primary constructor, and gofmts the rewritten files.
+// Subcommands that write files accept -dry-run, which prints what would be
+// written (a unified diff, for fix) and changes nothing. fix -interactive
+// shows each fix as a diff and asks whether to apply it, skip it, edit the
+// result, or accept every fix of its rule. fix -draft-docs has the model
+// configured for the review (see below) draft the doc comments that the
+// godoc fix would insert as stubs.
+//
+// Every fix run is journaled under .stdcheck/journal as a patch named by a
+// change ID, which fix prints. The undo subcommand reverts a change by
+// applying its patch in reverse; hunks moved by later edits are found where
+// they now are, and if any fixed line has since been edited nothing is
+// reverted. With no change ID, undo lists the journal.
+//
+// The baseline subcommand snapshots the current findings into
+// .stdcheck-baseline.json. Later runs report only findings missing from the
+// baseline, so existing debt does not fail the build. A single finding can
+// instead be suppressed in place with a justified directive on its line or
+// the line above:
+//
+//	//stdignore:<rule> reason
+//
+// Suppressed and baselined findings are counted on stderr rather than
+// reported; -show-suppressed lists them.
+//
+// Files under vendor/ or third_party/, and files over 512 KB, are skipped
+// (see -skip-dirs and -max-file-kb). Skipped files are listed on stderr.
+//
+// With -changed-since=<revision>, only packages with files changed since the
+// branch diverged from the git revision, committed or not, are analyzed, and
+// only findings inside the changed declarations are reported. Untracked
+// files count as changed.
+//
+// With -staged, only the Go files staged in the git index are checked, as
+// staged. The staged check parses just those files and runs only the rules
+// that need no type information, since type-checking would load whole
+// packages and their dependencies; the rules it leaves out are listed on
+// stderr. The hook install subcommand installs a git pre-commit hook that
+// runs it.
+//
+// The eval subcommand scores the analyzers against the annotated sample
+// corpus in docs/prompts/standards-compliance (see package evals), printing
+// precision and recall per rule. With -input it scores a JSON report, such
+// as the AI review's of the same files, instead. -golden compares the scores
+// with a checked-in file and -update rewrites it; -min-precision and
+// -min-recall set floors. eval exits 1 when the scores differ from the
+// golden file or fall below a floor.
+//
+// The api baseline subcommand records the exported constructors, interfaces
+// and container accessors of the packages in .stdcheck-api.json (see package
+// api), with the module version they are released as: -version, or the
+// latest v* git tag. The apistability rule then reports changes that break
+// code built against that surface, and api diff lists every change since it
+// was recorded, classifies them as a major, minor or patch change, and
+// recommends the next version. It exits 1 if any change breaks
+// compatibility. Re-record the baseline to accept a deliberate break, and at
+// each release.
+//
+// The review subcommand runs the AI review: it renders the review prompt
+// (see package prompt) for each file and has the model configured under
+// review in .standards.yaml (see package llm) report findings, which are
+// printed in any -format like a check's. The configuration's rule settings
+// and the skip policy apply as they do to a check. With -stream, each
+// finding is printed as text as soon as the model has written it. It prints
+// the prompt version and hash used to stderr, and exits like a check.
+// -cost-report prints the tokens and estimated cost of each file, each rule
+// and the run (see package llm/tokens), and -max-cost stops the run, exiting
+// 2, before reviewing a file whose prompt and longest allowed response could
+// take it over a budget. Calls to the provider are rate limited and retried
+// with backoff (see llm.WithPolicy); when the provider stays unavailable,
+// the files it didn't review get the static analyzers instead, unless
+// review.fallback is none. Verdicts are cached by prompt, model and file
+// content in the store review.cache configures (see package llm/memo), so
+// unchanged files aren't sent again; -cache=false bypasses it.
+//
+// Results are cached per package directory under the user cache directory
+// (see -cache-dir), keyed by the content of the package and its
+// dependencies, so unchanged packages are not re-analyzed. -cache=false
+// disables the cache.
+//
+// -verbose prints a table of what each rule cost on stderr: the packages
+// it analyzed, the time it took, the heap memory it allocated, the findings
+// it reported, and the package it was slowest on. -metrics=file writes the
+// same as JSON, or as CSV if file ends in .csv. Allocations are exact only
+// with -parallel=1.
+//
+// The check, fix, baseline and github subcommands accept -cpuprofile,
+// -memprofile and -trace, which write a CPU profile, a heap profile and an
+// execution trace of the run for go tool pprof and go tool trace.
+//
+// The github subcommand publishes the findings as a GitHub check run with an
+// inline annotation per finding, authenticating with $GITHUB_TOKEN or as a
+// GitHub App. With -input it publishes a JSON report, such as the AI
+// review's, instead of checking packages.
+//
+// Packages are loaded and analyzed in batches, -parallel at a time (default:
+// the number of CPUs). Output does not depend on the degree of parallelism.
+//
+// Rules, severities and the files checked are configured per project in
+// .standards.yaml (see package config). The config validate subcommand
+// checks the file and suggests spellings for unknown keys and rules, and
+// config migrate rewrites it to the current schema version, replacing
+// options a newer schema deprecates; schema 1 deprecates none, so for now
+// it only records the version. Only findings of severity error fail the
+// run; warn and info findings are reported without failing it.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/config"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/diff"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/prompt"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report/sarif"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/skip"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/suppress"
+	"golang.org/x/tools/go/analysis"
+)
+
+const (
+	exitClean    = 0
+	exitFindings = 1
+	exitError    = 2
+)
+
+// reporters maps -format values to their report emitters.
+var reporters = map[string]report.Reporter{
+	"text":  report.Text{},
+	"json":  report.JSON{},
+	"junit": report.JUnit{},
+	"html":  report.HTML{},
+	"sarif": sarif.Reporter{Tool: "stdcheck"},
+	"csv":   report.CSV{},
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(reporters))
+	for n := range reporters {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// version returns the module version stdcheck was built from, or "" for
+// development builds.
+func version() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return ""
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "fix":
+			return runFix(args[1:], stdout, stderr)
+		case "baseline":
+			return runBaseline(args[1:], stdout, stderr)
+		case "config":
+			return runConfig(args[1:], stdout, stderr)
+		case "undo":
+			return runUndo(args[1:], stdout, stderr)
+		case "github":
+			return runGitHub(args[1:], stdout, stderr)
+		case "hook":
+			return runHook(args[1:], stdout, stderr)
+		case "eval":
+			return runEval(args[1:], stdout, stderr)
+		case "api":
+			return runAPI(args[1:], stdout, stderr)
+		case "review":
+			return runReview(args[1:], stdout, stderr)
+		}
+	}
+	return runCheck(args, stdout, stderr)
+}
+
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("stdcheck", "stdcheck [flags] [packages]", stderr)
+	format := fs.String("format", "text", "output format: "+strings.Join(formatNames(), ", "))
+	baselinePath := fs.String("baseline", check.DefaultBaseline, "baseline `file` of accepted findings")
+	showSuppressed := fs.Bool("show-suppressed", false, "list suppressed and baselined findings on stderr")
+	fs.StringVar(&opts.changedSince, "changed-since", "", "only check code changed since git `revision`")
+	fs.BoolVar(&opts.staged, "staged", false, "only check the Go files staged in the git index, with the rules that need no type information")
+	fs.BoolVar(&opts.verbose, "verbose", false, "print the time, memory and findings of each rule on stderr")
+	fs.StringVar(&opts.metricsPath, "metrics", "", "write the time, memory and findings of each rule to `file` as JSON, or as CSV if it ends in .csv")
+	registerCacheFlags(fs, opts)
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	stopProfile, err := opts.profile(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	defer stopProfile()
+	if opts.staged && (opts.changedSince != "" || fs.NArg() > 0) {
+		fmt.Fprintf(stderr, "stdcheck: -staged takes neither -changed-since nor packages\n")
+		return exitError
+	}
+
+	st, err := opts.setup()
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	reporter, ok := reporters[*format]
+	if !ok {
+		fmt.Fprintf(stderr, "stdcheck: unknown format %q\n", *format)
+		return exitError
+	}
+
+	findings, err := reported(packagePatterns(fs), st, *baselinePath, *showSuppressed, stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	if opts.verbose {
+		st.metrics.write(stderr)
+	}
+	if opts.metricsPath != "" {
+		if err := st.metrics.writeFile(opts.metricsPath); err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+	}
+	if err := reporter.Report(stdout, analyzers.Rules(st.analyzers), findings); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: writing report: %v\n", err)
+		return exitError
+	}
+	for _, f := range findings {
+		if f.Failing() {
+			return exitFindings
+		}
+	}
+	return exitClean
+}
+
+// reported runs the check and returns the findings to report: those
+// neither suppressed inline nor in the baseline at baselinePath. Skipped
+// files, invalid directives and the number of findings left out are written
+// to stderr.
+func reported(patterns []string, st setup, baselinePath string, showSuppressed bool, stderr io.Writer) ([]report.Finding, error) {
+	baseline, err := report.ReadBaseline(baselinePath)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		findings   []report.Finding
+		directives []suppress.Directive
+		skipped    []skip.Item
+	)
+	if st.staged {
+		var typed []string
+		findings, directives, skipped, typed, err = checkStaged(st)
+		if len(typed) > 0 {
+			fmt.Fprintf(stderr, "staged check ran only syntactic rules; run stdcheck for %s\n", strings.Join(typed, ", "))
+		}
+	} else {
+		findings, directives, skipped, err = checkPackages(patterns, st)
+	}
+	if err != nil {
+		return nil, err
+	}
+	summarizeSkipped(stderr, skipped)
+	r := check.Filter(findings, directives, baseline)
+	for _, d := range r.Invalid {
+		fmt.Fprintf(stderr, "%s:%d: %s%s needs a rule and a reason; ignored\n", d.File, d.Line, suppress.Prefix, d.Rule)
+	}
+	summarizeSuppressed(stderr, r.Suppressed, r.Known, showSuppressed)
+	return r.Findings, nil
+}
+
+func runFix(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("stdcheck fix", "stdcheck fix [flags] [packages]", stderr)
+	fs.StringVar(&opts.changedSince, "changed-since", "", "only fix code changed since git `revision`")
+	dryRun := fs.Bool("dry-run", false, "print the changes as a unified diff instead of writing them")
+	interactive := fs.Bool("interactive", false, "review each fix as a diff and choose whether to apply it")
+	draftDocs := fs.Bool("draft-docs", false, "have the model configured under review draft the doc comments the godoc fix inserts")
+	promptDir := fs.String("prompts", "docs/prompts", "prompt template root `directory`, for -draft-docs")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	stopProfile, err := opts.profile(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	defer stopProfile()
+
+	st, err := opts.setup()
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	var rv *reviewer
+	if *interactive {
+		// Prompts go to stderr so a -dry-run diff on stdout stays clean.
+		rv = newReviewer(os.Stdin, stderr)
+	}
+	var dr *drafter
+	if *draftDocs {
+		client, err := reviewClient(st.config.Review)
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: -draft-docs: %v\n", err)
+			return exitError
+		}
+		catalog, err := prompt.Load(os.DirFS(*promptDir))
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		dr = &drafter{client: client, catalog: catalog, rc: st.config.Review, warn: stderr}
+	}
+	n, patch, err := fix(packagePatterns(fs), st, *dryRun, rv, dr, stdout)
+	if patch != "" && !*dryRun {
+		// Journal whatever was written, even if a later file failed.
+		id, jerr := record(patch, n)
+		if jerr != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", jerr)
+			return exitError
+		}
+		fmt.Fprintf(stderr, "change %s; revert with: stdcheck undo %s\n", id, id)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	if *dryRun {
+		fmt.Fprintf(stderr, "%d fix(es) would be applied\n", n)
+	} else {
+		fmt.Fprintf(stdout, "%d fix(es) applied\n", n)
+	}
+	return exitClean
+}
+
+func runUndo(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stdcheck undo", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dryRun := fs.Bool("dry-run", false, "print the reverting changes as a unified diff instead of writing them")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: stdcheck undo [-dry-run] [change-id]\n\nWith no change ID, lists the journaled changes.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	if fs.NArg() == 0 {
+		changes, err := journal()
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		for _, c := range changes {
+			fmt.Fprintf(stdout, "%s  %d fix(es)  %s\n", c.ID, c.Fixes, strings.Join(c.Files, " "))
+		}
+		return exitClean
+	}
+
+	c, err := readChange(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	files, err := undo(c)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: cannot undo %s; nothing was changed:\n%v\n", c.ID, err)
+		return exitError
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		name := filepath.FromSlash(path)
+		if *dryRun {
+			src, err := os.ReadFile(name)
+			if err != nil {
+				fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+				return exitError
+			}
+			io.WriteString(stdout, diff.Unified(path, src, files[path]))
+			continue
+		}
+		info, err := os.Stat(name)
+		if err == nil {
+			err = os.WriteFile(name, files[path], info.Mode().Perm())
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+	}
+	if *dryRun {
+		fmt.Fprintf(stderr, "would revert change %s in %d file(s)\n", c.ID, len(paths))
+		return exitClean
+	}
+	if err := os.Remove(journalPath(c.ID)); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "reverted change %s in %d file(s)\n", c.ID, len(paths))
+	return exitClean
+}
+
+func runBaseline(args []string, stdout, stderr io.Writer) int {
+	fs, opts := newFlagSet("stdcheck baseline", "stdcheck baseline [flags] [packages]", stderr)
+	baselinePath := fs.String("baseline", check.DefaultBaseline, "baseline `file` to write")
+	dryRun := fs.Bool("dry-run", false, "print the baseline instead of writing it")
+	registerCacheFlags(fs, opts)
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	stopProfile, err := opts.profile(stderr)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	defer stopProfile()
+
+	st, err := opts.setup()
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	findings, directives, skipped, err := checkPackages(packagePatterns(fs), st)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	summarizeSkipped(stderr, skipped)
+	// Inline suppressions already account for their findings.
+	findings, _, _ = suppress.Apply(findings, directives)
+
+	if *dryRun {
+		if err := report.NewBaseline(findings).Write(stdout); err != nil {
+			fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+			return exitError
+		}
+		fmt.Fprintf(stderr, "would write %d finding(s) to %s\n", len(findings), *baselinePath)
+		return exitClean
+	}
+
+	f, err := os.Create(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	err = report.NewBaseline(findings).Write(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: writing baseline: %v\n", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "wrote %d finding(s) to %s\n", len(findings), *baselinePath)
+	return exitClean
+}
+
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "migrate" {
+		return runConfigMigrate(args[1:], stdout, stderr)
+	}
+	if len(args) == 0 || args[0] != "validate" {
+		fmt.Fprintf(stderr, "usage: stdcheck config validate|migrate [flags]\n")
+		return exitError
+	}
+	fs := flag.NewFlagSet("stdcheck config validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("config", config.DefaultPath, "project configuration `file`")
+	if err := fs.Parse(args[1:]); err != nil {
+		return exitError
+	}
+	if _, err := os.Stat(*path); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+
+	cfg, err := config.Load(*path)
+	if err == nil {
+		err = errors.Join(cfg.CheckBinary(version()), checkRuleNames(cfg, *path))
+	}
+	if err != nil {
+		fmt.Fprintln(stdout, err)
+		return exitFindings
+	}
+	fmt.Fprintf(stdout, "%s: ok\n", *path)
+	return exitClean
+}
+
+// runConfigMigrate rewrites the configuration file to the current schema
+// version.
+func runConfigMigrate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stdcheck config migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	path := fs.String("config", config.DefaultPath, "project configuration `file`")
+	dryRun := fs.Bool("dry-run", false, "print the changes as a unified diff instead of writing the file")
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	data, err := os.ReadFile(*path)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	migrated, changes, err := config.Migrate(data)
+	if err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %s: %v\n", *path, err)
+		return exitError
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(stdout, "%s: already at schema version %d\n", *path, config.SchemaVersion)
+		return exitClean
+	}
+	if *dryRun {
+		io.WriteString(stdout, diff.Unified(*path, data, migrated))
+		return exitClean
+	}
+	if err := os.WriteFile(*path, migrated, 0o644); err != nil {
+		fmt.Fprintf(stderr, "stdcheck: %v\n", err)
+		return exitError
+	}
+	for _, c := range changes {
+		fmt.Fprintf(stdout, "%s: %s\n", *path, c)
+	}
+	fmt.Fprintf(stdout, "migrated %s to schema version %d\n", *path, config.SchemaVersion)
+	return exitClean
+}
+
+// summarizeSuppressed writes counts of the findings that were not reported,
+// and lists them if verbose is set.
+func summarizeSuppressed(w io.Writer, suppressed []report.Suppressed, known []report.Finding, verbose bool) {
+	if len(suppressed) > 0 {
+		fmt.Fprintf(w, "stdcheck: %d finding(s) suppressed inline\n", len(suppressed))
+		if verbose {
+			for _, s := range suppressed {
+				fmt.Fprintf(w, "  %s [%s]\n", s.Finding, s.Reason)
+			}
+		}
+	}
+	if len(known) > 0 {
+		fmt.Fprintf(w, "stdcheck: %d finding(s) accepted by baseline\n", len(known))
+		if verbose {
+			for _, f := range known {
+				fmt.Fprintf(w, "  %s\n", f)
+			}
+		}
+	}
+}
+
+// summarizeSkipped lists the files excluded by the skip policy, so nothing
+// drops out of a check unnoticed.
+func summarizeSkipped(w io.Writer, items []skip.Item) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "stdcheck: skipped %d file(s)\n", len(items))
+	for _, it := range items {
+		fmt.Fprintf(w, "  %s\n", it)
+	}
+}
+
+// checkRuleNames reports every rule cfg names that no analyzer implements,
+// suggesting the closest known rule.
+func checkRuleNames(cfg *config.Config, path string) error {
+	known := make([]string, len(analyzers.All))
+	for i, a := range analyzers.All {
+		known[i] = a.Name
+	}
+	var errs []error
+	for _, name := range cfg.RuleNames() {
+		if _, err := analyzers.Select([]string{name}); err == nil {
+			continue
+		}
+		msg := fmt.Sprintf("%s: unknown rule %q", path, name)
+		if s := config.Suggest(name, known); s != "" {
+			msg += fmt.Sprintf("; did you mean %q?", s)
+		}
+		errs = append(errs, errors.New(msg))
+	}
+	return errors.Join(errs...)
+}
+
+// options holds the flags shared by every subcommand.
+type options struct {
+	rules        string
+	configPath   string
+	skipDirs     string
+	maxKB        int64
+	changedSince string // check and fix only
+	staged       bool   // check only
+	verbose      bool   // check only
+	metricsPath  string // check only
+	useCache     bool   // check and baseline only
+	cacheDir     string
+	parallel     int
+	cpuProfile   string
+	memProfile   string
+	tracePath    string
+}
+
+// setup is what a run checks and how, resolved from the flags and the
+// project configuration file.
+type setup struct {
+	analyzers []*analysis.Analyzer
+	config    *config.Config
+	policy    skip.Policy
+	changed   *changes // nil unless -changed-since is set
+	staged    bool     // check only the files staged in the git index
+	cache     *cache   // nil if results are not cached
+	parallel  int      // batches of packages analyzed at once
+	// metrics collects what each analyzer costs; nil unless -verbose or
+	// -metrics is set. The analyzers are then instrumented copies.
+	metrics *runMetrics
+}
+
+// setup loads the configuration file and selects the analyzers to run:
+// those named by -rules, or else every rule the configuration doesn't
+// disable everywhere.
+func (o *options) setup() (setup, error) {
+	cfg, err := config.Load(o.configPath)
+	if err != nil {
+		return setup{}, err
+	}
+	if err := cfg.CheckBinary(version()); err != nil {
+		return setup{}, fmt.Errorf("%s: %w", o.configPath, err)
+	}
+	if err := checkRuleNames(cfg, o.configPath); err != nil {
+		return setup{}, err
+	}
+
+	selected, err := analyzers.Select(splitList(o.rules))
+	if err != nil {
+		return setup{}, err
+	}
+	if o.rules == "" {
+		var enabled []*analysis.Analyzer
+		for _, a := range selected {
+			if cfg.MaybeEnabled(a.Name) {
+				enabled = append(enabled, a)
+			}
+		}
+		selected = enabled
+	}
+
+	st := setup{
+		analyzers: selected,
+		config:    cfg,
+		policy:    skip.Policy{Dirs: splitList(o.skipDirs), MaxKB: o.maxKB, Filter: cfg.Excluded},
+		staged:    o.staged,
+		parallel:  o.parallel,
+	}
+	if o.parallel < 1 {
+		return setup{}, fmt.Errorf("-parallel must be at least 1")
+	}
+	if o.changedSince != "" {
+		if st.changed, err = changesSince(o.changedSince); err != nil {
+			return setup{}, err
+		}
+	}
+	if o.useCache && !o.staged {
+		if st.cache, err = openCache(o.cacheDir, selected); err != nil {
+			return setup{}, err
+		}
+	}
+	if o.verbose || o.metricsPath != "" {
+		st.metrics = newRunMetrics()
+		st.analyzers = st.metrics.instrument(selected)
+	}
+	return st, nil
+}
+
+// newFlagSet returns a flag set with the flags shared by every subcommand:
+// -rules, the skip policy, profiling, and each analyzer's own flags.
+func newFlagSet(name, usage string, stderr io.Writer) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts := new(options)
+	fs.StringVar(&opts.rules, "rules", "", "comma-separated rules to run (default: all enabled in the config file)")
+	fs.StringVar(&opts.configPath, "config", config.DefaultPath, "project configuration `file`")
+	fs.StringVar(&opts.skipDirs, "skip-dirs", strings.Join(skip.DefaultDirs, ","), "comma-separated directory names to skip")
+	fs.Int64Var(&opts.maxKB, "max-file-kb", skip.DefaultMaxKB, "skip files larger than this many KB (0: no limit)")
+	fs.IntVar(&opts.parallel, "parallel", runtime.GOMAXPROCS(0), "load and analyze up to `N` batches of packages concurrently")
+	fs.StringVar(&opts.cpuProfile, "cpuprofile", "", "write a CPU profile of the run to `file`")
+	fs.StringVar(&opts.memProfile, "memprofile", "", "write a heap profile to `file` at the end of the run")
+	fs.StringVar(&opts.tracePath, "trace", "", "write an execution trace of the run to `file`")
+	registerAnalyzerFlags(fs, analyzers.All)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: %s\n\nRules:\n", usage)
+		for _, a := range analyzers.All {
+			fmt.Fprintf(stderr, "  %-18s %s\n", a.Name, strings.SplitN(a.Doc, "\n", 2)[0])
+		}
+		fmt.Fprintf(stderr, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	return fs, opts
+}
+
+func registerCacheFlags(fs *flag.FlagSet, opts *options) {
+	fs.BoolVar(&opts.useCache, "cache", true, "reuse results for packages unchanged since a previous run")
+	fs.StringVar(&opts.cacheDir, "cache-dir", defaultCacheDir(), "analysis cache `directory`")
+}
+
+func packagePatterns(fs *flag.FlagSet) []string {
+	if fs.NArg() == 0 {
+		return []string{"./..."}
+	}
+	return fs.Args()
+}
+
+// registerAnalyzerFlags exposes each analyzer's own flags as -<rule>.<flag>.
+func registerAnalyzerFlags(fs *flag.FlagSet, as []*analysis.Analyzer) {
+	for _, a := range as {
+		prefix := a.Name + "."
+		a.Flags.VisitAll(func(f *flag.Flag) {
+			fs.Var(f.Value, prefix+f.Name, f.Usage)
+		})
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
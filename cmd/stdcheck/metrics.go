@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ruleMetrics is what running one analyzer cost over a check.
+type ruleMetrics struct {
+	Rule     string        `json:"rule"`
+	Packages int           `json:"packages"` // package variants analyzed, tests included
+	Time     time.Duration `json:"time_ns"`
+	// Allocated counts the bytes allocated on the heap while the analyzer
+	// ran. Batches analyzed at the same time allocate too, so it is exact
+	// only with -parallel=1.
+	Allocated uint64 `json:"allocated_bytes"`
+	Findings  int    `json:"findings"` // as reported, before suppression and configuration
+	// Slowest is the package the analyzer took longest on, and SlowestTime
+	// how long.
+	Slowest     string        `json:"slowest_package"`
+	SlowestTime time.Duration `json:"slowest_time_ns"`
+}
+
+// runMetrics collects the cost of each analyzer over a check, so rules that
+// are slow on large packages can be found and tuned.
+type runMetrics struct {
+	mu     sync.Mutex
+	rules  map[string]*ruleMetrics
+	cached int // directories whose results came from the cache
+}
+
+func newRunMetrics() *runMetrics {
+	return &runMetrics{rules: make(map[string]*ruleMetrics)}
+}
+
+// instrument returns copies of as that record each run in m. Their
+// requirements are shared with the originals.
+func (m *runMetrics) instrument(as []*analysis.Analyzer) []*analysis.Analyzer {
+	out := make([]*analysis.Analyzer, len(as))
+	for i, a := range as {
+		wrapped := *a
+		run := a.Run
+		wrapped.Run = func(pass *analysis.Pass) (any, error) {
+			findings := 0
+			report := pass.Report
+			pass.Report = func(d analysis.Diagnostic) {
+				findings++
+				report(d)
+			}
+			allocs := heapAllocs()
+			start := time.Now()
+			res, err := run(pass)
+			m.record(a.Name, pass.Pkg.Path(), time.Since(start), heapAllocs()-allocs, findings)
+			return res, err
+		}
+		out[i] = &wrapped
+	}
+	return out
+}
+
+func (m *runMetrics) record(rule, pkg string, d time.Duration, allocated uint64, findings int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r := m.rules[rule]
+	if r == nil {
+		r = &ruleMetrics{Rule: rule}
+		m.rules[rule] = r
+	}
+	r.Packages++
+	r.Time += d
+	r.Allocated += allocated
+	r.Findings += findings
+	if d > r.SlowestTime {
+		r.Slowest, r.SlowestTime = pkg, d
+	}
+}
+
+// fromCache counts a directory whose results came from the cache, which
+// costs the analyzers nothing.
+func (m *runMetrics) fromCache() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.cached++
+	m.mu.Unlock()
+}
+
+// sorted returns the metrics of each rule that ran, slowest first, and the
+// number of directories whose results came from the cache.
+func (m *runMetrics) sorted() ([]ruleMetrics, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]ruleMetrics, 0, len(m.rules))
+	for _, r := range m.rules {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Time != out[j].Time {
+			return out[i].Time > out[j].Time
+		}
+		return out[i].Rule < out[j].Rule
+	})
+	return out, m.cached
+}
+
+// write prints a table of the metrics of each rule, slowest first.
+func (m *runMetrics) write(w io.Writer) {
+	rules, cached := m.sorted()
+	if cached > 0 {
+		fmt.Fprintf(w, "stdcheck: %d package(s) not analyzed; their results were cached\n", cached)
+	}
+	if len(rules) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "rule\tpackages\ttime\tallocated\tfindings\tslowest package\n")
+	for _, r := range rules {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%d\t%s (%s)\n", r.Rule, r.Packages, r.Time.Round(time.Microsecond), formatBytes(r.Allocated), r.Findings, r.Slowest, r.SlowestTime.Round(time.Microsecond))
+	}
+	tw.Flush()
+}
+
+// writeFile writes the metrics of each rule, slowest first, to path as JSON,
+// or as CSV if path ends in .csv.
+func (m *runMetrics) writeFile(path string) error {
+	rules, cached := m.sorted()
+	if filepath.Ext(path) == ".csv" {
+		return writeMetricsCSV(path, rules)
+	}
+	data, err := json.MarshalIndent(struct {
+		Cached int           `json:"cached_dirs"`
+		Rules  []ruleMetrics `json:"rules"`
+	}{cached, rules}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+	return nil
+}
+
+// writeMetricsCSV writes a row for each rule to the file at path, with
+// the JSON fields as columns. The count of cached directories, which
+// isn't per rule, is left out.
+func writeMetricsCSV(path string, rules []ruleMetrics) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"rule", "packages", "time_ns", "allocated_bytes", "findings", "slowest_package", "slowest_time_ns"})
+	for _, r := range rules {
+		w.Write([]string{
+			r.Rule,
+			strconv.Itoa(r.Packages),
+			strconv.FormatInt(int64(r.Time), 10),
+			strconv.FormatUint(r.Allocated, 10),
+			strconv.Itoa(r.Findings),
+			r.Slowest,
+			strconv.FormatInt(int64(r.SlowestTime), 10),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+	return nil
+}
+
+// heapAllocs returns the bytes allocated on the heap since the program
+// started.
+func heapAllocs() uint64 {
+	s := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s[0].Value.Uint64()
+}
+
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
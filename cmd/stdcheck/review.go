@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// decision is a reviewer's answer to a proposed fix.
+type decision int
+
+const (
+	decisionAccept decision = iota
+	decisionSkip
+	decisionEdit
+)
+
+const reviewHelp = `y - apply this fix
+n - skip this fix
+e - edit the fixed file in $EDITOR, then apply your version
+a - apply this fix and every later fix of the same rule
+q - apply the fixes accepted so far and skip the rest
+? - show this help
+`
+
+// reviewer asks whether to apply each fix, for fix -interactive.
+type reviewer struct {
+	in     *bufio.Reader
+	out    io.Writer
+	color  bool
+	accept map[string]bool // rules whose fixes are all accepted
+	quit   bool            // set once the reviewer stops reviewing
+}
+
+func newReviewer(in io.Reader, out io.Writer) *reviewer {
+	return &reviewer{
+		in:     bufio.NewReader(in),
+		out:    out,
+		color:  isTerminal(out) && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb",
+		accept: make(map[string]bool),
+	}
+}
+
+// isTerminal reports whether w is a terminal, so diffs written to a file
+// or a pipe aren't colored.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// review shows the diff a fix makes and asks what to do with it.
+func (r *reviewer) review(pf pendingFix, patch string) (decision, error) {
+	if r.accept[pf.rule] {
+		return decisionAccept, nil
+	}
+	if patch == "" {
+		return decisionAccept, nil // only formatting, nothing to ask about
+	}
+	fmt.Fprintf(r.out, "\n%s (%s)\n", pf.message, pf.rule)
+	r.printDiff(patch)
+	for {
+		fmt.Fprintf(r.out, "Apply this fix? [y,n,e,a,q,?] ")
+		line, err := r.in.ReadString('\n')
+		if err != nil && line == "" {
+			if err == io.EOF {
+				// Input ended: keep what was accepted, ask nothing more.
+				fmt.Fprintln(r.out)
+				r.quit = true
+				return decisionSkip, nil
+			}
+			return 0, err
+		}
+		switch strings.TrimSpace(line) {
+		case "y":
+			return decisionAccept, nil
+		case "n":
+			return decisionSkip, nil
+		case "e":
+			return decisionEdit, nil
+		case "a":
+			r.accept[pf.rule] = true
+			return decisionAccept, nil
+		case "q":
+			r.quit = true
+			return decisionSkip, nil
+		default:
+			fmt.Fprint(r.out, reviewHelp)
+		}
+	}
+}
+
+// edit opens the fixed content of the file at rel in the user's editor and
+// returns what they saved.
+func (r *reviewer) edit(rel string, content []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "stdcheck-edit-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	// Keep the file name so the editor picks Go syntax highlighting.
+	name := filepath.Join(dir, filepath.Base(rel))
+	if err := os.WriteFile(name, content, 0o600); err != nil {
+		return nil, err
+	}
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may carry arguments, as in EDITOR="code --wait".
+	args := append(strings.Fields(editor), name)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running editor: %w", err)
+	}
+	return os.ReadFile(name)
+}
+
+// printDiff writes a unified diff, colored like git's when color is on.
+func (r *reviewer) printDiff(patch string) {
+	for _, line := range strings.SplitAfter(patch, "\n") {
+		if line == "" {
+			continue
+		}
+		code := ""
+		if r.color {
+			switch {
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+				code = "\x1b[1m"
+			case strings.HasPrefix(line, "@@"):
+				code = "\x1b[36m"
+			case strings.HasPrefix(line, "-"):
+				code = "\x1b[31m"
+			case strings.HasPrefix(line, "+"):
+				code = "\x1b[32m"
+			}
+		}
+		if code == "" {
+			fmt.Fprint(r.out, line)
+		} else {
+			fmt.Fprintf(r.out, "%s%s\x1b[0m\n", code, strings.TrimSuffix(line, "\n"))
+		}
+	}
+}
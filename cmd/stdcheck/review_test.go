@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewReviewer_Color(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+	file, err := os.Create(filepath.Join(t.TempDir(), "fix.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if newReviewer(strings.NewReader(""), &bytes.Buffer{}).color {
+		t.Error("diffs to a buffer are colored")
+	}
+	if newReviewer(strings.NewReader(""), file).color {
+		t.Error("diffs to a regular file are colored")
+	}
+
+	// A character device stands in for a terminal.
+	dev, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer dev.Close()
+	if info, err := dev.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		t.Skipf("%s is not a character device here", os.DevNull)
+	}
+	if !newReviewer(strings.NewReader(""), dev).color {
+		t.Error("diffs to a character device are not colored")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if newReviewer(strings.NewReader(""), dev).color {
+		t.Error("diffs are colored despite NO_COLOR")
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/check"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/diff"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/skip"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/suppress"
+	"golang.org/x/tools/go/analysis"
+)
+
+// checkStaged runs the syntactic analyzers of st over the Go files staged in
+// the git index, as they are staged rather than as they are in the working
+// tree. Nothing is loaded beyond the staged files themselves, so the check
+// takes a fraction of a second; rules that need type information would need
+// whole packages and their dependencies loaded, and are returned unrun in
+// typed. Results are otherwise as check returns them.
+func checkStaged(st setup) (findings []report.Finding, directives []suppress.Directive, skipped []skip.Item, typed []string, err error) {
+	var run []*analysis.Analyzer
+	for _, a := range st.analyzers {
+		if analyzers.Syntactic(a) {
+			run = append(run, a)
+		} else {
+			typed = append(typed, a.Name)
+		}
+	}
+
+	wd, _ := os.Getwd()
+	ctx := context.Background()
+	git := diff.Git{Relative: true}
+	paths, err := git.Staged(ctx)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("listing staged files: %w", err)
+	}
+
+	fset := token.NewFileSet()
+	content := make(map[string][]byte)
+	pkgs := make(map[string][]*ast.File) // by directory and package name
+	for _, rel := range paths {
+		if !strings.HasSuffix(rel, ".go") {
+			continue
+		}
+		src, err := git.Indexed(ctx, rel)
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+		rel = filepath.ToSlash(rel)
+		if reason := st.policy.Reason(rel, int64(len(src))); reason != "" {
+			skipped = append(skipped, skip.Item{Path: rel, Reason: reason})
+			continue
+		}
+		name := filepath.Join(wd, filepath.FromSlash(rel))
+		file, err := parser.ParseFile(fset, name, src, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("parsing staged %s: %w", rel, err)
+		}
+		content[name] = src
+		directives = append(directives, suppress.Parse(fset, file, rel)...)
+		key := path.Dir(rel) + " " + file.Name.Name
+		pkgs[key] = append(pkgs[key], file)
+	}
+
+	keys := make([]string, 0, len(pkgs))
+	for key := range pkgs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		files := pkgs[key]
+		results := make(map[*analysis.Analyzer]any)
+		for _, a := range run {
+			diags, err := runSyntactic(a, fset, files, content, results)
+			if err != nil {
+				return nil, nil, nil, nil, fmt.Errorf("%s: %s: %w", a.Name, strings.Fields(key)[0], err)
+			}
+			for _, d := range diags {
+				pos := fset.Position(d.Pos)
+				findings = append(findings, report.Finding{
+					Rule:    a.Name,
+					File:    check.Relative(wd, pos.Filename),
+					Line:    pos.Line,
+					Column:  pos.Column,
+					Message: d.Message,
+				})
+			}
+		}
+	}
+	sort.Slice(skipped, func(i, j int) bool { return skipped[i].Path < skipped[j].Path })
+	return settle(findings, st), directives, skipped, typed, nil
+}
+
+// runSyntactic runs a, after the analyzers it requires, over the syntax of
+// one package's files and returns its diagnostics. Results are memoized in
+// results so shared requirements such as inspect run once per package.
+func runSyntactic(a *analysis.Analyzer, fset *token.FileSet, files []*ast.File, content map[string][]byte, results map[*analysis.Analyzer]any) ([]analysis.Diagnostic, error) {
+	resultOf := make(map[*analysis.Analyzer]any)
+	for _, req := range a.Requires {
+		if _, ok := results[req]; !ok {
+			if _, err := runSyntactic(req, fset, files, content, results); err != nil {
+				return nil, err
+			}
+		}
+		resultOf[req] = results[req]
+	}
+
+	var diags []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer:   a,
+		Fset:       fset,
+		Files:      files,
+		Pkg:        types.NewPackage(files[0].Name.Name, files[0].Name.Name),
+		TypesInfo:  emptyInfo(),
+		TypesSizes: types.SizesFor("gc", runtime.GOARCH),
+		Report:     func(d analysis.Diagnostic) { diags = append(diags, d) },
+		ResultOf:   resultOf,
+		ReadFile: func(name string) ([]byte, error) {
+			if src, ok := content[name]; ok {
+				return src, nil
+			}
+			return os.ReadFile(name)
+		},
+		ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+		ImportPackageFact: func(*types.Package, analysis.Fact) bool { return false },
+		ExportObjectFact:  func(types.Object, analysis.Fact) {},
+		ExportPackageFact: func(analysis.Fact) {},
+		AllPackageFacts:   func() []analysis.PackageFact { return nil },
+		AllObjectFacts:    func() []analysis.ObjectFact { return nil },
+	}
+	res, err := a.Run(pass)
+	if err != nil {
+		return nil, err
+	}
+	results[a] = res
+	return diags, nil
+}
+
+// emptyInfo returns a types.Info whose maps are allocated but empty, so
+// lookups in syntactic analyzers find nothing rather than panic.
+func emptyInfo() *types.Info {
+	return &types.Info{
+		Types:        make(map[ast.Expr]types.TypeAndValue),
+		Instances:    make(map[*ast.Ident]types.Instance),
+		Defs:         make(map[*ast.Ident]types.Object),
+		Uses:         make(map[*ast.Ident]types.Object),
+		Implicits:    make(map[ast.Node]types.Object),
+		Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:       make(map[ast.Node]*types.Scope),
+		FileVersions: make(map[*ast.File]string),
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRunCheck_Staged(t *testing.T) {
+	gitRepo(t)
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/a\n\ngo 1.22\n")
+	// a.go is staged without a doc comment and documented since, b.go
+	// isn't staged at all, and notes.txt isn't Go.
+	write("a.go", "// Package a is a test module.\npackage a\n\nfunc A() {}\n")
+	write("notes.txt", "func Notes() {}\n")
+	gitRun(t, "add", "go.mod", "a.go", "notes.txt")
+	write("a.go", "// Package a is a test module.\npackage a\n\n// A does nothing.\nfunc A() {}\n")
+	write("b.go", "package a\n\nfunc B() {}\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-staged"}, &stdout, &stderr)
+
+	if code != exitFindings {
+		t.Errorf("run(-staged) = %d, want %d; stderr:\n%s", code, exitFindings, stderr.String())
+	}
+	if want := "a.go:4:6: exported function A has no doc comment (godoc)\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+	if !strings.Contains(stderr.String(), "staged check ran only syntactic rules; run stdcheck for ") {
+		t.Errorf("stderr doesn't name the rules left unrun:\n%s", stderr.String())
+	}
+
+	// Once the fix is staged too, the commit would pass.
+	gitRun(t, "add", "a.go")
+	stdout.Reset()
+	if code := run([]string{"-staged"}, &stdout, &stderr); code != exitClean || stdout.Len() != 0 {
+		t.Errorf("run(-staged) after staging the fix = %d, %q; want no findings", code, stdout.String())
+	}
+}
+
+func TestRunCheck_Staged_Packages(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-staged", "./..."}, &stdout, &stderr); code != exitError || !strings.Contains(stderr.String(), "-staged takes neither") {
+		t.Errorf("run(-staged ./...) = %d; stderr:\n%s", code, stderr.String())
+	}
+}
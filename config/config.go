@@ -0,0 +1,499 @@
+// Package config loads the project's .standards.yaml, which tunes the
+// standards checks for a repository: which rules run, how severe their
+// findings are, which paths are checked, and per-directory overrides.
+//
+// A complete example:
+//
+//	version: 1
+//	min_version: v1.4.0
+//	rules:
+//	  godoc:
+//	    severity: warn
+//	  factorydecisions:
+//	    enabled: false
+//	include:
+//	  - "**/*.go"
+//	exclude:
+//	  - generated/
+//	  - "**/*_mock.go"
+//	overrides:
+//	  - dir: internal/legacy
+//	    rules:
+//	      factorylogic:
+//	        severity: info
+//	review:
+//	  provider: anthropic
+//	  model: claude-sonnet-4-5
+//	  prompt: standards-compliance/review@v1
+//	  price:
+//	    input: 3
+//	    output: 15
+//	  cache:
+//	    backend: redis
+//	    address: cache.internal:6379
+//	    password_env: REDIS_PASSWORD
+//
+// Every field is optional; a missing file is the same as an empty one, under
+// which every rule is enabled with severity error.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"golang.org/x/mod/semver"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath is the configuration file read from the repository root.
+const DefaultPath = ".standards.yaml"
+
+// SchemaVersion is the newest configuration schema this build understands.
+const SchemaVersion = 1
+
+const upgradeHint = "go install github.com/benjaminabbitt/ai_assisted_requirements_workflow/cmd/stdcheck@latest"
+
+// Config is the parsed configuration file.
+type Config struct {
+	// Version is the schema version the file is written for; 0 means 1.
+	Version int `yaml:"version"`
+	// MinVersion is the oldest stdcheck release, such as v1.4.0, that
+	// understands the file's settings. See CheckBinary.
+	MinVersion string `yaml:"min_version"`
+
+	Rules map[string]Rule `yaml:"rules"`
+	// Include and Exclude are glob patterns matched against slash-separated
+	// paths relative to the repository root. "**" matches any number of
+	// directories, and a trailing "/" matches everything beneath a
+	// directory. A file is checked if it matches some Include pattern (or
+	// Include is empty) and no Exclude pattern.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Overrides adjust rules for the files beneath a directory. When
+	// directories nest, the deeper override takes precedence.
+	Overrides []Override `yaml:"overrides"`
+	// Review configures the AI review.
+	Review Review `yaml:"review"`
+}
+
+// Rule configures one rule. Unset fields inherit the enclosing setting.
+type Rule struct {
+	Enabled  *bool           `yaml:"enabled"`
+	Severity report.Severity `yaml:"severity"`
+}
+
+// Override applies rule settings to the files beneath Dir.
+type Override struct {
+	Dir   string          `yaml:"dir"`
+	Rules map[string]Rule `yaml:"rules"`
+}
+
+// Review configures the AI review: the model that runs it and the prompt it
+// renders.
+type Review struct {
+	Provider string `yaml:"provider"` // openai, anthropic or ollama
+	Model    string `yaml:"model"`
+	// BaseURL is the provider's API endpoint, for gateways and remote
+	// Ollama servers; each provider has a default.
+	BaseURL string `yaml:"base_url"`
+	// APIKeyEnv names the environment variable holding the API key. It
+	// defaults to OPENAI_API_KEY or ANTHROPIC_API_KEY; the key itself never
+	// belongs in the file.
+	APIKeyEnv   string   `yaml:"api_key_env"`
+	MaxTokens   int      `yaml:"max_tokens"`
+	Temperature *float64 `yaml:"temperature"` // the model's default if unset
+	// Prompt is the prompt template to render, such as
+	// standards-compliance/review@v1; see package prompt.
+	Prompt string `yaml:"prompt"`
+	// Price overrides the model's list price, for models the cost
+	// report doesn't know and for negotiated rates.
+	Price *Price `yaml:"price"`
+	// RequestsPerMinute limits the rate of calls to the provider; 0 means
+	// no limit.
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	// MaxRetries is how many times a call rejected with 429 or 5xx, or
+	// failed in transit, is retried with exponential backoff; 3 if unset.
+	MaxRetries *int `yaml:"max_retries"`
+	// BreakAfter is how many files in a row may fail so before the review
+	// stops calling the provider; 3 if unset, and 0 means never.
+	BreakAfter *int `yaml:"break_after"`
+	// Fallback is what the files the provider couldn't review get:
+	// "static", the default, reports the static analyzers' findings in
+	// them instead, and "none" fails the run.
+	Fallback string `yaml:"fallback"`
+	// Cache is where the review keeps the verdicts of files it has
+	// reviewed, so unchanged files aren't sent again.
+	Cache Cache `yaml:"cache"`
+}
+
+// Cache configures the store of review verdicts; see package llm/memo.
+type Cache struct {
+	// Backend is "dir", the default, for a local directory, "bolt" for a
+	// local database file, "redis" to share verdicts between CI runners,
+	// or "off".
+	Backend string `yaml:"backend"`
+	// Dir is the directory of the dir backend, and Path the database file
+	// of the bolt backend; both default to one under the user's cache
+	// directory.
+	Dir     string `yaml:"dir"`
+	Path    string `yaml:"path"`
+	Address string `yaml:"address"` // the Redis server's host:port
+	DB      int    `yaml:"db"`
+	// PasswordEnv names the environment variable holding the Redis
+	// password, if the server needs one.
+	PasswordEnv string        `yaml:"password_env"`
+	TTL         time.Duration `yaml:"ttl"` // how long Redis keeps a verdict; forever if unset
+}
+
+// Price is what a model costs, in US dollars per million tokens.
+type Price struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// reviewProviders are the valid values of Review.Provider, with the default
+// environment variable of each one's API key.
+var reviewProviders = map[string]string{
+	"anthropic": "ANTHROPIC_API_KEY",
+	"ollama":    "",
+	"openai":    "OPENAI_API_KEY",
+}
+
+// APIKeyVar returns the name of the environment variable holding the
+// provider's API key, or "" if the provider needs none.
+func (r Review) APIKeyVar() string {
+	if r.APIKeyEnv != "" {
+		return r.APIKeyEnv
+	}
+	return reviewProviders[r.Provider]
+}
+
+// APIKey returns the provider's API key from the environment, or "" if it
+// isn't set.
+func (r Review) APIKey() string {
+	if name := r.APIKeyVar(); name != "" {
+		return os.Getenv(name)
+	}
+	return ""
+}
+
+// Password returns the Redis password from the environment, or "" if none
+// is configured.
+func (c Cache) Password() string {
+	if c.PasswordEnv != "" {
+		return os.Getenv(c.PasswordEnv)
+	}
+	return ""
+}
+
+// Load reads the configuration file at path. A missing file yields an empty
+// configuration.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, err := Parse(f)
+	if err != nil {
+		return nil, prefixErrors(path, err)
+	}
+	return c, nil
+}
+
+// prefixErrors prefixes err, or each error joined in it, with path.
+func prefixErrors(path string, err error) error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, fmt.Errorf("%s: %w", path, e))
+	}
+	return errors.Join(errs...)
+}
+
+// Parse reads a configuration from r and validates it. Unknown keys are
+// errors, each reported with a suggested spelling where one is close, so
+// typos don't silently disable a setting. A file written for a
+// newer schema is rejected before its keys are checked, so the error says to
+// upgrade rather than listing unknown fields.
+func Parse(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if probe.Version > SchemaVersion {
+		return nil, fmt.Errorf("config schema version %d is newer than this stdcheck supports (%d); upgrade stdcheck: %s", probe.Version, SchemaVersion, upgradeHint)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := checkKeys(&doc); err != nil {
+		return nil, err
+	}
+
+	var c Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	for i := range c.Overrides {
+		c.Overrides[i].Dir = cleanDir(c.Overrides[i].Dir)
+	}
+	// Shallowest directories first, so applying overrides in order lets
+	// deeper directories take precedence.
+	sort.SliceStable(c.Overrides, func(i, j int) bool {
+		return depth(c.Overrides[i].Dir) < depth(c.Overrides[j].Dir)
+	})
+	return &c, nil
+}
+
+func (c *Config) validate() error {
+	if c.MinVersion != "" && !semver.IsValid(c.MinVersion) {
+		return fmt.Errorf("min_version %q is not a semantic version such as v1.4.0", c.MinVersion)
+	}
+	check := func(where string, rules map[string]Rule) error {
+		for name, r := range rules {
+			switch r.Severity {
+			case "", report.SeverityError, report.SeverityWarn, report.SeverityInfo:
+			default:
+				return fmt.Errorf("%srule %s: unknown severity %q (want error, warn or info)", where, name, r.Severity)
+			}
+		}
+		return nil
+	}
+	if err := check("", c.Rules); err != nil {
+		return err
+	}
+	for _, pattern := range append(append([]string(nil), c.Include...), c.Exclude...) {
+		for _, seg := range strings.Split(pattern, "/") {
+			if _, err := path.Match(seg, ""); err != nil {
+				return fmt.Errorf("bad pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	for _, o := range c.Overrides {
+		if strings.TrimSpace(o.Dir) == "" {
+			return errors.New("override without dir")
+		}
+		if err := check("override "+o.Dir+": ", o.Rules); err != nil {
+			return err
+		}
+	}
+	return c.Review.validate()
+}
+
+func (r Review) validate() error {
+	if _, ok := reviewProviders[r.Provider]; r.Provider != "" && !ok {
+		return fmt.Errorf("review: unknown provider %q (want anthropic, ollama or openai)", r.Provider)
+	}
+	if r.MaxTokens < 0 {
+		return fmt.Errorf("review: max_tokens %d is negative", r.MaxTokens)
+	}
+	if t := r.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return fmt.Errorf("review: temperature %g is outside 0 to 2", *t)
+	}
+	if p := r.Price; p != nil && (p.Input < 0 || p.Output < 0) {
+		return fmt.Errorf("review: price is negative")
+	}
+	if r.RequestsPerMinute < 0 {
+		return fmt.Errorf("review: requests_per_minute %d is negative", r.RequestsPerMinute)
+	}
+	if n := r.MaxRetries; n != nil && *n < 0 {
+		return fmt.Errorf("review: max_retries %d is negative", *n)
+	}
+	if n := r.BreakAfter; n != nil && *n < 0 {
+		return fmt.Errorf("review: break_after %d is negative", *n)
+	}
+	switch r.Fallback {
+	case "", "static", "none":
+	default:
+		return fmt.Errorf("review: unknown fallback %q (want static or none)", r.Fallback)
+	}
+	switch c := r.Cache; c.Backend {
+	case "", "dir", "bolt", "off":
+	case "redis":
+		if c.Address == "" {
+			return fmt.Errorf("review: cache: the redis backend needs an address")
+		}
+	default:
+		return fmt.Errorf("review: cache: unknown backend %q (want dir, bolt, redis or off)", c.Backend)
+	}
+	if r.Cache.DB < 0 || r.Cache.TTL < 0 {
+		return fmt.Errorf("review: cache: db and ttl must not be negative")
+	}
+	return nil
+}
+
+// CheckBinary reports an error if the running binary, whose module version
+// is version, is older than the configuration's MinVersion. Development
+// builds carry no release version and always pass.
+func (c *Config) CheckBinary(version string) error {
+	if c.MinVersion == "" || !semver.IsValid(version) {
+		return nil
+	}
+	if semver.Compare(version, c.MinVersion) < 0 {
+		return fmt.Errorf("config requires stdcheck %s or newer, but this is %s; upgrade stdcheck: %s", c.MinVersion, version, upgradeHint)
+	}
+	return nil
+}
+
+// RuleNames returns every rule named in the configuration, sorted, so
+// callers can reject unknown names.
+func (c *Config) RuleNames() []string {
+	seen := make(map[string]bool)
+	for name := range c.Rules {
+		seen[name] = true
+	}
+	for _, o := range c.Overrides {
+		for name := range o.Rules {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Setting is the effective configuration of a rule for one file.
+type Setting struct {
+	Enabled  bool
+	Severity report.Severity
+}
+
+// For returns the effective setting of rule for the file at rel, a
+// slash-separated path relative to the repository root.
+func (c *Config) For(rule, rel string) Setting {
+	s := Setting{Enabled: true, Severity: report.SeverityError}
+	apply := func(r Rule) {
+		if r.Enabled != nil {
+			s.Enabled = *r.Enabled
+		}
+		if r.Severity != "" {
+			s.Severity = r.Severity
+		}
+	}
+	apply(c.Rules[rule])
+	for _, o := range c.Overrides {
+		if r, ok := o.Rules[rule]; ok && within(o.Dir, rel) {
+			apply(r)
+		}
+	}
+	return s
+}
+
+// MaybeEnabled reports whether rule is enabled for at least some files, so
+// callers can avoid running rules that are disabled everywhere.
+func (c *Config) MaybeEnabled(rule string) bool {
+	if r := c.Rules[rule]; r.Enabled == nil || *r.Enabled {
+		return true
+	}
+	for _, o := range c.Overrides {
+		if r := o.Rules[rule]; r.Enabled != nil && *r.Enabled {
+			return true
+		}
+	}
+	return false
+}
+
+// Excluded returns the reason the file at rel is not checked, or "" if it
+// is.
+func (c *Config) Excluded(rel string) string {
+	if len(c.Include) > 0 && !matchAny(c.Include, rel) {
+		return "not in include patterns"
+	}
+	for _, pattern := range c.Exclude {
+		if Match(pattern, rel) {
+			return "excluded by " + pattern
+		}
+	}
+	return ""
+}
+
+func matchAny(patterns []string, rel string) bool {
+	for _, p := range patterns {
+		if Match(p, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the slash-separated path rel matches pattern. Path
+// segments are matched with path.Match; a "**" segment matches zero or more
+// segments, and a trailing "/" matches everything beneath the directory.
+func Match(pattern, rel string) bool {
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+func cleanDir(dir string) string {
+	dir = path.Clean(strings.TrimSpace(dir))
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return strings.TrimPrefix(dir, "./")
+}
+
+func depth(dir string) int {
+	if dir == "" {
+		return 0
+	}
+	return strings.Count(dir, "/") + 1
+}
+
+// within reports whether rel lies beneath dir; the empty dir is the root.
+func within(dir, rel string) bool {
+	return dir == "" || strings.HasPrefix(rel, dir+"/")
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+func TestLoad_Files(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // written to .standards.yaml unless "-"
+		wantErr string // a substring of the error, if any
+		check   func(t *testing.T, c *Config)
+	}{
+		{
+			name: "missing",
+			file: "-",
+			check: func(t *testing.T, c *Config) {
+				if !reflect.DeepEqual(c, &Config{}) {
+					t.Errorf("Load() = %+v, want an empty config", c)
+				}
+			},
+		},
+		{
+			name: "empty",
+			file: "",
+			check: func(t *testing.T, c *Config) {
+				if s := c.For("godoc", "a.go"); s != (Setting{Enabled: true, Severity: report.SeverityError}) {
+					t.Errorf("For() = %+v, want enabled errors", s)
+				}
+			},
+		},
+		{
+			name: "rules and overrides",
+			file: `version: 1
+min_version: v1.4.0
+rules:
+  godoc:
+    severity: warn
+  factorydecisions:
+    enabled: false
+overrides:
+  - dir: ./internal/legacy/old/
+    rules:
+      godoc:
+        enabled: false
+  - dir: internal/legacy
+    rules:
+      godoc:
+        severity: info
+      factorydecisions:
+        enabled: true
+review:
+  provider: anthropic
+  cache:
+    backend: redis
+    address: localhost:6379
+    ttl: 24h
+`,
+			check: func(t *testing.T, c *Config) {
+				if got := []string{c.Overrides[0].Dir, c.Overrides[1].Dir}; !reflect.DeepEqual(got, []string{"internal/legacy", "internal/legacy/old"}) {
+					t.Errorf("override dirs = %q, want them cleaned and shallowest first", got)
+				}
+				if got := c.RuleNames(); !reflect.DeepEqual(got, []string{"factorydecisions", "godoc"}) {
+					t.Errorf("RuleNames() = %q", got)
+				}
+				if got := c.Review.APIKeyVar(); got != "ANTHROPIC_API_KEY" {
+					t.Errorf("APIKeyVar() = %q, want ANTHROPIC_API_KEY", got)
+				}
+				if got := c.Review.Cache.TTL.Hours(); got != 24 {
+					t.Errorf("cache ttl = %vh, want 24h", got)
+				}
+			},
+		},
+		{name: "unknown key", file: "rules:\n  godoc:\n    severty: warn\n", wantErr: `line 3: unknown key "severty" in rule godoc; did you mean "severity"?`},
+		{name: "unknown severity", file: "rules:\n  godoc:\n    severity: fatal\n", wantErr: `rule godoc: unknown severity "fatal"`},
+		{name: "newer schema", file: "version: 2\nfuture: true\n", wantErr: "config schema version 2 is newer than this stdcheck supports"},
+		{name: "bad min_version", file: "min_version: 1.4\n", wantErr: `min_version "1.4" is not a semantic version`},
+		{name: "bad pattern", file: "exclude:\n  - \"[\"\n", wantErr: `bad pattern "["`},
+		{name: "override without dir", file: "overrides:\n  - rules: {}\n", wantErr: "override without dir"},
+		{name: "unknown provider", file: "review:\n  provider: gemini\n", wantErr: `unknown provider "gemini"`},
+		{name: "redis without address", file: "review:\n  cache:\n    backend: redis\n", wantErr: "the redis backend needs an address"},
+		{name: "not yaml", file: "rules: [\n", wantErr: "parsing config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), DefaultPath)
+			if tt.file != "-" {
+				if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			c, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				if !strings.HasPrefix(err.Error(), path+": ") {
+					t.Errorf("Load() error = %v, want it prefixed with the path", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestConfig_For(t *testing.T) {
+	c, err := Parse(strings.NewReader(`rules:
+  godoc:
+    severity: warn
+  nildeps:
+    enabled: false
+overrides:
+  - dir: legacy
+    rules:
+      godoc:
+        severity: info
+      nildeps:
+        enabled: true
+  - dir: legacy/gen
+    rules:
+      godoc:
+        enabled: false
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rule, rel string
+		want      Setting
+	}{
+		{"godoc", "main.go", Setting{true, report.SeverityWarn}},
+		{"godoc", "legacy/a.go", Setting{true, report.SeverityInfo}},
+		{"godoc", "legacy/gen/a.go", Setting{false, report.SeverityInfo}},
+		{"godoc", "legacyx/a.go", Setting{true, report.SeverityWarn}},
+		{"nildeps", "main.go", Setting{false, report.SeverityError}},
+		{"nildeps", "legacy/a.go", Setting{true, report.SeverityError}},
+		{"factorylogic", "legacy/a.go", Setting{true, report.SeverityError}},
+	}
+	for _, tt := range tests {
+		if got := c.For(tt.rule, tt.rel); got != tt.want {
+			t.Errorf("For(%q, %q) = %+v, want %+v", tt.rule, tt.rel, got, tt.want)
+		}
+	}
+	if !c.MaybeEnabled("nildeps") {
+		t.Error("MaybeEnabled(nildeps) = false, want true: an override enables it")
+	}
+}
+
+func TestConfig_Excluded(t *testing.T) {
+	c := &Config{Include: []string{"**/*.go"}, Exclude: []string{"generated/", "**/*_mock.go"}}
+	tests := []struct {
+		rel, want string
+	}{
+		{"main.go", ""},
+		{"a/b/c.go", ""},
+		{"README.md", "not in include patterns"},
+		{"generated/x.go", "excluded by generated/"},
+		{"a/generated/x.go", ""},
+		{"a/store_mock.go", "excluded by **/*_mock.go"},
+	}
+	for _, tt := range tests {
+		if got := c.Excluded(tt.rel); got != tt.want {
+			t.Errorf("Excluded(%q) = %q, want %q", tt.rel, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Known keys at each level of the file. Keep in sync with the yaml tags on
+// Config, Rule, Override, Review, Price and Cache, and with schema.json.
+var (
+	topKeys      = []string{"version", "min_version", "rules", "include", "exclude", "overrides", "review"}
+	ruleKeys     = []string{"enabled", "severity"}
+	overrideKeys = []string{"dir", "rules"}
+	reviewKeys   = []string{"provider", "model", "base_url", "api_key_env", "max_tokens", "temperature", "prompt", "price", "requests_per_minute", "max_retries", "break_after", "fallback", "cache"}
+	priceKeys    = []string{"input", "output"}
+	cacheKeys    = []string{"backend", "dir", "path", "address", "db", "password_env", "ttl"}
+)
+
+// checkKeys reports every unknown key in the document, with a suggestion
+// when a known key is spelled similarly.
+func checkKeys(doc *yaml.Node) error {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		doc = doc.Content[0]
+	}
+	var errs []error
+	unknown := func(where string, key *yaml.Node, known []string) {
+		msg := fmt.Sprintf("line %d: unknown key %q%s", key.Line, key.Value, where)
+		if s := Suggest(key.Value, known); s != "" {
+			msg += fmt.Sprintf("; did you mean %q?", s)
+		}
+		errs = append(errs, errors.New(msg))
+	}
+	rules := func(where string, n *yaml.Node) {
+		eachKey(n, func(rule string, _, v *yaml.Node) {
+			eachKey(v, func(_ string, k, _ *yaml.Node) {
+				if !contains(ruleKeys, k.Value) {
+					unknown(fmt.Sprintf(" in %srule %s", where, rule), k, ruleKeys)
+				}
+			})
+		})
+	}
+	eachKey(doc, func(key string, k, v *yaml.Node) {
+		switch key {
+		case "rules":
+			rules("", v)
+		case "overrides":
+			if v.Kind != yaml.SequenceNode {
+				return
+			}
+			for i, o := range v.Content {
+				eachKey(o, func(key string, k, v *yaml.Node) {
+					switch {
+					case key == "rules":
+						rules(fmt.Sprintf("override %d ", i+1), v)
+					case !contains(overrideKeys, key):
+						unknown(fmt.Sprintf(" in override %d", i+1), k, overrideKeys)
+					}
+				})
+			}
+		case "review":
+			eachKey(v, func(key string, k, v *yaml.Node) {
+				switch {
+				case key == "price":
+					eachKey(v, func(key string, k, _ *yaml.Node) {
+						if !contains(priceKeys, key) {
+							unknown(" in review price", k, priceKeys)
+						}
+					})
+				case key == "cache":
+					eachKey(v, func(key string, k, _ *yaml.Node) {
+						if !contains(cacheKeys, key) {
+							unknown(" in review cache", k, cacheKeys)
+						}
+					})
+				case !contains(reviewKeys, key):
+					unknown(" in review", k, reviewKeys)
+				}
+			})
+		default:
+			if !contains(topKeys, key) {
+				unknown("", k, topKeys)
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// eachKey calls fn for each key of a mapping node, in order.
+func eachKey(n *yaml.Node, fn func(key string, k, v *yaml.Node)) {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		fn(n.Content[i].Value, n.Content[i], n.Content[i+1])
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Suggest returns the candidate closest to name if it is a plausible typo:
+// within an edit distance of 2, or a third of name's length for long names.
+// It returns "" if no candidate is close enough.
+func Suggest(name string, candidates []string) string {
+	limit := max(2, len(name)/3)
+	best, bestDist := "", limit+1
+	sorted := append([]string(nil), candidates...)
+	sort.Strings(sorted)
+	for _, c := range sorted {
+		if d := editDistance(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A migration upgrades a document of one schema version to the next, in
+// place, and describes each change it made.
+type migration func(root *yaml.Node) []string
+
+// migrations holds the migration from each schema version to the next,
+// keyed by the version it upgrades. Schema 1 is the first and nothing in it
+// is deprecated, so there are none yet, and until schema 2 Migrate only
+// records the version. The schema that deprecates an option bumps
+// SchemaVersion and adds the migration here that rewrites the option to its
+// replacement.
+var migrations = map[int]migration{}
+
+// Migrate rewrites the configuration file data to SchemaVersion. It applies
+// the migration of each schema version between the file's and the current
+// one, and records the current version in the file. Comments and key order
+// are kept. It returns the rewritten file and a description of each change;
+// a file that is already current is returned as it is, with no changes.
+func Migrate(data []byte) ([]byte, []string, error) {
+	return migrate(data, SchemaVersion, migrations)
+}
+
+// migrate rewrites data to schema version to with steps, the migrations
+// keyed by the version each upgrades.
+func migrate(data []byte, to int, steps map[int]migration) ([]byte, []string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing config: %w", err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("parsing config: line %d: the file is not a mapping", root.Line)
+	}
+
+	from, versionNode := 1, (*yaml.Node)(nil)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "version" {
+			versionNode = root.Content[i+1]
+			v, err := strconv.Atoi(versionNode.Value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: version %q is not a number", versionNode.Line, versionNode.Value)
+			}
+			from = max(v, 1) // 0 means 1
+			break
+		}
+	}
+	if from > to {
+		return nil, nil, fmt.Errorf("config schema version %d is newer than this stdcheck supports (%d); upgrade stdcheck: %s", from, to, upgradeHint)
+	}
+
+	var changes []string
+	for v := from; v < to; v++ {
+		if m := steps[v]; m != nil {
+			changes = append(changes, m(root)...)
+		}
+	}
+	current := strconv.Itoa(to)
+	switch {
+	case versionNode == nil:
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+		if len(root.Content) > 0 {
+			// Keep the file's leading comment at the top.
+			key.HeadComment, root.Content[0].HeadComment = root.Content[0].HeadComment, ""
+		}
+		value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: current}
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+		changes = append(changes, fmt.Sprintf("set version: %d", to))
+	case versionNode.Value != current:
+		changes = append(changes, fmt.Sprintf("version: %s -> %d", versionNode.Value, to))
+		versionNode.Value = current
+	}
+	if len(changes) == 0 {
+		return data, nil, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("writing config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("writing config: %w", err)
+	}
+	return buf.Bytes(), changes, nil
+}
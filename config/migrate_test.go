@@ -0,0 +1,109 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate_Versions(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		changes []string
+		wantErr string
+	}{
+		{name: "current", in: "version: 1\nrules: {}\n", want: "version: 1\nrules: {}\n"},
+		{name: "unversioned", in: "# Standards.\nrules:\n  godoc:\n    severity: warn\n", want: "# Standards.\nversion: 1\nrules:\n  godoc:\n    severity: warn\n", changes: []string{"set version: 1"}},
+		{name: "version 0", in: "version: 0\n", want: "version: 1\n", changes: []string{"version: 0 -> 1"}},
+		{name: "newer", in: "version: 9\n", wantErr: "config schema version 9 is newer"},
+		{name: "not a mapping", in: "- a\n", wantErr: "the file is not a mapping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changes, err := Migrate([]byte(tt.in))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Migrate() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want || !reflect.DeepEqual(changes, tt.changes) {
+				t.Errorf("Migrate() = %q, %q; want %q, %q", got, changes, tt.want, tt.changes)
+			}
+		})
+	}
+}
+
+func TestMigrate_Steps(t *testing.T) {
+	// A stand-in schema 3: schema 2 renamed review.api_key_env to
+	// review.key_env, and schema 3 dropped min_version.
+	steps := map[int]migration{
+		1: func(root *yaml.Node) []string {
+			var changes []string
+			eachKey(root, func(key string, _, v *yaml.Node) {
+				if key != "review" {
+					return
+				}
+				eachKey(v, func(key string, k, _ *yaml.Node) {
+					if key == "api_key_env" {
+						k.Value = "key_env"
+						changes = append(changes, "review.api_key_env -> review.key_env")
+					}
+				})
+			})
+			return changes
+		},
+		2: func(root *yaml.Node) []string {
+			for i := 0; i+1 < len(root.Content); i += 2 {
+				if root.Content[i].Value == "min_version" {
+					root.Content = append(root.Content[:i], root.Content[i+2:]...)
+					return []string{"removed min_version"}
+				}
+			}
+			return nil
+		},
+	}
+	in := "version: 1\nmin_version: v1.4.0\nreview:\n  # The gateway's key.\n  api_key_env: GATEWAY_KEY\n"
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		changes []string
+	}{
+		{
+			name:    "from 1",
+			in:      in,
+			want:    "version: 3\nreview:\n  # The gateway's key.\n  key_env: GATEWAY_KEY\n",
+			changes: []string{"review.api_key_env -> review.key_env", "removed min_version", "version: 1 -> 3"},
+		},
+		{
+			name:    "from 2",
+			in:      strings.Replace(in, "version: 1", "version: 2", 1),
+			want:    "version: 3\nreview:\n  # The gateway's key.\n  api_key_env: GATEWAY_KEY\n",
+			changes: []string{"removed min_version", "version: 2 -> 3"},
+		},
+		{
+			name: "current",
+			in:   "version: 3\nreview:\n  key_env: GATEWAY_KEY\n",
+			want: "version: 3\nreview:\n  key_env: GATEWAY_KEY\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changes, err := migrate([]byte(tt.in), 3, steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want || !reflect.DeepEqual(changes, tt.changes) {
+				t.Errorf("migrate() = %q, %q; want %q, %q", got, changes, tt.want, tt.changes)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Hunk is one hunk of a unified diff: lines Old, starting at line OldStart
+// of the old file, are replaced by lines New, which start at line NewStart
+// of the new file. Lines keep their terminators. As in the hunk header, a
+// start with no lines is the line the hunk follows.
+type Hunk struct {
+	OldStart, NewStart int
+	Old, New           []string
+}
+
+// FilePatch is the hunks of a unified diff that apply to one file.
+type FilePatch struct {
+	Path  string // as in the +++ header, without the b/ prefix
+	Hunks []Hunk
+}
+
+// Reverse returns the patch that undoes p.
+func (p FilePatch) Reverse() FilePatch {
+	r := FilePatch{Path: p.Path}
+	for _, h := range p.Hunks {
+		r.Hunks = append(r.Hunks, Hunk{OldStart: h.NewStart, NewStart: h.OldStart, Old: h.New, New: h.Old})
+	}
+	return r
+}
+
+var fullHunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? \+(\d+)(?:,(\d+))? @@`)
+
+// ParsePatch reads a unified diff, such as one written by Unified, into the
+// hunks of each file. Text before the first file header is ignored.
+func ParsePatch(r io.Reader) ([]FilePatch, error) {
+	var patches []FilePatch
+	var cur *FilePatch
+	var hunk *Hunk
+	var oldLeft, newLeft int
+	var last byte // kind of the previous hunk line: ' ', '-' or '+'
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			if err != io.EOF {
+				return nil, fmt.Errorf("reading patch: %w", err)
+			}
+			break
+		}
+		if hunk != nil && (oldLeft > 0 || newLeft > 0) && line == "\n" {
+			line = " \n" // some tools strip the space of empty context lines
+		}
+		switch {
+		case hunk != nil && (oldLeft > 0 || newLeft > 0) && strings.ContainsRune(" -+", rune(line[0])):
+			text := line[1:]
+			last = line[0]
+			if line[0] != '+' {
+				hunk.Old = append(hunk.Old, text)
+				oldLeft--
+			}
+			if line[0] != '-' {
+				hunk.New = append(hunk.New, text)
+				newLeft--
+			}
+		case strings.HasPrefix(line, `\ `):
+			// "\ No newline at end of file" applies to the line before.
+			if hunk != nil && last != '+' {
+				trimLast(hunk.Old)
+			}
+			if hunk != nil && last != '-' {
+				trimLast(hunk.New)
+			}
+		case strings.HasPrefix(line, "+++ "):
+			path := cleanPath(strings.TrimSuffix(strings.TrimPrefix(line, "+++ "), "\n"))
+			patches = append(patches, FilePatch{Path: path})
+			cur = &patches[len(patches)-1]
+			hunk = nil
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				return nil, fmt.Errorf("hunk before file header: %q", strings.TrimSpace(line))
+			}
+			m := fullHunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("malformed hunk header %q", strings.TrimSpace(line))
+			}
+			oldStart, _ := strconv.Atoi(m[1])
+			newStart, _ := strconv.Atoi(m[3])
+			oldLeft, newLeft = count(m[2]), count(m[4])
+			cur.Hunks = append(cur.Hunks, Hunk{OldStart: oldStart, NewStart: newStart})
+			hunk = &cur.Hunks[len(cur.Hunks)-1]
+		}
+	}
+	return patches, nil
+}
+
+func count(s string) int {
+	if s == "" {
+		return 1
+	}
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// trimLast removes the newline from the last of lines.
+func trimLast(lines []string) {
+	if n := len(lines); n > 0 && strings.HasSuffix(lines[n-1], "\n") {
+		lines[n-1] = strings.TrimSuffix(lines[n-1], "\n")
+	}
+}
+
+// Apply applies the hunks of p to content. A hunk whose lines have moved,
+// because of edits made elsewhere in the file since the patch was made, is
+// applied where its old lines now are; the nearest match to the expected
+// position wins. Apply fails if any hunk's old lines, context included, no
+// longer appear.
+func (p FilePatch) Apply(content []byte) ([]byte, error) {
+	lines := splitLines(string(content))
+	var out []string
+	pos := 0    // lines before pos have been copied to out
+	offset := 0 // how far the file has moved relative to the patch
+	for i, h := range p.Hunks {
+		want := h.OldStart - 1 + offset
+		if len(h.Old) == 0 {
+			want = h.OldStart + offset // inserts after line OldStart
+		}
+		at := find(lines, h.Old, want, pos)
+		if at < 0 {
+			return nil, fmt.Errorf("%s: hunk %d (line %d) does not apply", p.Path, i+1, h.OldStart)
+		}
+		offset = at - (want - offset)
+		out = append(out, lines[pos:at]...)
+		out = append(out, h.New...)
+		pos = at + len(h.Old)
+	}
+	out = append(out, lines[pos:]...)
+	return []byte(strings.Join(out, "")), nil
+}
+
+// find returns the index at or after from closest to want where the lines
+// old start, or -1.
+func find(lines, old []string, want, from int) int {
+	matches := func(at int) bool {
+		if at < from || at+len(old) > len(lines) {
+			return false
+		}
+		for i, l := range old {
+			if lines[at+i] != l {
+				return false
+			}
+		}
+		return true
+	}
+	for d := 0; want-d >= from || want+d <= len(lines); d++ {
+		if matches(want - d) {
+			return want - d
+		}
+		if matches(want + d) {
+			return want + d
+		}
+	}
+	return -1
+}
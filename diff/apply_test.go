@@ -0,0 +1,51 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilePatch_Apply(t *testing.T) {
+	const old = "package p\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n"
+	const new = "package p\n\n// a does nothing.\nfunc a() {}\n\nfunc b() {}\n\nfunc c() { return }\n"
+	tests := []struct {
+		name      string
+		reverse   bool
+		content   string
+		want      string
+		wantError bool
+	}{
+		{name: "forward", content: old, want: new},
+		{name: "reverse", reverse: true, content: new, want: old},
+		{name: "moved", content: "// Package p.\n" + old, want: "// Package p.\n" + new},
+		{name: "conflict", content: strings.Replace(old, "func c() {}", "func d() {}", 1), wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches, err := ParsePatch(strings.NewReader(Unified("p.go", []byte(old), []byte(new))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(patches) != 1 || patches[0].Path != "p.go" {
+				t.Fatalf("ParsePatch() = %+v, want one patch of p.go", patches)
+			}
+			p := patches[0]
+			if tt.reverse {
+				p = p.Reverse()
+			}
+			got, err := p.Apply([]byte(tt.content))
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("Apply() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Apply() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
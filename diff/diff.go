@@ -0,0 +1,117 @@
+// Package diff resolves which lines of which files changed, from a version
+// control system or a patch file, so checks can be limited to changed code.
+package diff
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Range is an inclusive range of line numbers in the new version of a file.
+type Range struct {
+	Start, End int
+}
+
+// File lists the changed line ranges of one file, in ascending order.
+type File struct {
+	Path  string // slash-separated, relative to the repository root
+	Lines []Range
+}
+
+// Contains reports whether line lies in one of the changed ranges.
+func (f File) Contains(line int) bool {
+	for _, r := range f.Lines {
+		if r.Start <= line && line <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether any changed range intersects [start, end].
+func (f File) Overlaps(start, end int) bool {
+	for _, r := range f.Lines {
+		if r.Start <= end && start <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Provider is a source of changes.
+type Provider interface {
+	Changes(ctx context.Context) ([]File, error)
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// Parse reads a unified diff, as produced by git diff, hg diff or diff -u,
+// and returns the changed line ranges of each file that still exists. A
+// hunk that only deletes lines is recorded as touching the line it follows,
+// so code around a deletion still counts as changed.
+func Parse(r io.Reader) ([]File, error) {
+	var files []File
+	var cur *File
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			path := cleanPath(strings.TrimPrefix(line, "+++ "))
+			if path == "" {
+				cur = nil // deleted file
+				continue
+			}
+			files = append(files, File{Path: path})
+			cur = &files[len(files)-1]
+		case strings.HasPrefix(line, "@@ "):
+			if cur == nil {
+				continue
+			}
+			m := hunkHeader.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("malformed hunk header %q", line)
+			}
+			start, _ := strconv.Atoi(m[1])
+			count := 1
+			if m[2] != "" {
+				count, _ = strconv.Atoi(m[2])
+			}
+			if count == 0 {
+				if start == 0 {
+					start = 1
+				}
+				count = 1
+			}
+			cur.Lines = append(cur.Lines, Range{Start: start, End: start + count - 1})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading diff: %w", err)
+	}
+	return files, nil
+}
+
+// cleanPath strips the a/ or b/ prefix, timestamps and quoting from a
+// +++ header path. It returns "" for /dev/null.
+func cleanPath(p string) string {
+	if i := strings.IndexByte(p, '\t'); i >= 0 {
+		p = p[:i] // diff -u appends a timestamp after a tab
+	}
+	if unq, err := strconv.Unquote(p); err == nil {
+		p = unq
+	}
+	if p == "/dev/null" {
+		return ""
+	}
+	if strings.HasPrefix(p, "b/") {
+		p = p[2:]
+	}
+	return p
+}
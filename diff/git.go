@@ -0,0 +1,135 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// Git reports the changes in a git working tree since it diverged from
+// Base, including uncommitted changes and untracked files.
+type Git struct {
+	Dir  string // working tree; "" means the current directory
+	Base string // any revision git diff accepts, such as origin/main
+	// Relative limits the changes to files beneath Dir and reports their
+	// paths relative to Dir instead of the repository root.
+	Relative bool
+}
+
+// Changes diffs the working tree against the merge base of Base and HEAD,
+// so commits on Base since the branch was made don't count as changes.
+// Untracked files that .gitignore doesn't exclude are reported as changed
+// throughout.
+func (g Git) Changes(ctx context.Context) ([]File, error) {
+	args := []string{"diff", "--unified=0", "--no-color", "--no-ext-diff", "--no-renames"}
+	if g.Relative {
+		args = append(args, "--relative")
+	}
+	if g.Base != "" {
+		args = append(args, "--merge-base", g.Base)
+	}
+	out, err := run(ctx, g.Dir, "git", append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	files, err := Parse(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := g.untracked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, untracked...), nil
+}
+
+// untracked returns the files git doesn't track and doesn't ignore, each
+// changed throughout.
+func (g Git) untracked(ctx context.Context) ([]File, error) {
+	args := []string{"ls-files", "--others", "--exclude-standard", "-z"}
+	root := g.Dir
+	if !g.Relative {
+		top, err := run(ctx, g.Dir, "git", "rev-parse", "--show-toplevel")
+		if err != nil {
+			return nil, err
+		}
+		root = string(bytes.TrimSpace(top))
+		args = append(args, "--full-name", ":/")
+	}
+	out, err := run(ctx, g.Dir, "git", args...)
+	if err != nil {
+		return nil, err
+	}
+	return added(root, splitNUL(out))
+}
+
+// Staged returns the paths of the files added, copied or modified in the git
+// index. A renamed file is reported under its new path.
+func (g Git) Staged(ctx context.Context) ([]string, error) {
+	args := []string{"diff", "--cached", "--name-only", "-z", "--no-renames", "--diff-filter=ACM"}
+	if g.Relative {
+		args = append(args, "--relative")
+	}
+	out, err := run(ctx, g.Dir, "git", append(args, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// splitNUL splits NUL-terminated paths.
+func splitNUL(out []byte) []string {
+	var paths []string
+	for _, p := range bytes.Split(out, []byte{0}) {
+		if len(p) > 0 {
+			paths = append(paths, string(p))
+		}
+	}
+	return paths
+}
+
+// added returns paths, relative to dir, as files changed on every line.
+// Empty files have no lines to report and are left out.
+func added(dir string, paths []string) ([]File, error) {
+	var files []File
+	for _, p := range paths {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		if err != nil {
+			return nil, err
+		}
+		n := bytes.Count(data, []byte{'\n'})
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			n++
+		}
+		if n > 0 {
+			files = append(files, File{Path: filepath.ToSlash(p), Lines: []Range{{1, n}}})
+		}
+	}
+	return files, nil
+}
+
+// Indexed returns the content of the file at path in the git index, which
+// may differ from the working tree. path is as Staged reports it.
+func (g Git) Indexed(ctx context.Context, path string) ([]byte, error) {
+	spec := ":" + path
+	if g.Relative {
+		spec = ":./" + path
+	}
+	return run(ctx, g.Dir, "git", "show", spec)
+}
+
+// run executes a VCS command and returns its standard output.
+func run(ctx context.Context, dir, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running %s %s: %w: %s", name, args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
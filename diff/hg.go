@@ -0,0 +1,40 @@
+package diff
+
+import (
+	"bytes"
+	"context"
+)
+
+// Mercurial reports the changes in a Mercurial working directory since it
+// diverged from Rev, including uncommitted changes and unknown files.
+type Mercurial struct {
+	Dir string // working directory; "" means the current directory
+	Rev string // any revision hg diff -r accepts, such as default or .^
+}
+
+// Changes diffs the working directory against the common ancestor of Rev
+// and the working directory's parent. Unknown files that .hgignore doesn't
+// exclude are reported as changed throughout.
+func (m Mercurial) Changes(ctx context.Context) ([]File, error) {
+	out, err := run(ctx, m.Dir, "hg", "diff", "--git", "--unified", "0", "--rev", "ancestor("+m.Rev+", .)")
+	if err != nil {
+		return nil, err
+	}
+	files, err := Parse(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	root, err := run(ctx, m.Dir, "hg", "root")
+	if err != nil {
+		return nil, err
+	}
+	out, err = run(ctx, m.Dir, "hg", "status", "--unknown", "--no-status", "--print0", "--config", "ui.relative-paths=no")
+	if err != nil {
+		return nil, err
+	}
+	unknown, err := added(string(bytes.TrimSpace(root)), splitNUL(out))
+	if err != nil {
+		return nil, err
+	}
+	return append(files, unknown...), nil
+}
@@ -0,0 +1,24 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// PatchFile reports the changes described by a unified diff on disk, for CI
+// systems that provide a patch rather than a checkout with history.
+type PatchFile struct {
+	Path string
+}
+
+// Changes returns the files the patch adds or modifies, with the line
+// ranges of their new side. Deleted files are not reported.
+func (p PatchFile) Changes(ctx context.Context) ([]File, error) {
+	f, err := os.Open(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("opening patch: %w", err)
+	}
+	defer f.Close()
+	return Parse(f)
+}
@@ -0,0 +1,151 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+// Unified returns a unified diff from old to new, both the content of the
+// file at path, in the format Parse reads. It returns "" if they are equal.
+//
+// Lines are matched by a longest-common-subsequence search over the region
+// between the common prefix and suffix, which suits the local edits of
+// codemods and fixes; it is not meant for whole-file rewrites.
+func Unified(path string, old, new []byte) string {
+	a, b := splitLines(string(old)), splitLines(string(new))
+	ops := diffLines(a, b)
+	if len(ops) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n+++ b/%s\n", path, path)
+	for i := 0; i < len(ops); {
+		// Extend the hunk while the gap to the next change is small enough
+		// for their context to touch.
+		j := i + 1
+		for j < len(ops) && ops[j].aStart-ops[j-1].aEnd <= 2*contextLines {
+			j++
+		}
+		first, last := ops[i], ops[j-1]
+		aStart := max(first.aStart-contextLines, 0)
+		aEnd := min(last.aEnd+contextLines, len(a))
+		bStart := first.bStart - (first.aStart - aStart)
+		bEnd := last.bEnd + (aEnd - last.aEnd)
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aEnd), hunkRange(bStart, bEnd))
+
+		pos := aStart
+		for _, op := range ops[i:j] {
+			for ; pos < op.aStart; pos++ {
+				writeLine(&sb, ' ', a[pos])
+			}
+			for _, l := range a[op.aStart:op.aEnd] {
+				writeLine(&sb, '-', l)
+			}
+			for _, l := range b[op.bStart:op.bEnd] {
+				writeLine(&sb, '+', l)
+			}
+			pos = op.aEnd
+		}
+		for ; pos < aEnd; pos++ {
+			writeLine(&sb, ' ', a[pos])
+		}
+		i = j
+	}
+	return sb.String()
+}
+
+// op replaces lines a[aStart:aEnd] with b[bStart:bEnd].
+type op struct {
+	aStart, aEnd, bStart, bEnd int
+}
+
+// diffLines returns the changes that turn a into b, in order.
+func diffLines(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of ma[i:]
+	// and mb[j:].
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []op
+	var cur *op
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		if i < len(ma) && j < len(mb) && ma[i] == mb[j] {
+			cur = nil
+			i++
+			j++
+			continue
+		}
+		if cur == nil {
+			ops = append(ops, op{prefix + i, prefix + i, prefix + j, prefix + j})
+			cur = &ops[len(ops)-1]
+		}
+		if j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]) {
+			i++
+			cur.aEnd++
+		} else {
+			j++
+			cur.bEnd++
+		}
+	}
+	return ops
+}
+
+// splitLines splits s into lines, keeping their terminators so a missing
+// final newline is preserved.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func writeLine(sb *strings.Builder, prefix byte, line string) {
+	sb.WriteByte(prefix)
+	sb.WriteString(line)
+	if !strings.HasSuffix(line, "\n") {
+		sb.WriteString("\n\\ No newline at end of file\n")
+	}
+}
+
+// hunkRange formats the 0-based half-open range [start, end) as a hunk
+// header range.
+func hunkRange(start, end int) string {
+	switch n := end - start; n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, n)
+	}
+}
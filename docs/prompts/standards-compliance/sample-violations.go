@@ -1,3 +1,5 @@
+//go:build ignore
+
 package services
 
 import (
@@ -14,7 +16,7 @@ type OrderService struct {
 	calculator PriceCalculator
 }
 
-// ❌ VIOLATION 2: This should call a primary constructor
+// ❌ VIOLATION 2: This should call a primary constructor [primaryctor]
 func NewOrderServiceForProduction(db *sql.DB, logger Logger) *OrderService {
 	repo := persistence.NewOrderRepository(db)
 	calculator := pricing.NewCalculator()
@@ -48,13 +50,13 @@ func NewUserServiceForProduction(db *sql.DB, logger Logger) *UserService {
 	validator := validation.NewUserValidator()
 	repo := persistence.NewUserRepository(db)
 
-	// ❌ VIOLATION: Checking user count is business logic
+	// ❌ VIOLATION: Checking user count is business logic [factorylogic]
 	count, _ := repo.Count(context.Background())
 	if count > 1000 {
 		logger.Warn("High user count detected", "count", count)
 	}
 
-	// ❌ VIOLATION: Also missing coverage exclusion marker
+	// ❌ VIOLATION: Also missing coverage exclusion marker [coverageignore]
 	return NewUserService(repo, logger, validator)
 }
 
@@ -77,7 +79,7 @@ func NewPaymentService(processor PaymentProcessor, repo PaymentRepository, logge
 func NewPaymentServiceForProduction(db *sql.DB, logger Logger, cfg Config) *PaymentService {
 	repo := persistence.NewPaymentRepository(db)
 
-	// ❌ VIOLATION: Choosing processor based on business requirement
+	// ❌ VIOLATION: Choosing processor based on business requirement [factorydecisions]
 	var processor PaymentProcessor
 	if cfg.StrictMode {
 		processor = processors.NewStrictProcessor(cfg.Timeout)
@@ -107,7 +109,7 @@ func NewNotificationService(sender EmailSender, logger Logger, timeout int) *Not
 func NewNotificationServiceForProduction(logger Logger, cfg Config) *NotificationService {
 	sender := email.NewSMTPSender(cfg.SMTPHost)
 
-	// ❌ VIOLATION: Calculating timeout based on environment
+	// ❌ VIOLATION: Calculating timeout based on environment [factorydecisions]
 	timeout := 30
 	if cfg.Environment == "production" {
 		timeout = timeout * 2 // Calculation is business logic
@@ -130,7 +132,8 @@ func TestUserService_CreateUser(t *testing.T) {
 	assert.NotNil(t, user)
 }
 
-// ❌ VIOLATION: Missing godoc comment on exported function
+// ❌ VIOLATION: Missing godoc comment on exported function [godoc]
+
 func ProcessPayment(ctx context.Context, amount float64) error {
 	// Implementation
 	return nil
@@ -149,7 +152,7 @@ func NewReportService(generators []ReportGenerator, logger Logger) *ReportServic
 func NewReportServiceForProduction(logger Logger, cfg Config) *ReportService {
 	var generators []ReportGenerator
 
-	// ❌ VIOLATION: Loop with conditional logic in factory
+	// ❌ VIOLATION: Loop with conditional logic in factory [factorydecisions]
 	for _, reportType := range cfg.EnabledReports {
 		switch reportType {
 		case "sales":
@@ -177,7 +180,7 @@ func NewWeatherServiceForProduction(logger Logger) *WeatherService {
 	client := api.NewClient("https://api.weather.com")
 	cache := cache.NewRedisCache()
 
-	// ❌ VIOLATION: Making API call to check service health
+	// ❌ VIOLATION: Making API call to check service health [factorydecisions]
 	if !client.HealthCheck() {
 		logger.Error("Weather API unavailable")
 	}
@@ -198,7 +201,7 @@ func NewDataService(transformer DataTransformer, repo DataRepository) *DataServi
 func NewDataServiceForProduction(db *sql.DB, format string) *DataService {
 	repo := persistence.NewDataRepository(db)
 
-	// ❌ VIOLATION: Transforming data based on format
+	// ❌ VIOLATION: Transforming data based on format [factorylogic]
 	transformer := transformers.NewTransformer()
 	if format == "json" {
 		transformer.SetFormat("application/json")
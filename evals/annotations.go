@@ -0,0 +1,112 @@
+// Package evals scores standards checkers, the static analyzers or the AI
+// review, against an annotated sample corpus.
+//
+// The corpus marks code with comments: ❌ for a violation and ✅ for code
+// that complies. A violation annotation ending in the rules it exemplifies
+// in brackets is an expected finding:
+//
+//	// ❌ VIOLATION 1: Business logic in production factory [factorylogic]
+//
+// It expects a finding of each rule in the top-level declaration the
+// comment is in, or else the one it precedes. Annotations without rules
+// describe the code for readers and are not scored. Findings are scored per
+// rule: recall is the share of expectations matched by a finding, and
+// precision the share of scored findings that match an expectation. Each
+// expectation is matched by one finding at most; further findings in a
+// declaration expecting the rule, and findings in code annotated as
+// correct, are scored as misses. The corpus need not annotate every
+// violation, so other findings are counted but unscored.
+package evals
+
+import (
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+const (
+	violationMark = "❌"
+	correctMark   = "✅"
+)
+
+// ruleTags matches the bracketed rule list ending an annotation.
+var ruleTags = regexp.MustCompile(`\[([a-z0-9]+(?:\s*,\s*[a-z0-9]+)*)\]\s*$`)
+
+// Annotation is a ❌ or ✅ comment in the corpus and the code it covers.
+type Annotation struct {
+	File       string   `json:"file"` // as in report.Finding.File
+	Line       int      `json:"line"` // of the comment
+	Start, End int      `json:"-"`    // lines of the declaration it covers
+	Violation  bool     `json:"violation"`
+	Rules      []string `json:"rules,omitempty"` // expected rules, for violations
+	Text       string   `json:"text"`
+}
+
+// Covers reports whether line lies in the declaration a covers.
+func (a Annotation) Covers(line int) bool {
+	return a.Start <= line && line <= a.End
+}
+
+// Parse returns the annotations in f, whose path as reported in findings is
+// rel.
+func Parse(fset *token.FileSet, f *ast.File, rel string) []Annotation {
+	var as []Annotation
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			text := strings.TrimPrefix(c.Text, "//")
+			if strings.HasPrefix(c.Text, "/*") {
+				text = strings.TrimSuffix(strings.TrimPrefix(c.Text, "/*"), "*/")
+			}
+			text = strings.TrimSpace(text)
+			var violation bool
+			switch {
+			case strings.HasPrefix(text, violationMark):
+				violation = true
+			case strings.HasPrefix(text, correctMark):
+			default:
+				continue
+			}
+			a := Annotation{File: rel, Line: fset.Position(c.Pos()).Line, Violation: violation, Text: text}
+			decl := coveredDecl(f, c.Pos())
+			if decl == nil {
+				continue
+			}
+			a.Start = fset.Position(decl.Pos()).Line
+			if doc := declDoc(decl); doc != nil {
+				a.Start = fset.Position(doc.Pos()).Line
+			}
+			a.End = fset.Position(decl.End()).Line
+			if m := ruleTags.FindStringSubmatch(text); m != nil && violation {
+				for _, r := range strings.Split(m[1], ",") {
+					a.Rules = append(a.Rules, strings.TrimSpace(r))
+				}
+				a.Text = strings.TrimSpace(strings.TrimSuffix(text, m[0]))
+			}
+			as = append(as, a)
+		}
+	}
+	return as
+}
+
+// coveredDecl returns the top-level declaration containing pos, or else the
+// first one after it.
+func coveredDecl(f *ast.File, pos token.Pos) ast.Decl {
+	for _, d := range f.Decls {
+		if d.End() < pos {
+			continue
+		}
+		return d
+	}
+	return nil
+}
+
+func declDoc(d ast.Decl) *ast.CommentGroup {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package evals
+
+import (
+	"bytes"
+	"flag"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/analyzers"
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden file with the scores")
+
+// golden holds the analyzers' scores on the sample corpus, relative to the
+// repository root, as stdcheck eval -golden compares them.
+const golden = "docs/prompts/standards-compliance/eval.golden"
+
+// corpus is the annotated sample corpus, relative to the repository root.
+var corpus = []string{
+	"docs/prompts/standards-compliance/sample-correct.go",
+	"docs/prompts/standards-compliance/sample-violations.go",
+}
+
+func TestCorpus_Analyze_Golden(t *testing.T) {
+	c, err := Load("..", corpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	selected, err := analyzers.Select(c.Rules())
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err := c.Analyze(selected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	if err := Evaluate(c.Annotations, findings, c.Rules()).WriteText(&got, false); err != nil {
+		t.Fatal(err)
+	}
+
+	path := "../" + golden
+	if *update {
+		if err := os.WriteFile(path, got.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("scores differ from %s; rerun with -update if the change is intended\ngot:\n%s\nwant:\n%s", golden, got.Bytes(), want)
+	}
+}
+
+func TestParse_Annotations(t *testing.T) {
+	const src = `package p
+
+// ❌ VIOLATION 1: Logic in a factory [factorylogic, factorydecisions]
+func NewAForProduction() {
+}
+
+// ✅ CORRECT: Delegates to the primary constructor
+func NewBForProduction() {
+	// ❌ A note without rules
+}
+
+// An ordinary comment.
+func c() {}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Annotation{
+		{File: "p/p.go", Line: 3, Start: 3, End: 5, Violation: true, Rules: []string{"factorylogic", "factorydecisions"}, Text: "❌ VIOLATION 1: Logic in a factory"},
+		{File: "p/p.go", Line: 7, Start: 7, End: 10, Text: "✅ CORRECT: Delegates to the primary constructor"},
+		{File: "p/p.go", Line: 9, Start: 7, End: 10, Violation: true, Text: "❌ A note without rules"},
+	}
+	if got := Parse(fset, f, "p/p.go"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestEvaluate_Scores(t *testing.T) {
+	annotations := []Annotation{
+		{File: "a.go", Line: 1, Start: 1, End: 10, Violation: true, Rules: []string{"godoc", "factorylogic"}},
+		{File: "a.go", Line: 11, Start: 11, End: 20},
+		{File: "a.go", Line: 21, Start: 21, End: 30, Violation: true, Rules: []string{"godoc"}},
+	}
+	tests := []struct {
+		name     string
+		findings []report.Finding
+		want     []Score
+		misses   int
+	}{
+		{
+			name: "none",
+			want: []Score{
+				{Rule: "factorylogic", Expected: 1},
+				{Rule: "godoc", Expected: 2},
+			},
+			misses: 3,
+		},
+		{
+			name: "matched, unmatched, false and unscored",
+			findings: []report.Finding{
+				{File: "a.go", Line: 5, Rule: "godoc"},
+				{File: "a.go", Line: 6, Rule: "godoc"},
+				{File: "a.go", Line: 15, Rule: "godoc"},
+				{File: "a.go", Line: 40, Rule: "factorylogic"},
+				{File: "b.go", Line: 5, Rule: "factorylogic"},
+				{File: "a.go", Line: 5, Rule: "nildeps"},
+			},
+			want: []Score{
+				{Rule: "factorylogic", Expected: 1, Unscored: 2},
+				{Rule: "godoc", Expected: 2, Detected: 1, Matched: 1, Unmatched: 1, False: 1},
+			},
+			misses: 2,
+		},
+		{
+			name: "one finding per expectation",
+			findings: []report.Finding{
+				{File: "a.go", Line: 5, Rule: "godoc"},
+				{File: "a.go", Line: 22, Rule: "godoc"},
+				{File: "a.go", Line: 25, Rule: "godoc"},
+				{File: "a.go", Line: 9, Rule: "factorylogic"},
+			},
+			want: []Score{
+				{Rule: "factorylogic", Expected: 1, Detected: 1, Matched: 1},
+				{Rule: "godoc", Expected: 2, Detected: 2, Matched: 2, Unmatched: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Evaluate(annotations, tt.findings, []string{"godoc", "factorylogic"})
+			if !reflect.DeepEqual(res.Scores, tt.want) {
+				t.Errorf("Scores = %+v, want %+v", res.Scores, tt.want)
+			}
+			if len(res.Misses) != tt.misses {
+				t.Errorf("got %d misses, want %d", len(res.Misses), tt.misses)
+			}
+		})
+	}
+}
+
+func TestScore_Precision(t *testing.T) {
+	tests := []struct {
+		score             Score
+		precision, recall float64
+	}{
+		{Score{}, 1, 1},
+		{Score{Expected: 4, Detected: 3, Matched: 3, False: 1}, 0.75, 0.75},
+		{Score{Expected: 2, Unscored: 5}, 1, 0},
+		{Score{Expected: 2, Detected: 2, Matched: 2, Unmatched: 2}, 0.5, 1},
+	}
+	for _, tt := range tests {
+		if p, r := tt.score.Precision(), tt.score.Recall(); p != tt.precision || r != tt.recall {
+			t.Errorf("%+v: precision, recall = %v, %v; want %v, %v", tt.score, p, r, tt.precision, tt.recall)
+		}
+	}
+}
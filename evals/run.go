@@ -0,0 +1,165 @@
+package evals
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Corpus is a set of annotated sample files.
+type Corpus struct {
+	Root  string   // directory findings' paths are relative to
+	Files []string // slash-separated, relative to Root
+
+	fset        *token.FileSet
+	syntax      []*ast.File
+	Annotations []Annotation
+}
+
+// Load parses the files of the corpus and their annotations.
+func Load(root string, files []string) (*Corpus, error) {
+	c := &Corpus{Root: root, Files: files, fset: token.NewFileSet()}
+	for _, rel := range files {
+		f, err := parser.ParseFile(c.fset, filepath.Join(root, filepath.FromSlash(rel)), nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("parsing corpus: %w", err)
+		}
+		c.syntax = append(c.syntax, f)
+		c.Annotations = append(c.Annotations, Parse(c.fset, f, rel)...)
+	}
+	return c, nil
+}
+
+// Analyze runs the analyzers over each corpus file as a package of its own.
+// Sample files need not compile: they are type-checked as far as their
+// imports and declarations allow, and analyzers see the partial type
+// information. Analyzers that need facts from dependencies see none.
+func (c *Corpus) Analyze(as []*analysis.Analyzer) ([]report.Finding, error) {
+	imp := importer.ForCompiler(c.fset, "source", nil)
+	var findings []report.Finding
+	for i, f := range c.syntax {
+		info := &types.Info{
+			Types:        make(map[ast.Expr]types.TypeAndValue),
+			Instances:    make(map[*ast.Ident]types.Instance),
+			Defs:         make(map[*ast.Ident]types.Object),
+			Uses:         make(map[*ast.Ident]types.Object),
+			Implicits:    make(map[ast.Node]types.Object),
+			Selections:   make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:       make(map[ast.Node]*types.Scope),
+			FileVersions: make(map[*ast.File]string),
+		}
+		conf := types.Config{Importer: imp, Error: func(error) {}}
+		pkg, _ := conf.Check(f.Name.Name, c.fset, []*ast.File{f}, info)
+
+		results := make(map[*analysis.Analyzer]any)
+		for _, a := range as {
+			diags, err := c.run(a, pkg, f, info, results)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", a.Name, c.Files[i], err)
+			}
+			for _, d := range diags {
+				pos := c.fset.Position(d.Pos)
+				findings = append(findings, report.Finding{
+					Rule:    a.Name,
+					File:    c.Files[i],
+					Line:    pos.Line,
+					Column:  pos.Column,
+					Message: d.Message,
+				})
+			}
+		}
+	}
+	report.Sort(findings)
+	return findings, nil
+}
+
+// run runs a, after the analyzers it requires, over the single file f and
+// returns its diagnostics. A panic in an analyzer, such as on type
+// information the sample's missing imports left out, is an error.
+func (c *Corpus) run(a *analysis.Analyzer, pkg *types.Package, f *ast.File, info *types.Info, results map[*analysis.Analyzer]any) (diags []analysis.Diagnostic, err error) {
+	resultOf := make(map[*analysis.Analyzer]any)
+	for _, req := range a.Requires {
+		if _, ok := results[req]; !ok {
+			if _, err := c.run(req, pkg, f, info, results); err != nil {
+				return nil, err
+			}
+		}
+		resultOf[req] = results[req]
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("analyzer panicked: %v", r)
+		}
+	}()
+	pass := &analysis.Pass{
+		Analyzer:          a,
+		Fset:              c.fset,
+		Files:             []*ast.File{f},
+		Pkg:               pkg,
+		TypesInfo:         info,
+		TypesSizes:        types.SizesFor("gc", runtime.GOARCH),
+		Report:            func(d analysis.Diagnostic) { diags = append(diags, d) },
+		ResultOf:          resultOf,
+		ReadFile:          os.ReadFile,
+		ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+		ImportPackageFact: func(*types.Package, analysis.Fact) bool { return false },
+		ExportObjectFact:  func(types.Object, analysis.Fact) {},
+		ExportPackageFact: func(analysis.Fact) {},
+		AllPackageFacts:   func() []analysis.PackageFact { return nil },
+		AllObjectFacts:    func() []analysis.ObjectFact { return nil },
+	}
+	res, err := a.Run(pass)
+	if err != nil {
+		return nil, err
+	}
+	results[a] = res
+	sort.Slice(diags, func(i, j int) bool { return diags[i].Pos < diags[j].Pos })
+	return diags, nil
+}
+
+// Resolve rewrites the paths of findings from other sources, such as the AI
+// review, to the corpus paths they name. A path that is not a corpus path
+// names the corpus file it is a trailing path of, as sample-violations.go
+// names docs/prompts/standards-compliance/sample-violations.go.
+func (c *Corpus) Resolve(findings []report.Finding) []report.Finding {
+	out := make([]report.Finding, len(findings))
+	for i, f := range findings {
+		f.File = filepath.ToSlash(f.File)
+		if !slices.Contains(c.Files, f.File) {
+			for _, rel := range c.Files {
+				if strings.HasSuffix(rel, "/"+strings.TrimPrefix(f.File, "./")) {
+					f.File = rel
+					break
+				}
+			}
+		}
+		out[i] = f
+	}
+	return out
+}
+
+// Rules returns the rules the corpus expects findings of, sorted.
+func (c *Corpus) Rules() []string {
+	var rules []string
+	for _, a := range c.Annotations {
+		for _, r := range a.Rules {
+			if !slices.Contains(rules, r) {
+				rules = append(rules, r)
+			}
+		}
+	}
+	sort.Strings(rules)
+	return rules
+}
@@ -0,0 +1,194 @@
+package evals
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+// Score is how well the findings of one rule agree with the corpus.
+type Score struct {
+	Rule      string `json:"rule"`
+	Expected  int    `json:"expected"`  // expectations of the rule
+	Detected  int    `json:"detected"`  // expectations matched by a finding
+	Matched   int    `json:"matched"`   // findings that match an expectation
+	Unmatched int    `json:"unmatched"` // further findings where the rule is expected
+	False     int    `json:"false"`     // findings in code annotated as correct
+	Unscored  int    `json:"unscored"`  // findings the corpus makes no claim about
+}
+
+// Precision returns the share of scored findings that match an expectation,
+// or 1 if there are none.
+func (s Score) Precision() float64 {
+	scored := s.Matched + s.Unmatched + s.False
+	if scored == 0 {
+		return 1
+	}
+	return float64(s.Matched) / float64(scored)
+}
+
+// Recall returns the share of expectations matched by a finding, or 1 if
+// there are none.
+func (s Score) Recall() float64 {
+	if s.Expected == 0 {
+		return 1
+	}
+	return float64(s.Detected) / float64(s.Expected)
+}
+
+// Miss is an expected finding of Rule that no finding matched.
+type Miss struct {
+	Rule       string
+	Annotation Annotation
+}
+
+// FalsePositive is a finding in code annotated as correct.
+type FalsePositive struct {
+	Finding    report.Finding
+	Annotation Annotation
+}
+
+// Result is the outcome of scoring findings against a corpus.
+type Result struct {
+	Scores    []Score // by rule, sorted
+	Misses    []Miss
+	Unmatched []report.Finding
+	False     []FalsePositive
+	Unscored  []report.Finding
+}
+
+// Evaluate scores findings against the annotations of a corpus, for the
+// given rules; findings and expectations of other rules are ignored.
+//
+// A finding matches an expectation of its rule in the same file whose
+// declaration contains its line, and each expectation is matched by at most
+// one finding. A further finding in a declaration expecting its rule is
+// unmatched, and one in code annotated as correct is a false positive; both
+// count against precision. The corpus need not annotate every violation, so
+// any other finding is unscored.
+func Evaluate(annotations []Annotation, findings []report.Finding, rules []string) Result {
+	scored := make(map[string]*Score, len(rules))
+	for _, r := range rules {
+		scored[r] = &Score{Rule: r}
+	}
+
+	type expectation struct {
+		rule string
+		a    Annotation
+	}
+	var exps []expectation
+	for _, a := range annotations {
+		for _, r := range a.Rules {
+			if s := scored[r]; s != nil {
+				s.Expected++
+				exps = append(exps, expectation{r, a})
+			}
+		}
+	}
+
+	var res Result
+	detected := make([]bool, len(exps))
+findings:
+	for _, f := range findings {
+		s := scored[f.Rule]
+		if s == nil {
+			continue
+		}
+		expected := false
+		for i, e := range exps {
+			if e.rule != f.Rule || e.a.File != f.File || !e.a.Covers(f.Line) {
+				continue
+			}
+			if !detected[i] {
+				detected[i] = true
+				s.Matched++
+				continue findings
+			}
+			expected = true
+		}
+		if expected {
+			s.Unmatched++
+			res.Unmatched = append(res.Unmatched, f)
+			continue
+		}
+		for _, a := range annotations {
+			if !a.Violation && a.File == f.File && a.Covers(f.Line) {
+				s.False++
+				res.False = append(res.False, FalsePositive{Finding: f, Annotation: a})
+				continue findings
+			}
+		}
+		s.Unscored++
+		res.Unscored = append(res.Unscored, f)
+	}
+	for i, e := range exps {
+		if detected[i] {
+			scored[e.rule].Detected++
+		} else {
+			res.Misses = append(res.Misses, Miss{Rule: e.rule, Annotation: e.a})
+		}
+	}
+
+	for _, s := range scored {
+		res.Scores = append(res.Scores, *s)
+	}
+	sort.Slice(res.Scores, func(i, j int) bool { return res.Scores[i].Rule < res.Scores[j].Rule })
+	return res
+}
+
+// Total returns the scores of every rule combined.
+func (r Result) Total() Score {
+	t := Score{Rule: "total"}
+	for _, s := range r.Scores {
+		t.Expected += s.Expected
+		t.Detected += s.Detected
+		t.Matched += s.Matched
+		t.Unmatched += s.Unmatched
+		t.False += s.False
+		t.Unscored += s.Unscored
+	}
+	return t
+}
+
+// WriteText writes the per-rule scores as a table followed by the missed
+// expectations, unmatched findings and false positives, and if verbose the
+// unscored findings.
+// The output depends only on the result, so it can serve as a golden file.
+func (r Result) WriteText(w io.Writer, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "rule\texpected\tdetected\tmatched\tunmatched\tfalse\tunscored\tprecision\trecall")
+	for _, s := range append(r.Scores, r.Total()) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%.2f\t%.2f\n", s.Rule, s.Expected, s.Detected, s.Matched, s.Unmatched, s.False, s.Unscored, s.Precision(), s.Recall())
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.Misses) > 0 {
+		fmt.Fprintln(w, "\nmissed:")
+		for _, m := range r.Misses {
+			fmt.Fprintf(w, "  %s:%d: %s (%s)\n", m.Annotation.File, m.Annotation.Line, m.Annotation.Text, m.Rule)
+		}
+	}
+	if len(r.Unmatched) > 0 {
+		fmt.Fprintln(w, "\nunmatched:")
+		for _, f := range r.Unmatched {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+	if len(r.False) > 0 {
+		fmt.Fprintln(w, "\nfalse positives:")
+		for _, fp := range r.False {
+			fmt.Fprintf(w, "  %s\n    in code annotated at line %d: %s\n", fp.Finding, fp.Annotation.Line, fp.Annotation.Text)
+		}
+	}
+	if verbose && len(r.Unscored) > 0 {
+		fmt.Fprintln(w, "\nunscored:")
+		for _, f := range r.Unscored {
+			fmt.Fprintf(w, "  %s\n", f)
+		}
+	}
+	return nil
+}
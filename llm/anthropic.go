@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// anthropicVersion is the version of the messages API the client speaks.
+const anthropicVersion = "2023-06-01"
+
+// anthropicClient calls Anthropic's messages API. The API has no JSON mode,
+// so CompleteJSON asks for JSON in the system prompt.
+type anthropicClient struct {
+	cfg Config
+	hc  *http.Client
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      anthropicUsage `json:"usage"`
+}
+
+// anthropicEvent is an event of a streamed response.
+type anthropicEvent struct {
+	Type    string            `json:"type"`
+	Message anthropicResponse `json:"message"` // message_start
+	Delta   struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`        // content_block_delta
+		StopReason string `json:"stop_reason"` // message_delta
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"` // message_delta
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (c *anthropicClient) body(req Request) anthropicRequest {
+	body := anthropicRequest{Model: c.cfg.Model, System: req.System, MaxTokens: maxTokens(req), Temperature: req.Temperature}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, anthropicMessage{Role: string(m.Role), Content: m.Content})
+	}
+	return body
+}
+
+func (c *anthropicClient) header() http.Header {
+	return http.Header{
+		"X-Api-Key":         {c.cfg.APIKey},
+		"Anthropic-Version": {anthropicVersion},
+	}
+}
+
+func (c *anthropicClient) Complete(ctx context.Context, req Request) (Response, error) {
+	var out anthropicResponse
+	if err := postJSON(ctx, c.hc, c.cfg.Provider, c.cfg.BaseURL+"/v1/messages", c.header(), c.body(req), &out); err != nil {
+		return Response{}, err
+	}
+	var text strings.Builder
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	return Response{
+		Text:       text.String(),
+		Model:      out.Model,
+		StopReason: out.StopReason,
+		Usage:      Usage{InputTokens: out.Usage.InputTokens, OutputTokens: out.Usage.OutputTokens},
+	}, nil
+}
+
+func (c *anthropicClient) CompleteJSON(ctx context.Context, req Request, out any) (Response, error) {
+	resp, err := c.Complete(ctx, withJSONInstruction(req))
+	if err != nil {
+		return resp, err
+	}
+	return resp, DecodeJSON(resp.Text, out)
+}
+
+func (c *anthropicClient) StreamJSON(ctx context.Context, req Request, out any, emit func(string) error) (Response, error) {
+	resp, err := c.Stream(ctx, withJSONInstruction(req), emit)
+	if err != nil {
+		return resp, err
+	}
+	return resp, DecodeJSON(resp.Text, out)
+}
+
+func (c *anthropicClient) Stream(ctx context.Context, req Request, emit func(string) error) (Response, error) {
+	body := c.body(req)
+	body.Stream = true
+	httpResp, err := post(ctx, c.hc, c.cfg.Provider, c.cfg.BaseURL+"/v1/messages", c.header(), body)
+	if err != nil {
+		return Response{}, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp Response
+	var text strings.Builder
+	err = readEvents(httpResp.Body, func(_, data string) (bool, error) {
+		var ev anthropicEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return false, fmt.Errorf("anthropic: decoding stream: %w", err)
+		}
+		switch ev.Type {
+		case "message_start":
+			resp.Model = ev.Message.Model
+			resp.Usage.InputTokens = ev.Message.Usage.InputTokens
+		case "content_block_delta":
+			if ev.Delta.Type == "text_delta" && ev.Delta.Text != "" {
+				text.WriteString(ev.Delta.Text)
+				if err := emit(ev.Delta.Text); err != nil {
+					return false, err
+				}
+			}
+		case "message_delta":
+			resp.StopReason = ev.Delta.StopReason
+			resp.Usage.OutputTokens = ev.Usage.OutputTokens
+		case "message_stop":
+			return true, nil
+		case "error":
+			return false, fmt.Errorf("anthropic: %s: %s", ev.Error.Type, ev.Error.Message)
+		}
+		return false, nil
+	})
+	resp.Text = text.String()
+	return resp, err
+}
@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAnthropicClient_Complete(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, nil, `{
+		"model": "m1-20250101",
+		"content": [{"type": "text", "text": "Hello"}, {"type": "tool_use"}, {"type": "text", "text": ", world"}],
+		"stop_reason": "end_turn",
+		"usage": {"input_tokens": 12, "output_tokens": 3}
+	}`)
+	temp := 0.5
+	req := Request{
+		System:      "Be brief.",
+		Messages:    []Message{{Role: RoleUser, Content: "hi"}},
+		MaxTokens:   100,
+		Temperature: &temp,
+	}
+	resp, err := client(t, Anthropic, srv).Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Method != http.MethodPost || got.Path != "/v1/messages" {
+		t.Errorf("request = %s %s, want POST /v1/messages", got.Method, got.Path)
+	}
+	for k, want := range map[string]string{"X-Api-Key": "secret", "Anthropic-Version": anthropicVersion, "Content-Type": "application/json"} {
+		if v := got.Header.Get(k); v != want {
+			t.Errorf("header %s = %q, want %q", k, v, want)
+		}
+	}
+	wantBody := map[string]any{
+		"model":       "m1",
+		"system":      "Be brief.",
+		"messages":    []any{map[string]any{"role": "user", "content": "hi"}},
+		"max_tokens":  100.0,
+		"temperature": 0.5,
+	}
+	if !reflect.DeepEqual(got.Body, wantBody) {
+		t.Errorf("body = %v, want %v", got.Body, wantBody)
+	}
+
+	want := Response{Text: "Hello, world", Model: "m1-20250101", StopReason: "end_turn", Usage: Usage{InputTokens: 12, OutputTokens: 3}}
+	if resp != want {
+		t.Errorf("Complete() = %+v, want %+v", resp, want)
+	}
+}
+
+func TestAnthropicClient_CompleteJSON(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, nil, `{"content": [{"type": "text", "text": "`+"```json\\n{\\\"ok\\\": true}\\n```"+`"}]}`)
+	var out struct{ OK bool }
+	if _, err := client(t, Anthropic, srv).CompleteJSON(context.Background(), UserPrompt("hi"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.OK {
+		t.Error("CompleteJSON() didn't decode the fenced JSON")
+	}
+	if got.Body["system"] != jsonInstruction {
+		t.Errorf("system = %q, want %q", got.Body["system"], jsonInstruction)
+	}
+	if got.Body["max_tokens"] != float64(DefaultMaxTokens) {
+		t.Errorf("max_tokens = %v, want %d", got.Body["max_tokens"], DefaultMaxTokens)
+	}
+}
+
+const anthropicStream = `event: message_start
+data: {"type": "message_start", "message": {"model": "m1-20250101", "usage": {"input_tokens": 12}}}
+
+event: ping
+data: {"type": "ping"}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "delta": {"type": "text_delta", "text": "{\"ok\":"}}
+
+event: content_block_delta
+data: {"type": "content_block_delta", "delta": {"type": "text_delta", "text": " true}"}}
+
+event: message_delta
+data: {"type": "message_delta", "delta": {"stop_reason": "end_turn"}, "usage": {"output_tokens": 5}}
+
+event: message_stop
+data: {"type": "message_stop"}
+
+`
+
+func TestAnthropicClient_StreamJSON(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, http.Header{"Content-Type": {"text/event-stream"}}, anthropicStream)
+	var deltas []string
+	var out struct{ OK bool }
+	resp, err := client(t, Anthropic, srv).StreamJSON(context.Background(), UserPrompt("hi"), &out, collect(&deltas))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Body["stream"] != true {
+		t.Errorf("stream = %v, want true", got.Body["stream"])
+	}
+	if want := []string{`{"ok":`, " true}"}; !reflect.DeepEqual(deltas, want) {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+	want := Response{Text: `{"ok": true}`, Model: "m1-20250101", StopReason: "end_turn", Usage: Usage{InputTokens: 12, OutputTokens: 5}}
+	if resp != want {
+		t.Errorf("StreamJSON() = %+v, want %+v", resp, want)
+	}
+	if !out.OK {
+		t.Error("StreamJSON() didn't decode the streamed JSON")
+	}
+}
+
+func TestAnthropicClient_Stream_Errors(t *testing.T) {
+	t.Run("error event", func(t *testing.T) {
+		srv, _ := serve(t, http.StatusOK, nil, strings.Replace(anthropicStream,
+			"event: message_delta\n", "event: error\ndata: {\"type\": \"error\", \"error\": {\"type\": \"overloaded_error\", \"message\": \"Overloaded\"}}\n\nevent: message_delta\n", 1))
+		resp, err := client(t, Anthropic, srv).Stream(context.Background(), UserPrompt("hi"), func(string) error { return nil })
+		if err == nil || err.Error() != "anthropic: overloaded_error: Overloaded" {
+			t.Errorf("Stream() error = %v, want the error event", err)
+		}
+		if resp.Text != `{"ok": true}` {
+			t.Errorf("Stream() text = %q, want the text before the error", resp.Text)
+		}
+	})
+	t.Run("emit fails", func(t *testing.T) {
+		srv, _ := serve(t, http.StatusOK, nil, anthropicStream)
+		stop := errors.New("stop")
+		_, err := client(t, Anthropic, srv).Stream(context.Background(), UserPrompt("hi"), func(string) error { return stop })
+		if !errors.Is(err, stop) {
+			t.Errorf("Stream() error = %v, want %v", err, stop)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Elements finds the elements of one array in a JSON object that a
+// streamed response writes, as soon as each is complete, so a caller can
+// act on the first findings of a review before the model has written the
+// last. Pass its Feed method to Stream or StreamJSON as the emit function.
+//
+// The array is the value of the member Key of the outermost object. As
+// DecodeJSON does, Elements skips text before the object, such as a
+// Markdown code fence. It doesn't validate the JSON; decode the whole
+// response for that.
+type Elements struct {
+	Key string
+	// Emit is called with each element of the array, in order. An error
+	// from Emit is returned by Feed.
+	Emit func(json.RawMessage) error
+
+	depth    int  // nesting of the outermost object's members is 1
+	inString bool // inside a string at depth 1 or more
+	escaped  bool // after a backslash in a string
+	str      bytes.Buffer
+	key      string // the member whose value comes next, at depth 1
+	inArray  bool   // inside the array of Key
+	elem     bytes.Buffer
+}
+
+// Feed scans the next fragment of the response.
+func (e *Elements) Feed(delta string) error {
+	for i := 0; i < len(delta); i++ {
+		if err := e.scan(delta[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Elements) scan(c byte) error {
+	if e.depth == 0 {
+		if c == '{' {
+			e.depth = 1
+		}
+		return nil
+	}
+	inElem := e.inArray && e.depth >= 2
+	if inElem && (e.elem.Len() > 0 || !isSpace(c)) && !(e.depth == 2 && (c == ',' || c == ']')) {
+		e.elem.WriteByte(c)
+	}
+	if e.inString {
+		switch {
+		case e.escaped:
+			e.escaped = false
+		case c == '\\':
+			e.escaped = true
+		case c == '"':
+			e.inString = false
+			return nil
+		}
+		if e.depth == 1 {
+			e.str.WriteByte(c)
+		}
+		return nil
+	}
+	switch c {
+	case '"':
+		e.inString = true
+		if e.depth == 1 {
+			e.str.Reset()
+		}
+	case ':':
+		if e.depth == 1 {
+			e.key = e.str.String()
+		}
+	case ',':
+		if e.depth == 1 {
+			e.key = ""
+		} else if inElem && e.depth == 2 {
+			return e.flush()
+		}
+	case '{', '[':
+		if e.depth == 1 && c == '[' && e.key == e.Key {
+			e.inArray = true
+		}
+		e.depth++
+	case '}', ']':
+		e.depth--
+		switch {
+		case inElem && e.depth == 1:
+			// The array closed; a scalar element may precede it.
+			e.inArray = false
+			return e.flush()
+		case inElem && e.depth == 2:
+			return e.flush()
+		case e.depth == 0:
+			e.key = ""
+		}
+	}
+	return nil
+}
+
+// flush emits the element scanned, if any.
+func (e *Elements) flush() error {
+	if e.elem.Len() == 0 {
+		return nil
+	}
+	raw := json.RawMessage(bytes.TrimSpace(e.elem.Bytes()))
+	e.elem = bytes.Buffer{}
+	return e.Emit(raw)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// feed passes each chunk to a new Elements for key and returns the
+// elements it emitted.
+func feed(t *testing.T, key string, chunks []string) []string {
+	t.Helper()
+	var got []string
+	e := &Elements{Key: key, Emit: func(raw json.RawMessage) error {
+		got = append(got, string(raw))
+		return nil
+	}}
+	for _, c := range chunks {
+		if err := e.Feed(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return got
+}
+
+// bytewise splits s into chunks of one byte.
+func bytewise(s string) []string {
+	chunks := make([]string, len(s))
+	for i := range s {
+		chunks[i] = s[i : i+1]
+	}
+	return chunks
+}
+
+func TestElements_Feed(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			name:   "objects",
+			chunks: []string{`{"findings": [{"line": 1}, {"line": 2}]}`},
+			want:   []string{`{"line": 1}`, `{"line": 2}`},
+		},
+		{
+			name:   "split across chunks",
+			chunks: []string{`{"find`, `ings": [{"li`, `ne": 1}`, `, {"line"`, `: 2}]`, `}`},
+			want:   []string{`{"line": 1}`, `{"line": 2}`},
+		},
+		{
+			name:   "scalars",
+			chunks: []string{`{"findings": [1, "two" , true]}`},
+			want:   []string{`1`, `"two"`, `true`},
+		},
+		{
+			name:   "empty array",
+			chunks: []string{`{"findings": []}`},
+		},
+		{
+			name:   "brackets and escapes in strings",
+			chunks: []string{`{"findings": [{"message": "use x[0], not {x}", "rule": "a\"],b"}, {"message": "C:\\dir\\"}]}`},
+			want:   []string{`{"message": "use x[0], not {x}", "rule": "a\"],b"}`, `{"message": "C:\\dir\\"}`},
+		},
+		{
+			name:   "nested arrays",
+			chunks: []string{`{"findings": [[1, [2]], {"lines": [3, 4]}]}`},
+			want:   []string{`[1, [2]]`, `{"lines": [3, 4]}`},
+		},
+		{
+			name:   "other members first",
+			chunks: []string{`{"rules": [{"id": "x"}], "summary": "findings: [none]", "findings": [{"line": 1}]}`},
+			want:   []string{`{"line": 1}`},
+		},
+		{
+			name:   "key inside another member",
+			chunks: []string{`{"meta": {"findings": [{"line": 9}]}, "findings": [{"line": 1}]}`},
+			want:   []string{`{"line": 1}`},
+		},
+		{
+			name:   "code fence before the object",
+			chunks: []string{"```json\n", `{"findings": [{"line": 1}]}`, "\n```"},
+			want:   []string{`{"line": 1}`},
+		},
+		{
+			name:   "cut off mid-element",
+			chunks: []string{`{"findings": [{"line": 1}, {"line": 2, "message": "unfin`},
+			want:   []string{`{"line": 1}`},
+		},
+		{
+			name:   "cut off after an element",
+			chunks: []string{`{"findings": [{"line": 1},`},
+			want:   []string{`{"line": 1}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := feed(t, "findings", tt.chunks); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("elements = %q, want %q", got, tt.want)
+			}
+			// However the response is split, the same elements come out.
+			whole := strings.Join(tt.chunks, "")
+			if got := feed(t, "findings", bytewise(whole)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("elements fed byte by byte = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElements_Feed_EmitError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	e := &Elements{Key: "findings", Emit: func(json.RawMessage) error {
+		calls++
+		return stop
+	}}
+	if err := e.Feed(`{"findings": [{"line": 1}, {"line": 2}]}`); !errors.Is(err, stop) {
+		t.Errorf("Feed() = %v, want %v", err, stop)
+	}
+	if calls != 1 {
+		t.Errorf("Emit called %d times, want 1", calls)
+	}
+}
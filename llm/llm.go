@@ -0,0 +1,273 @@
+// Package llm calls large language model providers for the AI review.
+//
+// A Client completes a prompt with one provider's model. New returns the
+// Client for a Config: OpenAI's chat completions API (and compatible
+// servers), Anthropic's messages API, or a local Ollama server. Clients
+// speak the providers' HTTP APIs directly and share one request and
+// response shape, so the review pipeline doesn't depend on a provider.
+// WithPolicy wraps a Client with rate limiting, retries and a circuit
+// breaker.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Providers supported by New.
+const (
+	OpenAI    = "openai"
+	Anthropic = "anthropic"
+	Ollama    = "ollama"
+)
+
+// Providers lists the supported providers, sorted.
+var Providers = []string{Anthropic, Ollama, OpenAI}
+
+// DefaultMaxTokens bounds the length of a response when the Request doesn't.
+const DefaultMaxTokens = 4096
+
+// Role is the author of a Message.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+)
+
+// Message is one turn of a conversation.
+type Message struct {
+	Role    Role
+	Content string
+}
+
+// Request is a prompt to complete.
+type Request struct {
+	System    string    // instructions outside the conversation, if any
+	Messages  []Message // the conversation, ending with a user message
+	MaxTokens int       // DefaultMaxTokens if zero
+	// Temperature is the sampling temperature, or nil for the model's
+	// default. Some models accept only their default.
+	Temperature *float64
+}
+
+// UserPrompt returns a Request of a single user message.
+func UserPrompt(prompt string) Request {
+	return Request{Messages: []Message{{Role: RoleUser, Content: prompt}}}
+}
+
+// Response is a completed prompt.
+type Response struct {
+	Text  string
+	Model string // as reported by the provider, which may add a version
+	// StopReason is the provider's reason for ending the response, such as
+	// "stop" or "end_turn"; see Truncated.
+	StopReason string
+	Usage      Usage
+}
+
+// Truncated reports whether the response ended because it reached the token
+// limit rather than because the model finished.
+func (r Response) Truncated() bool {
+	switch r.StopReason {
+	case "length", "max_tokens":
+		return true
+	}
+	return false
+}
+
+// Usage counts the tokens a completion consumed, as the provider billed
+// them.
+type Usage struct {
+	InputTokens  int
+	OutputTokens int
+}
+
+// Client completes prompts with one model of one provider.
+type Client interface {
+	// Complete returns the model's response to req.
+	Complete(ctx context.Context, req Request) (Response, error)
+	// CompleteJSON asks for a response that is a single JSON value,
+	// using the provider's JSON mode where it has one, and decodes it into
+	// out. A response wrapped in a Markdown code fence is accepted.
+	CompleteJSON(ctx context.Context, req Request, out any) (Response, error)
+	// Stream is Complete, calling emit with each fragment of the response
+	// text as it arrives. An error from emit stops the stream and is
+	// returned. The Response holds the whole text.
+	Stream(ctx context.Context, req Request, emit func(delta string) error) (Response, error)
+	// StreamJSON is CompleteJSON, calling emit as Stream does. Elements
+	// finds the parts of the JSON value that are complete.
+	StreamJSON(ctx context.Context, req Request, out any, emit func(delta string) error) (Response, error)
+}
+
+// Config selects and configures a Client.
+type Config struct {
+	Provider string // one of Providers
+	Model    string
+	// BaseURL is the provider's API endpoint, such as an OpenAI-compatible
+	// gateway or a remote Ollama server. Each provider has a default.
+	BaseURL string
+	APIKey  string // required except for Ollama
+}
+
+// New returns the Client that cfg configures, sending its requests with hc,
+// or http.DefaultClient if hc is nil.
+func New(cfg Config, hc *http.Client) (Client, error) {
+	if cfg.Model == "" {
+		return nil, errors.New("no model configured")
+	}
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	switch cfg.Provider {
+	case OpenAI:
+		if cfg.APIKey == "" {
+			return nil, errors.New("openai: no API key")
+		}
+		return &openAIClient{cfg: withDefaultURL(cfg, "https://api.openai.com/v1"), hc: hc}, nil
+	case Anthropic:
+		if cfg.APIKey == "" {
+			return nil, errors.New("anthropic: no API key")
+		}
+		return &anthropicClient{cfg: withDefaultURL(cfg, "https://api.anthropic.com"), hc: hc}, nil
+	case Ollama:
+		return &ollamaClient{cfg: withDefaultURL(cfg, "http://localhost:11434"), hc: hc}, nil
+	case "":
+		return nil, errors.New("no provider configured")
+	}
+	return nil, fmt.Errorf("unknown provider %q (want %s)", cfg.Provider, strings.Join(Providers, ", "))
+}
+
+func withDefaultURL(cfg Config, url string) Config {
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = url
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	return cfg
+}
+
+// StatusError is a provider's response with a non-2xx HTTP status.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Status     string
+	Message    string // the response body, truncated
+	// RetryAfter is how long the provider asked to be left alone before a
+	// retry, if it said.
+	RetryAfter time.Duration
+}
+
+// Error returns the provider, the HTTP status and the provider's message.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Provider, e.Status, e.Message)
+}
+
+// post sends body as JSON to url and returns the response, which the caller
+// must close. A non-2xx response is a *StatusError.
+func post(ctx context.Context, hc *http.Client, provider, url string, header http.Header, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", provider, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &StatusError{
+			Provider:   provider,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Message:    string(bytes.TrimSpace(msg)),
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return resp, nil
+}
+
+// retryAfter parses a Retry-After header, which holds either seconds or an
+// HTTP date. It returns 0 if there is none.
+func retryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// postJSON sends body to url and decodes the JSON response into out.
+func postJSON(ctx context.Context, hc *http.Client, provider, url string, header http.Header, body, out any) error {
+	resp, err := post(ctx, hc, provider, url, header, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s: decoding response: %w", provider, err)
+	}
+	return nil
+}
+
+// jsonInstruction is added to the system prompt of JSON requests, for
+// providers whose JSON mode needs the prompt to ask for JSON, or that have
+// none.
+const jsonInstruction = "Respond with a single JSON value and nothing else."
+
+func withJSONInstruction(req Request) Request {
+	if req.System == "" {
+		req.System = jsonInstruction
+	} else {
+		req.System += "\n\n" + jsonInstruction
+	}
+	return req
+}
+
+// DecodeJSON decodes the JSON value in a model's response text into out. A
+// value in a Markdown code fence, or preceded or followed by prose, is
+// found.
+func DecodeJSON(text string, out any) error {
+	s := strings.TrimSpace(text)
+	if strings.HasPrefix(s, "```") {
+		if _, rest, ok := strings.Cut(s, "\n"); ok {
+			s = strings.TrimSuffix(strings.TrimSpace(rest), "```")
+		}
+	}
+	if i := strings.IndexAny(s, "{["); i > 0 {
+		s = s[i:]
+	}
+	dec := json.NewDecoder(strings.NewReader(s))
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("response is not the JSON asked for: %w", err)
+	}
+	return nil
+}
+
+func maxTokens(req Request) int {
+	if req.MaxTokens > 0 {
+		return req.MaxTokens
+	}
+	return DefaultMaxTokens
+}
@@ -0,0 +1,165 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// captured is the request a test server received.
+type captured struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   map[string]any
+}
+
+// serve starts a server that records each request into got and replies
+// with status and body.
+func serve(t *testing.T, status int, header http.Header, body string) (*httptest.Server, *captured) {
+	t.Helper()
+	got := &captured{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		*got = captured{Method: r.Method, Path: r.URL.Path, Header: r.Header}
+		if err := json.Unmarshal(data, &got.Body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		for k, vs := range header {
+			w.Header()[k] = vs
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, got
+}
+
+// client returns the Client for provider that sends its requests to srv.
+func client(t *testing.T, provider string, srv *httptest.Server) Client {
+	t.Helper()
+	c, err := New(Config{Provider: provider, Model: "m1", BaseURL: srv.URL + "/", APIKey: "secret"}, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// collect returns an emit func that appends each delta to deltas.
+func collect(deltas *[]string) func(string) error {
+	return func(d string) error {
+		*deltas = append(*deltas, d)
+		return nil
+	}
+}
+
+func TestNew_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"no model", Config{Provider: OpenAI, APIKey: "k"}, "no model configured"},
+		{"no provider", Config{Model: "m"}, "no provider configured"},
+		{"unknown provider", Config{Provider: "bard", Model: "m"}, `unknown provider "bard" (want anthropic, ollama, openai)`},
+		{"openai without key", Config{Provider: OpenAI, Model: "m"}, "openai: no API key"},
+		{"anthropic without key", Config{Provider: Anthropic, Model: "m"}, "anthropic: no API key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.cfg, nil); err == nil || err.Error() != tt.want {
+				t.Errorf("New() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if _, err := New(Config{Provider: Ollama, Model: "m"}, nil); err != nil {
+		t.Errorf("New() for ollama without a key: %v", err)
+	}
+}
+
+func TestPost_StatusError(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		want       time.Duration
+	}{
+		{"no retry-after", "", 0},
+		{"seconds", "7", 7 * time.Second},
+		{"past date", "Mon, 02 Jan 2006 15:04:05 GMT", 0},
+		{"garbage", "soon", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter != "" {
+				header.Set("Retry-After", tt.retryAfter)
+			}
+			srv, _ := serve(t, http.StatusTooManyRequests, header, "  slow down\n")
+			_, err := client(t, Anthropic, srv).Complete(context.Background(), UserPrompt("hi"))
+			var se *StatusError
+			if !errors.As(err, &se) {
+				t.Fatalf("Complete() error = %v, want a *StatusError", err)
+			}
+			if se.StatusCode != http.StatusTooManyRequests || se.Message != "slow down" || se.RetryAfter != tt.want {
+				t.Errorf("StatusError = %+v, want status 429, message %q, RetryAfter %v", se, "slow down", tt.want)
+			}
+			if want := "anthropic: 429 Too Many Requests: slow down"; se.Error() != want {
+				t.Errorf("Error() = %q, want %q", se.Error(), want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter_Date(t *testing.T) {
+	v := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(v); got <= 50*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %v, want about a minute", v, got)
+	}
+}
+
+func TestDecodeJSON_Text(t *testing.T) {
+	type verdict struct {
+		OK bool `json:"ok"`
+	}
+	tests := []struct {
+		name    string
+		text    string
+		want    verdict
+		wantErr bool
+	}{
+		{name: "bare", text: `{"ok": true}`, want: verdict{OK: true}},
+		{name: "fenced", text: "```json\n{\"ok\": true}\n```", want: verdict{OK: true}},
+		{name: "fenced without language", text: "```\n{\"ok\": true}\n```\n", want: verdict{OK: true}},
+		{name: "prose around", text: "Here you go:\n{\"ok\": true}\nHope that helps.", want: verdict{OK: true}},
+		{name: "no JSON", text: "I can't review this.", wantErr: true},
+		{name: "cut off", text: `{"ok": tr`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got verdict
+			err := DecodeJSON(tt.text, &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeJSON() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DecodeJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponse_Truncated(t *testing.T) {
+	for reason, want := range map[string]bool{"length": true, "max_tokens": true, "stop": false, "end_turn": false, "": false} {
+		if got := (Response{StopReason: reason}).Truncated(); got != want {
+			t.Errorf("Truncated() with stop reason %q = %v, want %v", reason, got, want)
+		}
+	}
+}
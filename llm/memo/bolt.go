@@ -0,0 +1,74 @@
+package memo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Bolt is a Store in a single bbolt database file. It keeps a local cache
+// in one file rather than one file per entry. The database is opened for
+// each call and closed again, so concurrent runs take turns rather than
+// one holding it for a whole run.
+type Bolt string
+
+// boltBucket holds the entries.
+var boltBucket = []byte("memo")
+
+// boltTimeout is how long a call waits for another process to release the
+// database.
+const boltTimeout = 5 * time.Second
+
+// Get returns the value stored under key. A missing database or entry is
+// not an error.
+func (b Bolt) Get(_ context.Context, key string) ([]byte, bool, error) {
+	if _, err := os.Stat(string(b)); errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	db, err := bbolt.Open(string(b), 0o644, &bbolt.Options{Timeout: boltTimeout, ReadOnly: true})
+	if err != nil {
+		return nil, false, fmt.Errorf("opening cache: %w", err)
+	}
+	defer db.Close()
+	var value []byte
+	err = db.View(func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket(boltBucket); bucket != nil {
+			// The value is only valid during the transaction.
+			if v := bucket.Get([]byte(key)); v != nil {
+				value = append([]byte(nil), v...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return value, value != nil, nil
+}
+
+// Put stores value under key, creating the database if need be.
+func (b Bolt) Put(_ context.Context, key string, value []byte) error {
+	if err := os.MkdirAll(filepath.Dir(string(b)), 0o755); err != nil {
+		return fmt.Errorf("creating cache: %w", err)
+	}
+	db, err := bbolt.Open(string(b), 0o644, &bbolt.Options{Timeout: boltTimeout})
+	if err != nil {
+		return fmt.Errorf("opening cache: %w", err)
+	}
+	err = db.Update(func(tx *bbolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(key), value)
+	})
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package memo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBolt_Store(t *testing.T) {
+	testStore(t, Bolt(filepath.Join(t.TempDir(), "cache", "review.db")))
+}
+
+func TestBolt_Get_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "review.db")
+	if err := os.WriteFile(path, []byte("not a bbolt database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b := Bolt(path)
+	if _, ok, err := b.Get(context.Background(), Key("k")); err == nil || ok {
+		t.Errorf("Get() = %v, %v; want an error", ok, err)
+	}
+	if err := b.Put(context.Background(), Key("k"), []byte("v")); err == nil {
+		t.Error("Put() into a corrupt database succeeded")
+	}
+}
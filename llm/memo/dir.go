@@ -0,0 +1,55 @@
+package memo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Dir is a Store of one file per entry beneath a local directory. Entries
+// never go stale, so deleting the directory is always safe.
+type Dir string
+
+// Get returns the value stored under key. A missing entry is not an error.
+func (d Dir) Get(_ context.Context, key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Put stores value under key. It writes to a temporary file and renames it,
+// so concurrent runs never read a partial entry.
+func (d Dir) Put(_ context.Context, key string, value []byte) error {
+	name := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("creating cache: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (d Dir) path(key string) string {
+	if len(key) < 2 {
+		return filepath.Join(string(d), key)
+	}
+	return filepath.Join(string(d), key[:2], key)
+}
@@ -0,0 +1,39 @@
+package memo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDir_Store(t *testing.T) {
+	testStore(t, Dir(filepath.Join(t.TempDir(), "review")))
+}
+
+func TestDir_Get_Unreadable(t *testing.T) {
+	d := Dir(t.TempDir())
+	key := Key("k")
+	// A directory where the entry belongs can't be read as one.
+	if err := os.MkdirAll(d.path(key), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := d.Get(context.Background(), key); err == nil || ok {
+		t.Errorf("Get() = %v, %v; want an error", ok, err)
+	}
+}
+
+func TestDir_Put_NoTempFilesLeft(t *testing.T) {
+	d := Dir(t.TempDir())
+	key := Key("k")
+	if err := d.Put(context.Background(), key, []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(d.path(key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != key {
+		t.Errorf("entry directory holds %v, want only %s", entries, key)
+	}
+}
@@ -0,0 +1,35 @@
+// Package memo stores model responses by the content that produced them, so
+// a review of an unchanged file reuses the earlier verdict instead of
+// spending tokens on it again.
+//
+// A Store maps keys to values. Key derives a key from everything that can
+// change a response, such as the prompt's hash, the model and the reviewed
+// file's content, so an entry is never stale and needs no invalidation. Dir
+// keeps entries in a local directory, Bolt in a local bbolt database file,
+// and Redis shares them between CI runners.
+package memo
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// A Store holds values by key. Implementations are safe for concurrent use.
+type Store interface {
+	// Get returns the value stored under key, and whether there is one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Put stores value under key, replacing any value there.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// Key returns a key derived from parts, in order. Each part is
+// length-prefixed, so no two lists of parts share a key.
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%d:%s", len(p), p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,44 @@
+package memo
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+// testStore checks the behavior every Store shares: a miss, a round trip,
+// and a replaced entry.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	key := Key("prompt", "model", "content")
+	if _, ok, err := s.Get(ctx, key); err != nil || ok {
+		t.Fatalf("Get() of a new key = %v, %v; want a miss", ok, err)
+	}
+	for _, value := range [][]byte{[]byte(`{"findings": []}`), []byte(`{"findings": [{"line": 1}]}`)} {
+		if err := s.Put(ctx, key, value); err != nil {
+			t.Fatalf("Put() = %v", err)
+		}
+		got, ok, err := s.Get(ctx, key)
+		if err != nil || !ok || !bytes.Equal(got, value) {
+			t.Fatalf("Get() = %q, %v, %v; want %q", got, ok, err, value)
+		}
+	}
+	if _, ok, err := s.Get(ctx, Key("prompt", "model", "other content")); err != nil || ok {
+		t.Errorf("Get() of another key = %v, %v; want a miss", ok, err)
+	}
+}
+
+func TestKey_Parts(t *testing.T) {
+	if Key("a", "b") != Key("a", "b") {
+		t.Error("Key() differs for the same parts")
+	}
+	for _, other := range [][]string{{"ab"}, {"a", "b", ""}, {"b", "a"}, {"a:", "b"}} {
+		if Key("a", "b") == Key(other...) {
+			t.Errorf("Key(a, b) == Key(%q)", other)
+		}
+	}
+	if n := len(Key()); n != 64 {
+		t.Errorf("len(Key()) = %d, want 64 hex digits", n)
+	}
+}
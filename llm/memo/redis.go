@@ -0,0 +1,68 @@
+package memo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/redis/go-redis/v9/maintnotifications"
+)
+
+// Redis is a Store on a Redis server, shared by every run that can reach
+// it. Its client, with a pool of connections, is created on first use.
+type Redis struct {
+	Addr     string // host:port
+	Password string // empty if the server needs none
+	DB       int
+	Prefix   string        // prepended to each key
+	TTL      time.Duration // how long entries live; 0 means until evicted
+
+	once   sync.Once
+	client *redis.Client
+}
+
+// Get returns the value stored under key.
+func (s *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := s.conn().Get(ctx, s.Prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("redis: %w", err)
+	}
+	return v, true, nil
+}
+
+// Put stores value under key, to expire after TTL.
+func (s *Redis) Put(ctx context.Context, key string, value []byte) error {
+	if err := s.conn().Set(ctx, s.Prefix+key, value, s.TTL).Err(); err != nil {
+		return fmt.Errorf("redis: %w", err)
+	}
+	return nil
+}
+
+// Close closes the client's connections.
+func (s *Redis) Close() error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Close()
+}
+
+func (s *Redis) conn() *redis.Client {
+	s.once.Do(func() {
+		s.client = redis.NewClient(&redis.Options{
+			Addr:     s.Addr,
+			Password: s.Password,
+			DB:       s.DB,
+			// The handshake for maintenance notifications logs a warning
+			// on servers that lack them, and a run is too short to need
+			// them.
+			MaintNotificationsConfig: &maintnotifications.Config{Mode: maintnotifications.ModeDisabled},
+		})
+	})
+	return s.client
+}
@@ -0,0 +1,46 @@
+package memo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestRedis_Store(t *testing.T) {
+	srv := miniredis.RunT(t)
+	s := &Redis{Addr: srv.Addr(), Prefix: "stdcheck:review:"}
+	defer s.Close()
+	testStore(t, s)
+	if keys := srv.Keys(); len(keys) != 1 || keys[0] != "stdcheck:review:"+Key("prompt", "model", "content") {
+		t.Errorf("server keys = %q, want the one entry under the prefix", keys)
+	}
+}
+
+func TestRedis_Put_TTL(t *testing.T) {
+	srv := miniredis.RunT(t)
+	s := &Redis{Addr: srv.Addr(), TTL: time.Hour}
+	defer s.Close()
+	ctx := context.Background()
+	if err := s.Put(ctx, "k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := srv.TTL("k"); ttl != time.Hour {
+		t.Errorf("TTL = %v, want %v", ttl, time.Hour)
+	}
+	srv.FastForward(time.Hour)
+	if _, ok, err := s.Get(ctx, "k"); err != nil || ok {
+		t.Errorf("Get() after the TTL = %v, %v; want a miss", ok, err)
+	}
+}
+
+func TestRedis_Get_Unreachable(t *testing.T) {
+	srv := miniredis.RunT(t)
+	s := &Redis{Addr: srv.Addr()}
+	defer s.Close()
+	srv.Close()
+	if _, ok, err := s.Get(context.Background(), "k"); err == nil || ok {
+		t.Errorf("Get() = %v, %v; want an error", ok, err)
+	}
+}
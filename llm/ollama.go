@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ollamaClient calls the chat API of an Ollama server, which runs models
+// locally. It needs no API key.
+type ollamaClient struct {
+	cfg Config
+	hc  *http.Client
+}
+
+type ollamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Stream   bool            `json:"stream"`
+	Format   string          `json:"format,omitempty"`
+	Options  struct {
+		Temperature *float64 `json:"temperature,omitempty"`
+		NumPredict  int      `json:"num_predict"`
+	} `json:"options"`
+}
+
+type ollamaResponse struct {
+	Model           string        `json:"model"`
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	DoneReason      string        `json:"done_reason"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+	Error           string        `json:"error"`
+}
+
+func (c *ollamaClient) body(req Request, jsonMode, stream bool) ollamaRequest {
+	body := ollamaRequest{Model: c.cfg.Model, Stream: stream}
+	body.Options.Temperature = req.Temperature
+	body.Options.NumPredict = maxTokens(req)
+	if req.System != "" {
+		body.Messages = append(body.Messages, ollamaMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, ollamaMessage{Role: string(m.Role), Content: m.Content})
+	}
+	if jsonMode {
+		body.Format = "json"
+	}
+	return body
+}
+
+func (r ollamaResponse) response(text string) Response {
+	return Response{
+		Text:       text,
+		Model:      r.Model,
+		StopReason: r.DoneReason,
+		Usage:      Usage{InputTokens: r.PromptEvalCount, OutputTokens: r.EvalCount},
+	}
+}
+
+func (c *ollamaClient) complete(ctx context.Context, req Request, jsonMode bool) (Response, error) {
+	var out ollamaResponse
+	if err := postJSON(ctx, c.hc, c.cfg.Provider, c.cfg.BaseURL+"/api/chat", nil, c.body(req, jsonMode, false), &out); err != nil {
+		return Response{}, err
+	}
+	if out.Error != "" {
+		return Response{}, fmt.Errorf("ollama: %s", out.Error)
+	}
+	return out.response(out.Message.Content), nil
+}
+
+func (c *ollamaClient) Complete(ctx context.Context, req Request) (Response, error) {
+	return c.complete(ctx, req, false)
+}
+
+func (c *ollamaClient) CompleteJSON(ctx context.Context, req Request, out any) (Response, error) {
+	resp, err := c.complete(ctx, withJSONInstruction(req), true)
+	if err != nil {
+		return resp, err
+	}
+	return resp, DecodeJSON(resp.Text, out)
+}
+
+func (c *ollamaClient) Stream(ctx context.Context, req Request, emit func(string) error) (Response, error) {
+	return c.stream(ctx, req, false, emit)
+}
+
+func (c *ollamaClient) StreamJSON(ctx context.Context, req Request, out any, emit func(string) error) (Response, error) {
+	resp, err := c.stream(ctx, withJSONInstruction(req), true, emit)
+	if err != nil {
+		return resp, err
+	}
+	return resp, DecodeJSON(resp.Text, out)
+}
+
+// stream reads the newline-delimited JSON objects Ollama streams, the last
+// of which is marked done and carries the token counts.
+func (c *ollamaClient) stream(ctx context.Context, req Request, jsonMode bool, emit func(string) error) (Response, error) {
+	httpResp, err := post(ctx, c.hc, c.cfg.Provider, c.cfg.BaseURL+"/api/chat", nil, c.body(req, jsonMode, true))
+	if err != nil {
+		return Response{}, err
+	}
+	defer httpResp.Body.Close()
+
+	var text strings.Builder
+	sc := bufio.NewScanner(httpResp.Body)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		if len(strings.TrimSpace(sc.Text())) == 0 {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal(sc.Bytes(), &chunk); err != nil {
+			return Response{Text: text.String()}, fmt.Errorf("ollama: decoding stream: %w", err)
+		}
+		if chunk.Error != "" {
+			return Response{Text: text.String()}, fmt.Errorf("ollama: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			text.WriteString(chunk.Message.Content)
+			if err := emit(chunk.Message.Content); err != nil {
+				return Response{Text: text.String()}, err
+			}
+		}
+		if chunk.Done {
+			return chunk.response(text.String()), nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return Response{Text: text.String()}, fmt.Errorf("ollama: reading stream: %w", err)
+	}
+	return Response{Text: text.String()}, fmt.Errorf("ollama: stream ended before the response was done")
+}
@@ -0,0 +1,122 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOllamaClient_Complete(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, nil, `{
+		"model": "m1:latest",
+		"message": {"role": "assistant", "content": "Hello"},
+		"done": true,
+		"done_reason": "stop",
+		"prompt_eval_count": 8,
+		"eval_count": 2
+	}`)
+	req := Request{System: "Be brief.", Messages: []Message{{Role: RoleUser, Content: "hi"}}}
+	resp, err := client(t, Ollama, srv).Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Method != http.MethodPost || got.Path != "/api/chat" {
+		t.Errorf("request = %s %s, want POST /api/chat", got.Method, got.Path)
+	}
+	if v := got.Header.Get("Authorization"); v != "" {
+		t.Errorf("Authorization = %q, want none", v)
+	}
+	wantBody := map[string]any{
+		"model": "m1",
+		"messages": []any{
+			map[string]any{"role": "system", "content": "Be brief."},
+			map[string]any{"role": "user", "content": "hi"},
+		},
+		"stream":  false,
+		"options": map[string]any{"num_predict": float64(DefaultMaxTokens)},
+	}
+	if !reflect.DeepEqual(got.Body, wantBody) {
+		t.Errorf("body = %v, want %v", got.Body, wantBody)
+	}
+
+	want := Response{Text: "Hello", Model: "m1:latest", StopReason: "stop", Usage: Usage{InputTokens: 8, OutputTokens: 2}}
+	if resp != want {
+		t.Errorf("Complete() = %+v, want %+v", resp, want)
+	}
+}
+
+func TestOllamaClient_CompleteJSON(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, nil, `{"message": {"content": "{\"ok\": true}"}, "done": true}`)
+	var out struct{ OK bool }
+	if _, err := client(t, Ollama, srv).CompleteJSON(context.Background(), UserPrompt("hi"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.OK {
+		t.Error("CompleteJSON() didn't decode the JSON")
+	}
+	if got.Body["format"] != "json" {
+		t.Errorf("format = %v, want json", got.Body["format"])
+	}
+}
+
+func TestOllamaClient_Complete_Error(t *testing.T) {
+	srv, _ := serve(t, http.StatusOK, nil, `{"error": "model \"m1\" not found"}`)
+	_, err := client(t, Ollama, srv).Complete(context.Background(), UserPrompt("hi"))
+	if err == nil || err.Error() != `ollama: model "m1" not found` {
+		t.Errorf("Complete() error = %v, want the server's error", err)
+	}
+}
+
+func TestOllamaClient_Stream(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    Response
+		deltas  []string
+		wantErr string
+	}{
+		{
+			name: "done",
+			body: `{"model": "m1:latest", "message": {"content": "Hel"}}` + "\n\n" +
+				`{"model": "m1:latest", "message": {"content": "lo"}}` + "\n" +
+				`{"model": "m1:latest", "message": {"content": ""}, "done": true, "done_reason": "stop", "prompt_eval_count": 8, "eval_count": 2}` + "\n",
+			want:   Response{Text: "Hello", Model: "m1:latest", StopReason: "stop", Usage: Usage{InputTokens: 8, OutputTokens: 2}},
+			deltas: []string{"Hel", "lo"},
+		},
+		{
+			name:    "ends early",
+			body:    `{"message": {"content": "Hel"}}` + "\n",
+			want:    Response{Text: "Hel"},
+			deltas:  []string{"Hel"},
+			wantErr: "ollama: stream ended before the response was done",
+		},
+		{
+			name:    "error chunk",
+			body:    `{"message": {"content": "Hel"}}` + "\n" + `{"error": "out of memory"}` + "\n",
+			want:    Response{Text: "Hel"},
+			deltas:  []string{"Hel"},
+			wantErr: "ollama: out of memory",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, got := serve(t, http.StatusOK, http.Header{"Content-Type": {"application/x-ndjson"}}, tt.body)
+			var deltas []string
+			resp, err := client(t, Ollama, srv).Stream(context.Background(), UserPrompt("hi"), collect(&deltas))
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("Stream() error = %v, want %q", err, tt.wantErr)
+			}
+			if got.Body["stream"] != true {
+				t.Errorf("stream = %v, want true", got.Body["stream"])
+			}
+			if resp != tt.want {
+				t.Errorf("Stream() = %+v, want %+v", resp, tt.want)
+			}
+			if !reflect.DeepEqual(deltas, tt.deltas) {
+				t.Errorf("deltas = %q, want %q", deltas, tt.deltas)
+			}
+		})
+	}
+}
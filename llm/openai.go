@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// openAIClient calls the OpenAI chat completions API, which many gateways
+// and local servers also implement.
+type openAIClient struct {
+	cfg Config
+	hc  *http.Client
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type openAIRequest struct {
+	Model          string          `json:"model"`
+	Messages       []openAIMessage `json:"messages"`
+	MaxTokens      int             `json:"max_completion_tokens"`
+	Temperature    *float64        `json:"temperature,omitempty"`
+	ResponseFormat *struct {
+		Type string `json:"type"`
+	} `json:"response_format,omitempty"`
+	Stream        bool `json:"stream,omitempty"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type openAIResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message      openAIMessage `json:"message"`
+		Delta        openAIMessage `json:"delta"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+func (c *openAIClient) body(req Request, jsonMode bool) openAIRequest {
+	body := openAIRequest{Model: c.cfg.Model, MaxTokens: maxTokens(req), Temperature: req.Temperature}
+	if req.System != "" {
+		body.Messages = append(body.Messages, openAIMessage{Role: "system", Content: req.System})
+	}
+	for _, m := range req.Messages {
+		body.Messages = append(body.Messages, openAIMessage{Role: string(m.Role), Content: m.Content})
+	}
+	if jsonMode {
+		body.ResponseFormat = &struct {
+			Type string `json:"type"`
+		}{Type: "json_object"}
+	}
+	return body
+}
+
+func (c *openAIClient) header() http.Header {
+	return http.Header{"Authorization": {"Bearer " + c.cfg.APIKey}}
+}
+
+func (c *openAIClient) complete(ctx context.Context, req Request, jsonMode bool) (Response, error) {
+	var out openAIResponse
+	if err := postJSON(ctx, c.hc, c.cfg.Provider, c.cfg.BaseURL+"/chat/completions", c.header(), c.body(req, jsonMode), &out); err != nil {
+		return Response{}, err
+	}
+	if len(out.Choices) == 0 {
+		return Response{}, fmt.Errorf("openai: response has no choices")
+	}
+	resp := Response{Text: out.Choices[0].Message.Content, Model: out.Model, StopReason: out.Choices[0].FinishReason}
+	if out.Usage != nil {
+		resp.Usage = Usage{InputTokens: out.Usage.PromptTokens, OutputTokens: out.Usage.CompletionTokens}
+	}
+	return resp, nil
+}
+
+func (c *openAIClient) Complete(ctx context.Context, req Request) (Response, error) {
+	return c.complete(ctx, req, false)
+}
+
+func (c *openAIClient) CompleteJSON(ctx context.Context, req Request, out any) (Response, error) {
+	// JSON mode requires the word JSON in the messages.
+	resp, err := c.complete(ctx, withJSONInstruction(req), true)
+	if err != nil {
+		return resp, err
+	}
+	return resp, DecodeJSON(resp.Text, out)
+}
+
+func (c *openAIClient) Stream(ctx context.Context, req Request, emit func(string) error) (Response, error) {
+	return c.stream(ctx, req, false, emit)
+}
+
+func (c *openAIClient) StreamJSON(ctx context.Context, req Request, out any, emit func(string) error) (Response, error) {
+	resp, err := c.stream(ctx, withJSONInstruction(req), true, emit)
+	if err != nil {
+		return resp, err
+	}
+	return resp, DecodeJSON(resp.Text, out)
+}
+
+func (c *openAIClient) stream(ctx context.Context, req Request, jsonMode bool, emit func(string) error) (Response, error) {
+	body := c.body(req, jsonMode)
+	body.Stream = true
+	body.StreamOptions = &struct {
+		IncludeUsage bool `json:"include_usage"`
+	}{IncludeUsage: true}
+	httpResp, err := post(ctx, c.hc, c.cfg.Provider, c.cfg.BaseURL+"/chat/completions", c.header(), body)
+	if err != nil {
+		return Response{}, err
+	}
+	defer httpResp.Body.Close()
+
+	var resp Response
+	var text strings.Builder
+	err = readEvents(httpResp.Body, func(_, data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+		var chunk openAIResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, fmt.Errorf("openai: decoding stream: %w", err)
+		}
+		if chunk.Model != "" {
+			resp.Model = chunk.Model
+		}
+		if chunk.Usage != nil {
+			resp.Usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+		}
+		for _, choice := range chunk.Choices {
+			if choice.FinishReason != "" {
+				resp.StopReason = choice.FinishReason
+			}
+			if choice.Delta.Content != "" {
+				text.WriteString(choice.Delta.Content)
+				if err := emit(choice.Delta.Content); err != nil {
+					return false, err
+				}
+			}
+		}
+		return false, nil
+	})
+	resp.Text = text.String()
+	return resp, err
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAIClient_Complete(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, nil, `{
+		"model": "m1-2025",
+		"choices": [{"message": {"role": "assistant", "content": "Hello"}, "finish_reason": "length"}],
+		"usage": {"prompt_tokens": 9, "completion_tokens": 1}
+	}`)
+	req := Request{System: "Be brief.", Messages: []Message{{Role: RoleUser, Content: "hi"}}, MaxTokens: 1}
+	resp, err := client(t, OpenAI, srv).Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Method != http.MethodPost || got.Path != "/chat/completions" {
+		t.Errorf("request = %s %s, want POST /chat/completions", got.Method, got.Path)
+	}
+	if v := got.Header.Get("Authorization"); v != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", v, "Bearer secret")
+	}
+	wantBody := map[string]any{
+		"model": "m1",
+		"messages": []any{
+			map[string]any{"role": "system", "content": "Be brief."},
+			map[string]any{"role": "user", "content": "hi"},
+		},
+		"max_completion_tokens": 1.0,
+	}
+	if !reflect.DeepEqual(got.Body, wantBody) {
+		t.Errorf("body = %v, want %v", got.Body, wantBody)
+	}
+
+	want := Response{Text: "Hello", Model: "m1-2025", StopReason: "length", Usage: Usage{InputTokens: 9, OutputTokens: 1}}
+	if resp != want {
+		t.Errorf("Complete() = %+v, want %+v", resp, want)
+	}
+	if !resp.Truncated() {
+		t.Error("Truncated() = false for finish reason length")
+	}
+}
+
+func TestOpenAIClient_Complete_NoChoices(t *testing.T) {
+	srv, _ := serve(t, http.StatusOK, nil, `{"model": "m1", "choices": []}`)
+	if _, err := client(t, OpenAI, srv).Complete(context.Background(), UserPrompt("hi")); err == nil {
+		t.Error("Complete() succeeded on a response without choices")
+	}
+}
+
+func TestOpenAIClient_CompleteJSON(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, nil, `{"choices": [{"message": {"content": "{\"ok\": true}"}}]}`)
+	var out struct{ OK bool }
+	if _, err := client(t, OpenAI, srv).CompleteJSON(context.Background(), UserPrompt("hi"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.OK {
+		t.Error("CompleteJSON() didn't decode the JSON")
+	}
+	if want := map[string]any{"type": "json_object"}; !reflect.DeepEqual(got.Body["response_format"], want) {
+		t.Errorf("response_format = %v, want %v", got.Body["response_format"], want)
+	}
+	first := got.Body["messages"].([]any)[0].(map[string]any)
+	if first["role"] != "system" || first["content"] != jsonInstruction {
+		t.Errorf("first message = %v, want the JSON instruction as the system message", first)
+	}
+}
+
+const openAIStream = `data: {"model": "m1-2025", "choices": [{"delta": {"role": "assistant"}}]}
+
+data: {"model": "m1-2025", "choices": [{"delta": {"content": "Hel"}}]}
+
+data: {"model": "m1-2025", "choices": [{"delta": {"content": "lo"}, "finish_reason": "stop"}]}
+
+data: {"model": "m1-2025", "choices": [], "usage": {"prompt_tokens": 9, "completion_tokens": 2}}
+
+data: [DONE]
+
+data: {"choices": [{"delta": {"content": "after done"}}]}
+
+`
+
+func TestOpenAIClient_Stream(t *testing.T) {
+	srv, got := serve(t, http.StatusOK, http.Header{"Content-Type": {"text/event-stream"}}, openAIStream)
+	var deltas []string
+	resp, err := client(t, OpenAI, srv).Stream(context.Background(), UserPrompt("hi"), collect(&deltas))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Body["stream"] != true {
+		t.Errorf("stream = %v, want true", got.Body["stream"])
+	}
+	if want := map[string]any{"include_usage": true}; !reflect.DeepEqual(got.Body["stream_options"], want) {
+		t.Errorf("stream_options = %v, want %v", got.Body["stream_options"], want)
+	}
+	if want := []string{"Hel", "lo"}; !reflect.DeepEqual(deltas, want) {
+		t.Errorf("deltas = %q, want %q", deltas, want)
+	}
+	want := Response{Text: "Hello", Model: "m1-2025", StopReason: "stop", Usage: Usage{InputTokens: 9, OutputTokens: 2}}
+	if resp != want {
+		t.Errorf("Stream() = %+v, want %+v", resp, want)
+	}
+}
+
+func TestOpenAIClient_Stream_Errors(t *testing.T) {
+	t.Run("bad chunk", func(t *testing.T) {
+		srv, _ := serve(t, http.StatusOK, nil, "data: {\"choices\": [{\"delta\": {\"content\": \"Hel\"}}]}\n\ndata: {not json\n\n")
+		resp, err := client(t, OpenAI, srv).Stream(context.Background(), UserPrompt("hi"), func(string) error { return nil })
+		if err == nil {
+			t.Error("Stream() succeeded on a malformed chunk")
+		}
+		if resp.Text != "Hel" {
+			t.Errorf("Stream() text = %q, want the text before the bad chunk", resp.Text)
+		}
+	})
+	t.Run("status", func(t *testing.T) {
+		srv, _ := serve(t, http.StatusUnauthorized, nil, `{"error": "bad key"}`)
+		_, err := client(t, OpenAI, srv).Stream(context.Background(), UserPrompt("hi"), func(string) error { return nil })
+		var se *StatusError
+		if !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized || se.Provider != OpenAI {
+			t.Errorf("Stream() error = %v, want an openai *StatusError with status 401", err)
+		}
+	})
+}
@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the provider while the circuit
+// breaker of a WithPolicy client is open.
+var ErrCircuitOpen = errors.New("provider unavailable: circuit breaker open")
+
+// Policy configures how WithPolicy calls a provider: how fast, how often it
+// retries, and when it stops trying.
+type Policy struct {
+	// RequestsPerMinute spaces calls evenly to stay under the provider's
+	// rate limit; 0 means no limit.
+	RequestsPerMinute int
+	// MaxRetries is how many times a call that failed transiently is
+	// retried; see Transient.
+	MaxRetries int
+	// BaseDelay is the wait before the first retry. Each later retry waits
+	// twice as long as the one before, up to MaxDelay, with jitter.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// BreakAfter is how many calls in a row may fail transiently, after
+	// their retries, before the circuit breaker opens; 0 means never. While
+	// it is open, calls fail with ErrCircuitOpen. After Cooldown, one call
+	// is let through: if it succeeds the breaker closes, and otherwise it
+	// stays open for another Cooldown.
+	BreakAfter int
+	Cooldown   time.Duration
+}
+
+// DefaultPolicy retries three times within about seven seconds, and gives up
+// on a provider that fails three calls in a row.
+var DefaultPolicy = Policy{
+	MaxRetries: 3,
+	BaseDelay:  time.Second,
+	MaxDelay:   30 * time.Second,
+	BreakAfter: 3,
+	Cooldown:   30 * time.Second,
+}
+
+// Transient reports whether err is a failure that may pass: the provider
+// rate limited the call (429), failed on its side (5xx), couldn't be
+// reached or closed the connection partway through the response, or the
+// circuit breaker is open. Other errors, such as a rejected API key, fail
+// again however often a call is retried.
+func Transient(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.StatusCode == 429 || se.StatusCode >= 500
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false // the caller gave up
+	}
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, ErrCircuitOpen)
+}
+
+// WithPolicy returns a Client that calls c as p configures. The Usage of
+// a response it returns adds up every attempt's, since the provider bills
+// failed attempts that got as far as a response too. It is safe for
+// concurrent use if c is.
+func WithPolicy(c Client, p Policy) Client {
+	return &policyClient{next: c, policy: p, now: time.Now, sleep: sleep}
+}
+
+type policyClient struct {
+	next   Client
+	policy Policy
+	// now and sleep are time.Now and the package's sleep, unless a test
+	// stands in a fake clock.
+	now   func() time.Time
+	sleep func(context.Context, time.Duration) error
+
+	mu        sync.Mutex
+	nextSlot  time.Time // earliest start of the next call under the rate limit
+	failures  int       // consecutive calls that failed transiently
+	openUntil time.Time // while the breaker is open; zero when closed
+	probing   bool      // a call is testing whether the provider is back
+}
+
+func (c *policyClient) Complete(ctx context.Context, req Request) (Response, error) {
+	return c.call(ctx, nil, func(ctx context.Context, emit func(string) error) (Response, error) {
+		return c.next.Complete(ctx, req)
+	})
+}
+
+func (c *policyClient) CompleteJSON(ctx context.Context, req Request, out any) (Response, error) {
+	return c.call(ctx, nil, func(ctx context.Context, emit func(string) error) (Response, error) {
+		return c.next.CompleteJSON(ctx, req, out)
+	})
+}
+
+func (c *policyClient) Stream(ctx context.Context, req Request, emit func(string) error) (Response, error) {
+	return c.call(ctx, emit, func(ctx context.Context, emit func(string) error) (Response, error) {
+		return c.next.Stream(ctx, req, emit)
+	})
+}
+
+func (c *policyClient) StreamJSON(ctx context.Context, req Request, out any, emit func(string) error) (Response, error) {
+	return c.call(ctx, emit, func(ctx context.Context, emit func(string) error) (Response, error) {
+		return c.next.StreamJSON(ctx, req, out, emit)
+	})
+}
+
+// call makes a call through the breaker, the rate limiter and retries. A
+// streamed call is retried only until the first fragment is emitted, since
+// the caller can't take fragments back.
+func (c *policyClient) call(ctx context.Context, emit func(string) error, do func(context.Context, func(string) error) (Response, error)) (Response, error) {
+	if err := c.admit(); err != nil {
+		return Response{}, err
+	}
+	emitted := false
+	if emit != nil {
+		inner := emit
+		emit = func(delta string) error {
+			emitted = true
+			return inner(delta)
+		}
+	}
+	var resp Response
+	var err error
+	var used Usage // by the attempts before this one
+	for attempt := 0; ; attempt++ {
+		if err = c.wait(ctx); err != nil {
+			break
+		}
+		resp, err = do(ctx, emit)
+		resp.Usage.InputTokens += used.InputTokens
+		resp.Usage.OutputTokens += used.OutputTokens
+		used = resp.Usage
+		if err == nil || !Transient(err) || emitted || attempt == c.policy.MaxRetries {
+			break
+		}
+		delay := c.backoff(attempt, err)
+		if delay < 0 {
+			break // the provider asked for a longer wait than the policy allows
+		}
+		if serr := c.sleep(ctx, delay); serr != nil {
+			err = errors.Join(err, serr)
+			break
+		}
+	}
+	c.settle(err)
+	return resp, err
+}
+
+// admit fails the call if the breaker is open. Once the cooldown is over,
+// it lets one call through to probe the provider.
+func (c *policyClient) admit() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.openUntil.IsZero() {
+		return nil
+	}
+	if c.probing || c.now().Before(c.openUntil) {
+		return ErrCircuitOpen
+	}
+	c.probing = true
+	return nil
+}
+
+// settle records the outcome of a call with the breaker.
+func (c *policyClient) settle(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.probing = false
+	if err == nil || !Transient(err) {
+		c.failures = 0
+		c.openUntil = time.Time{}
+		return
+	}
+	c.failures++
+	if c.policy.BreakAfter > 0 && c.failures >= c.policy.BreakAfter {
+		c.openUntil = c.now().Add(c.policy.Cooldown)
+	}
+}
+
+// wait blocks until the rate limit allows another call.
+func (c *policyClient) wait(ctx context.Context) error {
+	if c.policy.RequestsPerMinute <= 0 {
+		return nil
+	}
+	interval := time.Minute / time.Duration(c.policy.RequestsPerMinute)
+	c.mu.Lock()
+	now := c.now()
+	start := now
+	if c.nextSlot.After(now) {
+		start = c.nextSlot
+	}
+	c.nextSlot = start.Add(interval)
+	c.mu.Unlock()
+	return c.sleep(ctx, start.Sub(now))
+}
+
+// sleep waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// backoff returns the wait before retrying after the given attempt failed
+// with err, or -1 if the provider asked for a longer wait than MaxDelay.
+func (c *policyClient) backoff(attempt int, err error) time.Duration {
+	delay := c.policy.MaxDelay
+	if attempt < 32 {
+		delay = min(c.policy.BaseDelay<<attempt, delay)
+	}
+	// Jitter over the upper half of the delay keeps concurrent runs from
+	// retrying in step.
+	if delay > 1 {
+		delay = delay/2 + rand.N(delay/2)
+	}
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > delay {
+		if se.RetryAfter > c.policy.MaxDelay {
+			return -1
+		}
+		delay = se.RetryAfter
+	}
+	return delay
+}
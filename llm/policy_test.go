@@ -0,0 +1,311 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// attempt is what one call to a scripted client does: emit a fragment, if
+// any, and return resp and err.
+type attempt struct {
+	emit string
+	resp Response
+	err  error
+}
+
+// scripted is a Client whose calls play its attempts in order, and fail the
+// test once they run out.
+type scripted struct {
+	t        *testing.T
+	attempts []attempt
+	calls    int
+}
+
+func (s *scripted) next(emit func(string) error) (Response, error) {
+	if s.calls == len(s.attempts) {
+		s.t.Fatalf("call %d, but only %d scripted", s.calls+1, len(s.attempts))
+	}
+	a := s.attempts[s.calls]
+	s.calls++
+	if a.emit != "" && emit != nil {
+		if err := emit(a.emit); err != nil {
+			return a.resp, err
+		}
+	}
+	return a.resp, a.err
+}
+
+func (s *scripted) Complete(context.Context, Request) (Response, error) { return s.next(nil) }
+
+func (s *scripted) CompleteJSON(context.Context, Request, any) (Response, error) {
+	return s.next(nil)
+}
+
+func (s *scripted) Stream(_ context.Context, _ Request, emit func(string) error) (Response, error) {
+	return s.next(emit)
+}
+
+func (s *scripted) StreamJSON(_ context.Context, _ Request, _ any, emit func(string) error) (Response, error) {
+	return s.next(emit)
+}
+
+// fakeClock stands in for the time a policyClient sees. Sleeping advances
+// it at once.
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(max(d, 0))
+	return ctx.Err()
+}
+
+// withFakeClock returns a policy client over s that runs on a fake clock.
+func withFakeClock(s *scripted, p Policy) (*policyClient, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := WithPolicy(s, p).(*policyClient)
+	c.now = clock.Now
+	c.sleep = clock.Sleep
+	return c, clock
+}
+
+var (
+	errUnavailable = &StatusError{Provider: "p", StatusCode: 503, Status: "503 Service Unavailable"}
+	errBadKey      = &StatusError{Provider: "p", StatusCode: 401, Status: "401 Unauthorized"}
+)
+
+func TestPolicyClient_Complete_Retries(t *testing.T) {
+	p := Policy{MaxRetries: 2, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+	billed := Response{Usage: Usage{InputTokens: 10, OutputTokens: 1}}
+	tests := []struct {
+		name     string
+		attempts []attempt
+		wantErr  error
+		wantUse  Usage
+		// slept holds the bounds of each backoff: jitter puts it in the
+		// upper half of the delay.
+		slept [][2]time.Duration
+	}{
+		{
+			name:     "first try",
+			attempts: []attempt{{resp: billed}},
+			wantUse:  billed.Usage,
+		},
+		{
+			name:     "after two failures",
+			attempts: []attempt{{resp: billed, err: errUnavailable}, {err: errUnavailable}, {resp: billed}},
+			wantUse:  Usage{InputTokens: 20, OutputTokens: 2},
+			slept:    [][2]time.Duration{{time.Second / 2, time.Second}, {time.Second, 2 * time.Second}},
+		},
+		{
+			name:     "retries run out",
+			attempts: []attempt{{err: errUnavailable}, {err: errUnavailable}, {err: errUnavailable}},
+			wantErr:  errUnavailable,
+			slept:    [][2]time.Duration{{time.Second / 2, time.Second}, {time.Second, 2 * time.Second}},
+		},
+		{
+			name:     "not transient",
+			attempts: []attempt{{err: errBadKey}},
+			wantErr:  errBadKey,
+		},
+		{
+			name:     "retry-after longer than the backoff",
+			attempts: []attempt{{err: &StatusError{StatusCode: 429, RetryAfter: 5 * time.Second}}, {resp: billed}},
+			wantUse:  billed.Usage,
+			slept:    [][2]time.Duration{{5 * time.Second, 5 * time.Second}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &scripted{t: t, attempts: tt.attempts}
+			c, clock := withFakeClock(s, p)
+			resp, err := c.Complete(context.Background(), UserPrompt("hi"))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Complete() error = %v, want %v", err, tt.wantErr)
+			}
+			if s.calls != len(tt.attempts) {
+				t.Errorf("%d calls, want %d", s.calls, len(tt.attempts))
+			}
+			if resp.Usage != tt.wantUse {
+				t.Errorf("Usage = %+v, want %+v", resp.Usage, tt.wantUse)
+			}
+			if len(clock.slept) != len(tt.slept) {
+				t.Fatalf("slept %v, want %d backoffs", clock.slept, len(tt.slept))
+			}
+			for i, d := range clock.slept {
+				if lo, hi := tt.slept[i][0], tt.slept[i][1]; d < lo || d > hi {
+					t.Errorf("backoff %d = %v, want between %v and %v", i+1, d, lo, hi)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyClient_Complete_RetryAfterTooLong(t *testing.T) {
+	s := &scripted{t: t, attempts: []attempt{{err: &StatusError{StatusCode: 429, RetryAfter: time.Hour}}}}
+	c, clock := withFakeClock(s, Policy{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: time.Minute})
+	if _, err := c.Complete(context.Background(), UserPrompt("hi")); err == nil {
+		t.Error("Complete() succeeded")
+	}
+	if s.calls != 1 || len(clock.slept) != 0 {
+		t.Errorf("%d calls after sleeping %v; want 1 call and no wait for an hour", s.calls, clock.slept)
+	}
+}
+
+func TestPolicyClient_Stream_NoRetryAfterEmit(t *testing.T) {
+	p := Policy{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: time.Minute}
+	t.Run("before the first fragment", func(t *testing.T) {
+		s := &scripted{t: t, attempts: []attempt{{err: errUnavailable}, {emit: "ok"}}}
+		c, _ := withFakeClock(s, p)
+		var deltas []string
+		if _, err := c.Stream(context.Background(), UserPrompt("hi"), collect(&deltas)); err != nil {
+			t.Fatal(err)
+		}
+		if s.calls != 2 || !reflect.DeepEqual(deltas, []string{"ok"}) {
+			t.Errorf("%d calls emitting %q, want 2 emitting [ok]", s.calls, deltas)
+		}
+	})
+	t.Run("after a fragment", func(t *testing.T) {
+		s := &scripted{t: t, attempts: []attempt{{emit: "part", err: errUnavailable}}}
+		c, _ := withFakeClock(s, p)
+		var deltas []string
+		if _, err := c.StreamJSON(context.Background(), UserPrompt("hi"), nil, collect(&deltas)); !errors.Is(err, errUnavailable) {
+			t.Errorf("StreamJSON() error = %v, want %v", err, errUnavailable)
+		}
+		if s.calls != 1 {
+			t.Errorf("%d calls, want 1: a fragment was emitted", s.calls)
+		}
+	})
+}
+
+func TestPolicyClient_Complete_CircuitBreaker(t *testing.T) {
+	p := Policy{BreakAfter: 2, Cooldown: 30 * time.Second}
+	s := &scripted{t: t, attempts: []attempt{
+		{err: errUnavailable},
+		{err: errUnavailable}, // opens the breaker
+		{err: errUnavailable}, // the first probe, which fails
+		{},                    // the second probe, which closes it
+		{},
+	}}
+	c, clock := withFakeClock(s, p)
+	ctx := context.Background()
+	call := func() error {
+		_, err := c.CompleteJSON(ctx, UserPrompt("hi"), nil)
+		return err
+	}
+
+	for i := range 2 {
+		if err := call(); !errors.Is(err, errUnavailable) {
+			t.Fatalf("call %d: %v, want %v", i+1, err, errUnavailable)
+		}
+	}
+	if err := call(); !errors.Is(err, ErrCircuitOpen) || s.calls != 2 {
+		t.Fatalf("call while open: %v after %d provider calls, want %v after 2", err, s.calls, ErrCircuitOpen)
+	}
+
+	clock.now = clock.now.Add(p.Cooldown)
+	if err := call(); !errors.Is(err, errUnavailable) || s.calls != 3 {
+		t.Fatalf("probe: %v after %d provider calls, want %v after 3", err, s.calls, errUnavailable)
+	}
+	if err := call(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("call after a failed probe: %v, want %v", err, ErrCircuitOpen)
+	}
+
+	clock.now = clock.now.Add(p.Cooldown)
+	for i := range 2 {
+		if err := call(); err != nil {
+			t.Fatalf("call %d after a successful probe: %v", i+1, err)
+		}
+	}
+	if s.calls != 5 {
+		t.Errorf("%d provider calls, want 5", s.calls)
+	}
+}
+
+func TestPolicyClient_Complete_NotTransientResetsBreaker(t *testing.T) {
+	s := &scripted{t: t, attempts: []attempt{{err: errUnavailable}, {err: errBadKey}, {err: errUnavailable}, {}}}
+	c, _ := withFakeClock(s, Policy{BreakAfter: 2, Cooldown: time.Minute})
+	for range 4 {
+		c.Complete(context.Background(), UserPrompt("hi"))
+	}
+	if s.calls != 4 {
+		t.Errorf("%d provider calls, want 4: no two transient failures in a row", s.calls)
+	}
+}
+
+func TestPolicyClient_Complete_RateLimit(t *testing.T) {
+	s := &scripted{t: t, attempts: []attempt{{}, {}, {}, {}}}
+	c, clock := withFakeClock(s, Policy{RequestsPerMinute: 30})
+	for range 3 {
+		if _, err := c.Complete(context.Background(), UserPrompt("hi")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []time.Duration{0, 2 * time.Second, 2 * time.Second}; !reflect.DeepEqual(clock.slept, want) {
+		t.Errorf("slept %v, want %v", clock.slept, want)
+	}
+
+	// A slot left unused isn't saved up.
+	clock.now = clock.now.Add(time.Minute)
+	clock.slept = nil
+	c.Complete(context.Background(), UserPrompt("hi"))
+	if want := []time.Duration{0}; !reflect.DeepEqual(clock.slept, want) {
+		t.Errorf("slept %v after an idle minute, want %v", clock.slept, want)
+	}
+}
+
+func TestPolicyClient_Complete_Canceled(t *testing.T) {
+	s := &scripted{t: t, attempts: []attempt{{err: errUnavailable}}}
+	c, _ := withFakeClock(s, Policy{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := c.Complete(ctx, UserPrompt("hi"))
+	if !errors.Is(err, errUnavailable) || !errors.Is(err, context.Canceled) || s.calls != 1 {
+		t.Errorf("Complete() = %v after %d calls, want the failure and the cancellation after 1", err, s.calls)
+	}
+}
+
+func TestTransient_Errors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{StatusCode: 429}, true},
+		{&StatusError{StatusCode: 500}, true},
+		{fmt.Errorf("wrapped: %w", &StatusError{StatusCode: 503}), true},
+		{&StatusError{StatusCode: 400}, false},
+		{&StatusError{StatusCode: 401}, false},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{fmt.Errorf("ollama: reading stream: %w", io.ErrUnexpectedEOF), true},
+		{ErrCircuitOpen, true},
+		{context.Canceled, false},
+		{fmt.Errorf("x: %w", context.DeadlineExceeded), false},
+		{errors.New("response is not the JSON asked for"), false},
+	}
+	for _, tt := range tests {
+		if got := Transient(tt.err); got != tt.want {
+			t.Errorf("Transient(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSleep_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleep(ctx, time.Hour); !errors.Is(err, context.Canceled) {
+		t.Errorf("sleep() = %v, want %v", err, context.Canceled)
+	}
+	if err := sleep(ctx, 0); err != nil {
+		t.Errorf("sleep(0) = %v, want nil", err)
+	}
+}
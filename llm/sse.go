@@ -0,0 +1,46 @@
+package llm
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// readEvents reads a server-sent event stream, calling fn with the type and
+// data of each event until the stream ends or fn returns an error or
+// reports done. The type is "" for events without one.
+func readEvents(r io.Reader, fn func(event, data string) (done bool, err error)) error {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	var event string
+	var data []string
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if len(data) > 0 {
+				done, err := fn(event, strings.Join(data, "\n"))
+				if err != nil || done {
+					return err
+				}
+			}
+			event, data = "", nil
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		_, err := fn(event, strings.Join(data, "\n"))
+		return err
+	}
+	return nil
+}
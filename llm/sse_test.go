@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadEvents_Stream(t *testing.T) {
+	type event struct{ Event, Data string }
+	tests := []struct {
+		name   string
+		stream string
+		want   []event
+	}{
+		{
+			name:   "typed and untyped",
+			stream: "event: a\ndata: 1\n\ndata: 2\n\n",
+			want:   []event{{"a", "1"}, {"", "2"}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: line 1\ndata: line 2\n\n",
+			want:   []event{{"", "line 1\nline 2"}},
+		},
+		{
+			name:   "comments, ids and blank runs",
+			stream: ": keep-alive\n\n\nid: 7\ndata:no space\nretry: 100\n\n",
+			want:   []event{{"", "no space"}},
+		},
+		{
+			name:   "last event without a blank line",
+			stream: "data: 1\n\ndata: 2",
+			want:   []event{{"", "1"}, {"", "2"}},
+		},
+		{
+			name:   "stops when done",
+			stream: "data: 1\n\ndata: done\n\ndata: 3\n\n",
+			want:   []event{{"", "1"}, {"", "done"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []event
+			err := readEvents(strings.NewReader(tt.stream), func(ev, data string) (bool, error) {
+				got = append(got, event{ev, data})
+				return data == "done", nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEvents_Error(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := readEvents(strings.NewReader("data: 1\n\ndata: 2\n\n"), func(string, string) (bool, error) {
+		calls++
+		return false, stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("readEvents() = %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
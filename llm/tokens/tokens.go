@@ -0,0 +1,120 @@
+// Package tokens counts the tokens a review consumes and prices them.
+//
+// Providers bill by token, and each reports the tokens of a completed
+// request, so Count uses those figures. Before a request is sent, or when a
+// provider reports none, Estimate approximates the count from the length of
+// the text. The providers' tokenizers aren't public for every model, so no
+// estimate is exact. Lookup knows the list prices of common models. Prices
+// change, so callers should let users override them.
+package tokens
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm"
+)
+
+// Price is what a model costs, in US dollars per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// Cost returns the price of the tokens u counts, in US dollars.
+func (p Price) Cost(u llm.Usage) float64 {
+	return (float64(u.InputTokens)*p.Input + float64(u.OutputTokens)*p.Output) / 1e6
+}
+
+// listPrices holds the list prices of models by provider, keyed by model
+// name prefix so that dated snapshots, such as claude-sonnet-4-5-20250929,
+// match their model. The longest matching prefix wins.
+var listPrices = map[string]map[string]Price{
+	llm.Anthropic: {
+		"claude-opus-4-5":   {Input: 5, Output: 25},
+		"claude-opus-4":     {Input: 15, Output: 75},
+		"claude-sonnet-4":   {Input: 3, Output: 15},
+		"claude-3-7-sonnet": {Input: 3, Output: 15},
+		"claude-haiku-4-5":  {Input: 1, Output: 5},
+		"claude-3-5-haiku":  {Input: 0.8, Output: 4},
+	},
+	llm.OpenAI: {
+		"gpt-5":        {Input: 1.25, Output: 10},
+		"gpt-5-mini":   {Input: 0.25, Output: 2},
+		"gpt-5-nano":   {Input: 0.05, Output: 0.4},
+		"gpt-4.1":      {Input: 2, Output: 8},
+		"gpt-4.1-mini": {Input: 0.4, Output: 1.6},
+		"gpt-4.1-nano": {Input: 0.1, Output: 0.4},
+		"gpt-4o":       {Input: 2.5, Output: 10},
+		"gpt-4o-mini":  {Input: 0.15, Output: 0.6},
+		"o4-mini":      {Input: 1.1, Output: 4.4},
+	},
+}
+
+// Lookup returns the list price of model on provider. Models run locally
+// with Ollama cost nothing.
+func Lookup(provider, model string) (Price, bool) {
+	if provider == llm.Ollama {
+		return Price{}, true
+	}
+	best := ""
+	for prefix := range listPrices[provider] {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return listPrices[provider][best], true
+}
+
+// charsPerToken is about how many characters of English prose and Go
+// source a token of each provider's tokenizers covers.
+var charsPerToken = map[string]float64{
+	llm.OpenAI:    4,
+	llm.Anthropic: 3.5,
+	llm.Ollama:    3.8,
+}
+
+// messageOverhead approximates the tokens that frame each message, such as
+// its role.
+const messageOverhead = 4
+
+// Estimate approximates the tokens text takes in provider's models.
+func Estimate(provider, text string) int {
+	if text == "" {
+		return 0
+	}
+	ratio, ok := charsPerToken[provider]
+	if !ok {
+		ratio = 4
+	}
+	return int(math.Ceil(float64(utf8.RuneCountInString(text)) / ratio))
+}
+
+// EstimateRequest approximates the input tokens of req in provider's models.
+func EstimateRequest(provider string, req llm.Request) int {
+	n := 0
+	if req.System != "" {
+		n += Estimate(provider, req.System) + messageOverhead
+	}
+	for _, m := range req.Messages {
+		n += Estimate(provider, m.Content) + messageOverhead
+	}
+	return n
+}
+
+// Count returns the tokens that answering req with resp consumed: as the
+// provider billed them, or estimated where it reported none.
+func Count(provider string, req llm.Request, resp llm.Response) llm.Usage {
+	u := resp.Usage
+	if u.InputTokens == 0 {
+		u.InputTokens = EstimateRequest(provider, req)
+	}
+	if u.OutputTokens == 0 {
+		u.OutputTokens = Estimate(provider, resp.Text)
+	}
+	return u
+}
@@ -0,0 +1,94 @@
+package tokens
+
+import (
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/llm"
+)
+
+func TestLookup_Models(t *testing.T) {
+	tests := []struct {
+		provider, model string
+		want            Price
+		ok              bool
+	}{
+		{llm.Anthropic, "claude-sonnet-4-5-20250929", Price{Input: 3, Output: 15}, true},
+		{llm.Anthropic, "claude-opus-4-1", Price{Input: 15, Output: 75}, true},
+		{llm.Anthropic, "claude-opus-4-5-20251101", Price{Input: 5, Output: 25}, true},
+		{llm.OpenAI, "gpt-5-mini-2025-08-07", Price{Input: 0.25, Output: 2}, true},
+		{llm.OpenAI, "gpt-5", Price{Input: 1.25, Output: 10}, true},
+		{llm.OpenAI, "gpt-4o-mini", Price{Input: 0.15, Output: 0.6}, true},
+		{llm.Ollama, "llama3.1:70b", Price{}, true},
+		{llm.OpenAI, "claude-sonnet-4-5", Price{}, false},
+		{llm.Anthropic, "claude-2", Price{}, false},
+		{"bard", "gpt-5", Price{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := Lookup(tt.provider, tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Lookup(%q, %q) = %+v, %v; want %+v, %v", tt.provider, tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestPrice_Cost(t *testing.T) {
+	p := Price{Input: 3, Output: 15}
+	if got, want := p.Cost(llm.Usage{InputTokens: 2_000_000, OutputTokens: 100_000}), 7.5; got != want {
+		t.Errorf("Cost() = %v, want %v", got, want)
+	}
+}
+
+func TestEstimate_Text(t *testing.T) {
+	tests := []struct {
+		provider, text string
+		want           int
+	}{
+		{llm.OpenAI, "", 0},
+		{llm.OpenAI, "abcd", 1},
+		{llm.OpenAI, "abcde", 2},
+		{llm.Anthropic, "abcdefg", 2},
+		{llm.Anthropic, "abcdefgh", 3},
+		{"unknown", "abcdefgh", 2},
+		// Runes, not bytes, are counted.
+		{llm.OpenAI, "ééééé", 2},
+	}
+	for _, tt := range tests {
+		if got := Estimate(tt.provider, tt.text); got != tt.want {
+			t.Errorf("Estimate(%q, %q) = %d, want %d", tt.provider, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateRequest_Messages(t *testing.T) {
+	req := llm.Request{
+		System:   "abcd",
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: "abcdefgh"}, {Role: llm.RoleAssistant, Content: ""}},
+	}
+	// 1 + 2 + 0 tokens of text and 3 messages' framing.
+	if got, want := EstimateRequest(llm.OpenAI, req), 3+3*messageOverhead; got != want {
+		t.Errorf("EstimateRequest() = %d, want %d", got, want)
+	}
+	if got := EstimateRequest(llm.OpenAI, llm.Request{}); got != 0 {
+		t.Errorf("EstimateRequest() of an empty request = %d, want 0", got)
+	}
+}
+
+func TestCount_Usage(t *testing.T) {
+	req := llm.UserPrompt("abcdefgh")
+	tests := []struct {
+		name string
+		resp llm.Response
+		want llm.Usage
+	}{
+		{"billed", llm.Response{Text: "abcd", Usage: llm.Usage{InputTokens: 100, OutputTokens: 7}}, llm.Usage{InputTokens: 100, OutputTokens: 7}},
+		{"none billed", llm.Response{Text: "abcd"}, llm.Usage{InputTokens: 2 + messageOverhead, OutputTokens: 1}},
+		{"output only", llm.Response{Text: "abcd", Usage: llm.Usage{OutputTokens: 7}}, llm.Usage{InputTokens: 2 + messageOverhead, OutputTokens: 7}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Count(llm.OpenAI, req, tt.resp); got != tt.want {
+				t.Errorf("Count() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
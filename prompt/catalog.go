@@ -0,0 +1,268 @@
+package prompt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"golang.org/x/mod/semver"
+)
+
+// Entry is one version of a prompt.
+type Entry struct {
+	Name    string `json:"name"`    // such as standards-compliance/review
+	Version string `json:"version"` // semantic version, such as v1.2.0
+	Path    string `json:"path"`    // template file, relative to the catalog root
+	// Hash is the hex SHA-256 of everything the rendered prompt depends on
+	// besides its Data: the template, the partials it uses and the files it
+	// includes by literal path. Editing a shared partial changes the hash
+	// of every version that uses it.
+	Hash string `json:"hash"`
+}
+
+// Ref returns the reference that pins e: name@version.
+func (e Entry) Ref() string {
+	return e.Name + "@" + e.Version
+}
+
+// Catalog is a loaded collection of prompt templates and partials.
+type Catalog struct {
+	fsys     fs.FS
+	tmpl     *template.Template
+	entries  map[string][]Entry // by name, in ascending version order
+	partials map[string]string  // partial name to path
+}
+
+// Load parses every template and partial beneath the root of fsys and
+// indexes the prompts. Templates may read other files of fsys with include.
+func Load(fsys fs.FS) (*Catalog, error) {
+	c := &Catalog{fsys: fsys, entries: make(map[string][]Entry), partials: make(map[string]string)}
+	c.tmpl = template.New("").Option("missingkey=error").Funcs(template.FuncMap{
+		"include": c.include,
+		"section": Section,
+		"fence":   Fence,
+	})
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != Ext {
+			return err
+		}
+		src, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		var name string
+		if base, ok := strings.CutPrefix(path.Base(strings.TrimSuffix(p, Ext)), "_"); ok {
+			if other, dup := c.partials[base]; dup {
+				return fmt.Errorf("partial %q defined by both %s and %s", base, other, p)
+			}
+			c.partials[base] = p
+			name = base
+		} else {
+			e, err := parseEntry(p)
+			if err != nil {
+				return err
+			}
+			c.entries[e.Name] = append(c.entries[e.Name], e)
+			name = e.Ref()
+		}
+		if _, err := c.tmpl.New(name).Parse(string(src)); err != nil {
+			return fmt.Errorf("parsing template: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("loading prompt templates: %w", err)
+	}
+	for name, es := range c.entries {
+		sort.Slice(es, func(i, j int) bool { return semver.Compare(es[i].Version, es[j].Version) < 0 })
+		for i := range es {
+			if i > 0 && semver.Compare(es[i-1].Version, es[i].Version) == 0 {
+				return nil, fmt.Errorf("loading prompt templates: %s and %s are the same version", es[i-1].Path, es[i].Path)
+			}
+			if es[i].Hash, err = c.hash(es[i]); err != nil {
+				return nil, fmt.Errorf("hashing %s: %w", es[i].Ref(), err)
+			}
+		}
+		c.entries[name] = es
+	}
+	return c, nil
+}
+
+// parseEntry returns the prompt version held by the template file at p,
+// which is named name@version.tmpl.
+func parseEntry(p string) (Entry, error) {
+	name, version, ok := strings.Cut(strings.TrimSuffix(p, Ext), "@")
+	if !ok {
+		return Entry{}, fmt.Errorf("%s: template file name needs a version, as %s@v1.0.0%s", p, path.Base(name), Ext)
+	}
+	if !semver.IsValid(version) || semver.Canonical(version) != version {
+		return Entry{}, fmt.Errorf("%s: %q is not a full semantic version such as v1.0.0", p, version)
+	}
+	return Entry{Name: name, Version: version, Path: p}, nil
+}
+
+// Entries returns every prompt version, ordered by name and then version.
+func (c *Catalog) Entries() []Entry {
+	var all []Entry
+	for _, es := range c.entries {
+		all = append(all, es...)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Name != all[j].Name {
+			return all[i].Name < all[j].Name
+		}
+		return semver.Compare(all[i].Version, all[j].Version) < 0
+	})
+	return all
+}
+
+// Lookup resolves ref to a prompt version. ref is a prompt name, optionally
+// pinned with @ to a full version (name@v1.2.0) or to the latest release of
+// a major or minor version (name@v1, name@v1.2). An unpinned name resolves
+// to the latest release, or the latest pre-release if there is none.
+func (c *Catalog) Lookup(ref string) (Entry, error) {
+	name, want, pinned := strings.Cut(ref, "@")
+	es := c.entries[name]
+	if len(es) == 0 {
+		return Entry{}, fmt.Errorf("no prompt %q", name)
+	}
+	if !pinned {
+		for i := len(es) - 1; i >= 0; i-- {
+			if semver.Prerelease(es[i].Version) == "" {
+				return es[i], nil
+			}
+		}
+		return es[len(es)-1], nil
+	}
+	if !semver.IsValid(want) {
+		return Entry{}, fmt.Errorf("%s: %q is not a semantic version", ref, want)
+	}
+	var match func(v string) bool
+	switch strings.Count(strings.SplitN(want, "-", 2)[0], ".") {
+	case 0:
+		match = func(v string) bool { return semver.Major(v) == want && semver.Prerelease(v) == "" }
+	case 1:
+		match = func(v string) bool { return semver.MajorMinor(v) == want && semver.Prerelease(v) == "" }
+	default:
+		match = func(v string) bool { return v == want }
+	}
+	for i := len(es) - 1; i >= 0; i-- {
+		if match(es[i].Version) {
+			return es[i], nil
+		}
+	}
+	return Entry{}, fmt.Errorf("no version of prompt %q matches %s", name, want)
+}
+
+// Render resolves ref with Lookup and writes that prompt version, executed
+// with data, to w. It returns the version rendered, for the run to record.
+// Line endings are normalized to \n and the output ends with exactly one
+// newline.
+func (c *Catalog) Render(w io.Writer, ref string, data Data) (Entry, error) {
+	e, err := c.Lookup(ref)
+	if err != nil {
+		return Entry{}, err
+	}
+	var buf bytes.Buffer
+	if err := c.tmpl.Lookup(e.Ref()).Execute(&buf, data); err != nil {
+		return Entry{}, fmt.Errorf("rendering %s: %w", e.Ref(), err)
+	}
+	out := strings.ReplaceAll(buf.String(), "\r\n", "\n")
+	out = strings.TrimRight(out, " \t\n") + "\n"
+	if _, err := io.WriteString(w, out); err != nil {
+		return Entry{}, err
+	}
+	return e, nil
+}
+
+// include returns the content of the file at the slash-separated path p
+// beneath the template root.
+func (c *Catalog) include(p string) (string, error) {
+	data, err := fs.ReadFile(c.fsys, p)
+	if err != nil {
+		return "", fmt.Errorf("including %s: %w", p, err)
+	}
+	return string(data), nil
+}
+
+// hash computes the Hash of e from the files its template depends on.
+func (c *Catalog) hash(e Entry) (string, error) {
+	files := map[string]bool{e.Path: true}
+	seen := make(map[string]bool)
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n != nil {
+				for _, n := range n.Nodes {
+					walk(n)
+				}
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.PipeNode:
+			if n != nil {
+				for _, cmd := range n.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			if len(n.Args) == 2 {
+				id, ok1 := n.Args[0].(*parse.IdentifierNode)
+				s, ok2 := n.Args[1].(*parse.StringNode)
+				if ok1 && ok2 && id.Ident == "include" {
+					files[s.Text] = true
+				}
+			}
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+			if p, ok := c.partials[n.Name]; ok {
+				files[p] = true
+			}
+			if t := c.tmpl.Lookup(n.Name); t != nil && !seen[n.Name] {
+				seen[n.Name] = true
+				walk(t.Tree.Root)
+			}
+		}
+	}
+	walk(c.tmpl.Lookup(e.Ref()).Tree.Root)
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, p := range paths {
+		data, err := fs.ReadFile(c.fsys, p)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s %d\n", p, len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
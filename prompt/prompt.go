@@ -0,0 +1,102 @@
+// Package prompt renders the AI review prompts from templates.
+//
+// Templates are text/template files with the extension .tmpl, found anywhere
+// beneath a root directory such as docs/prompts. Each is one version of a
+// prompt, named by its slash-separated path and a semantic version:
+// standards-compliance/review@v1.0.0.tmpl is version v1.0.0 of the prompt
+// standards-compliance/review. Files whose name starts with an underscore
+// are partials, unversioned sections shared between prompts, named by their
+// base name without the underscore and extension and included with
+// {{template "findings-output" .}}.
+//
+// A Catalog indexes the prompts by name and version and records a content
+// hash for each, so a review run can pin the version it renders and its
+// findings can be traced to the exact prompt revision that produced them.
+// Rendering is deterministic: the same templates and Data always produce the
+// same bytes, so prompts can be diffed, cached and replayed.
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ext is the extension of template files.
+const Ext = ".tmpl"
+
+// Data holds the variables a template can refer to.
+type Data struct {
+	File      string            // path of the file under review, slash-separated
+	Content   string            // content of the file under review
+	Standards string            // the standards text, or an excerpt of it
+	Diff      string            // unified diff of the changes under review
+	Schema    string            // JSON schema the findings must follow
+	Vars      map[string]string // further variables, by name
+}
+
+// Section returns the Markdown section of text under the heading whose text
+// is heading, from the heading line up to the next heading of the same or a
+// higher level, without trailing newlines. It is an error if there is no
+// such heading.
+func Section(text, heading string) (string, error) {
+	lines := strings.SplitAfter(text, "\n")
+	start, level := -1, 0
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		n, title := headingOf(line)
+		if n == 0 {
+			continue
+		}
+		if start < 0 {
+			if title == heading {
+				start, level = i, n
+			}
+			continue
+		}
+		if n <= level {
+			return strings.TrimRight(strings.Join(lines[start:i], ""), "\n"), nil
+		}
+	}
+	if start < 0 {
+		return "", fmt.Errorf("no section %q", heading)
+	}
+	return strings.TrimRight(strings.Join(lines[start:], ""), "\n"), nil
+}
+
+// headingOf returns the level and text of the ATX heading line, or 0.
+func headingOf(line string) (int, string) {
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	if n == 0 || n > 6 || n == len(line) || (line[n] != ' ' && line[n] != '\n') {
+		return 0, ""
+	}
+	return n, strings.TrimSpace(line[n:])
+}
+
+// Fence wraps text in a Markdown code fence for language lang. The fence is
+// longer than any run of backticks in text, so the content can't close it.
+func Fence(lang, text string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return fence + lang + "\n" + text + fence
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// BaselineVersion is the format version written to baseline files.
+const BaselineVersion = 1
+
+// Baseline is a snapshot of accepted findings. Findings whose fingerprint
+// appears in the baseline are known debt and do not fail the build; only
+// new findings do.
+type Baseline struct {
+	Version  int       `json:"version"`
+	Findings []Finding `json:"findings"`
+}
+
+// NewBaseline returns a baseline accepting findings, which must have
+// fingerprints set.
+func NewBaseline(findings []Finding) *Baseline {
+	b := &Baseline{Version: BaselineVersion, Findings: findings}
+	if b.Findings == nil {
+		b.Findings = []Finding{}
+	}
+	return b
+}
+
+// ReadBaseline reads the baseline file at path. A missing file yields an
+// empty baseline.
+func ReadBaseline(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewBaseline(nil), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parsing baseline %s: %w", path, err)
+	}
+	if b.Version != BaselineVersion {
+		return nil, fmt.Errorf("baseline %s: unsupported version %d", path, b.Version)
+	}
+	return &b, nil
+}
+
+// Write writes the baseline as indented JSON.
+func (b *Baseline) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(b)
+}
+
+// Filter splits findings into those not in the baseline and those it
+// accepts. Findings are matched by fingerprint, so baselined findings stay
+// accepted when surrounding code moves.
+func (b *Baseline) Filter(findings []Finding) (fresh, known []Finding) {
+	accepted := make(map[string]bool, len(b.Findings))
+	for _, f := range b.Findings {
+		accepted[f.Fingerprint] = true
+	}
+	for _, f := range findings {
+		if accepted[f.Fingerprint] {
+			known = append(known, f)
+		} else {
+			fresh = append(fresh, f)
+		}
+	}
+	return fresh, known
+}
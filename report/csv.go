@@ -0,0 +1,35 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// CSV reports one finding per row, after a header row, for spreadsheets and
+// BI tools that track findings across runs.
+type CSV struct{}
+
+// csvHeader names the columns of a CSV report.
+var csvHeader = []string{"file", "line", "column", "package", "rule", "severity", "message", "fingerprint"}
+
+// Report writes the findings to w as CSV. A finding without a severity is
+// written as an error, which it counts as. The rules are not written.
+func (CSV) Report(w io.Writer, _ []Rule, findings []Finding) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, f := range findings {
+		severity := f.Severity
+		if severity == "" {
+			severity = SeverityError
+		}
+		row := []string{f.File, strconv.Itoa(f.Line), strconv.Itoa(f.Column), f.Package(), f.Rule, string(severity), f.Message, f.Fingerprint}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,34 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCSV_Report(t *testing.T) {
+	const header = "file,line,column,package,rule,severity,message,fingerprint\n"
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     string
+	}{
+		{"none", nil, header},
+		{"findings", testFindings, header +
+			"svc/a.go,3,6,svc,godoc,error,exported function A has no doc comment,f1\n" +
+			"svc/b.go,7,1,svc,godoc,warn,exported type <B> has no doc comment,f2\n" +
+			"main.go,1,0,.,review,info,looks off,\n"},
+		{"quoting", []Finding{{Rule: "x", File: "a.go", Line: 1, Message: `say "hi", then leave`}}, header +
+			"a.go,1,0,.,x,error,\"say \"\"hi\"\", then leave\",\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (CSV{}).Report(&buf, testRules, tt.findings); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Report() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
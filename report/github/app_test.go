@@ -0,0 +1,65 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestApp_Token(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/app/installations/42/access_tokens" {
+			http.NotFound(w, r)
+			return
+		}
+		// The bearer token is a JWT the app's key signed, issued by the app.
+		jwt, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		parts := strings.Split(jwt, ".")
+		if len(parts) != 3 {
+			http.Error(w, "malformed JWT", http.StatusUnauthorized)
+			return
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		var claims struct {
+			Iss      string
+			Iat, Exp int64
+		}
+		data, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		json.Unmarshal(data, &claims)
+		if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig) != nil || claims.Iss != "7" || claims.Exp-claims.Iat != 600 {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"token": "ghs_installation"}`)
+	}))
+	defer srv.Close()
+
+	token, err := App{ID: 7, InstallationID: 42, PrivateKey: pemKey}.Token(context.Background(), srv.URL)
+	if err != nil || token != "ghs_installation" {
+		t.Errorf("Token() = %q, %v; want ghs_installation", token, err)
+	}
+	if _, err := (App{ID: 8, InstallationID: 42, PrivateKey: pemKey}).Token(context.Background(), srv.URL); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Token() for another app = %v, want a 401 error", err)
+	}
+	if _, err := (App{ID: 7, InstallationID: 42, PrivateKey: []byte("not a key")}).Token(context.Background(), srv.URL); err == nil || !strings.Contains(err.Error(), "not PEM") {
+		t.Errorf("Token() with a bad key = %v, want a PEM error", err)
+	}
+}
@@ -0,0 +1,208 @@
+// Package github publishes findings to GitHub as a check run, whose
+// annotations GitHub shows inline on the pull request diff.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+// DefaultAPIURL is the REST API of github.com.
+const DefaultAPIURL = "https://api.github.com"
+
+// maxAnnotations is the most annotations the API accepts per request; more
+// are added by updating the check run.
+const maxAnnotations = 50
+
+// Client calls the GitHub REST API with a token: a personal access token,
+// the GITHUB_TOKEN of an Actions run, or a GitHub App installation token
+// from App.Token.
+type Client struct {
+	APIURL string // DefaultAPIURL if empty
+	Token  string
+	HTTP   *http.Client // http.DefaultClient if nil
+}
+
+// CheckRun describes the check run to create.
+type CheckRun struct {
+	Repo    string // owner/name
+	HeadSHA string // the commit the findings are for
+	Name    string
+	Rules   []report.Rule
+}
+
+type output struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Annotations []annotation `json:"annotations"`
+}
+
+type annotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title"`
+	Message         string `json:"message"`
+}
+
+type checkRunResponse struct {
+	ID      int64  `json:"id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Publish creates a completed check run reporting findings, and returns its
+// URL. The run concludes with failure if any finding fails the build,
+// neutral if there are only warn and info findings, and success otherwise.
+func (c *Client) Publish(ctx context.Context, run CheckRun, findings []report.Finding) (string, error) {
+	out := output{Title: title(findings), Summary: summary(run.Rules, findings)}
+	anns := annotations(findings)
+	first := anns[:min(len(anns), maxAnnotations)]
+	out.Annotations = first
+
+	body := map[string]any{
+		"name":       run.Name,
+		"head_sha":   run.HeadSHA,
+		"status":     "completed",
+		"conclusion": conclusion(findings),
+		"output":     out,
+	}
+	var created checkRunResponse
+	if err := c.do(ctx, http.MethodPost, "/repos/"+run.Repo+"/check-runs", body, &created); err != nil {
+		return "", fmt.Errorf("creating check run: %w", err)
+	}
+	for rest := anns[len(first):]; len(rest) > 0; {
+		n := min(len(rest), maxAnnotations)
+		out.Annotations = rest[:n]
+		rest = rest[n:]
+		path := fmt.Sprintf("/repos/%s/check-runs/%d", run.Repo, created.ID)
+		if err := c.do(ctx, http.MethodPatch, path, map[string]any{"output": out}, nil); err != nil {
+			return created.HTMLURL, fmt.Errorf("adding annotations: %w", err)
+		}
+	}
+	return created.HTMLURL, nil
+}
+
+func annotations(findings []report.Finding) []annotation {
+	anns := make([]annotation, 0, len(findings))
+	for _, f := range findings {
+		anns = append(anns, annotation{
+			Path:            f.File,
+			StartLine:       f.Line,
+			EndLine:         f.Line,
+			AnnotationLevel: level(f),
+			Title:           f.Rule,
+			Message:         f.Message,
+		})
+	}
+	return anns
+}
+
+// level maps a finding's severity to an annotation level.
+func level(f report.Finding) string {
+	switch {
+	case f.Failing():
+		return "failure"
+	case f.Severity == report.SeverityWarn:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+func conclusion(findings []report.Finding) string {
+	result := "success"
+	for _, f := range findings {
+		if f.Failing() {
+			return "failure"
+		}
+		result = "neutral"
+	}
+	return result
+}
+
+func title(findings []report.Finding) string {
+	if len(findings) == 0 {
+		return "No findings"
+	}
+	return fmt.Sprintf("%d finding(s)", len(findings))
+}
+
+// summary tabulates the findings by rule, in Markdown.
+func summary(rules []report.Rule, findings []report.Finding) string {
+	if len(findings) == 0 {
+		return "All checked code meets the standards."
+	}
+	counts := make(map[string]int)
+	for _, f := range findings {
+		counts[f.Rule]++
+	}
+	var sb strings.Builder
+	sb.WriteString("| Rule | Findings | Checks |\n| --- | ---: | --- |\n")
+	listed := make(map[string]bool)
+	for _, r := range rules {
+		if counts[r.ID] > 0 {
+			fmt.Fprintf(&sb, "| `%s` | %d | %s |\n", r.ID, counts[r.ID], r.Summary)
+			listed[r.ID] = true
+		}
+	}
+	// Findings from sources without rule descriptions, such as the AI review.
+	for _, f := range findings {
+		if !listed[f.Rule] {
+			fmt.Fprintf(&sb, "| `%s` | %d | |\n", f.Rule, counts[f.Rule])
+			listed[f.Rule] = true
+		}
+	}
+	return sb.String()
+}
+
+// do sends a JSON request to the API and decodes the JSON response into
+// out, if non-nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	base := c.APIURL
+	if base == "" {
+		base = DefaultAPIURL
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(base, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
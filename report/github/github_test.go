@@ -0,0 +1,204 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+// request is a request the fake API received.
+type request struct {
+	method, path string
+	header       http.Header
+	body         map[string]any
+}
+
+// fakeAPI answers check run requests with the statuses in fail, keyed by
+// method, and 2xx otherwise, and records what it receives.
+type fakeAPI struct {
+	mu       sync.Mutex
+	requests []request
+	fail     map[string]int
+}
+
+func (f *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	data, _ := io.ReadAll(r.Body)
+	json.Unmarshal(data, &body)
+	f.mu.Lock()
+	f.requests = append(f.requests, request{r.Method, r.URL.Path, r.Header.Clone(), body})
+	f.mu.Unlock()
+	if code := f.fail[r.Method]; code != 0 {
+		http.Error(w, `{"message": "Validation Failed"}`, code)
+		return
+	}
+	if r.Method == http.MethodPost {
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"id": 7, "html_url": "https://github.example/o/r/runs/7"}`)
+		return
+	}
+	io.WriteString(w, `{"id": 7}`)
+}
+
+// outputOf returns the output member of a request body.
+func outputOf(t *testing.T, r request) (title string, annotations []any) {
+	t.Helper()
+	out, ok := r.body["output"].(map[string]any)
+	if !ok {
+		t.Fatalf("%s %s has no output: %v", r.method, r.path, r.body)
+	}
+	anns, ok := out["annotations"].([]any)
+	if !ok {
+		t.Fatalf("%s %s output has no annotations array: %v", r.method, r.path, out)
+	}
+	title, _ = out["title"].(string)
+	return title, anns
+}
+
+func findingsN(n int, severity report.Severity) []report.Finding {
+	findings := make([]report.Finding, n)
+	for i := range findings {
+		findings[i] = report.Finding{Rule: "godoc", File: "svc/a.go", Line: i + 1, Message: fmt.Sprintf("m%d", i), Severity: severity}
+	}
+	return findings
+}
+
+func TestClient_Publish(t *testing.T) {
+	api := &fakeAPI{}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	c := &Client{APIURL: srv.URL + "/", Token: "t0ken"}
+	run := CheckRun{Repo: "o/r", HeadSHA: "abc123", Name: "stdcheck", Rules: []report.Rule{{ID: "godoc", Summary: "documented"}}}
+	findings := append(findingsN(119, report.SeverityWarn), report.Finding{Rule: "review", File: "main.go", Line: 1, Message: "looks off"})
+
+	url, err := c.Publish(context.Background(), run, findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://github.example/o/r/runs/7" {
+		t.Errorf("Publish() = %q, want the run's URL", url)
+	}
+
+	// 120 annotations take a create and two updates of at most 50 each.
+	if len(api.requests) != 3 {
+		t.Fatalf("API received %d requests, want 3", len(api.requests))
+	}
+	create := api.requests[0]
+	if create.method != http.MethodPost || create.path != "/repos/o/r/check-runs" {
+		t.Errorf("first request = %s %s, want POST /repos/o/r/check-runs", create.method, create.path)
+	}
+	for k, want := range map[string]any{"name": "stdcheck", "head_sha": "abc123", "status": "completed", "conclusion": "failure"} {
+		if create.body[k] != want {
+			t.Errorf("create %s = %v, want %v", k, create.body[k], want)
+		}
+	}
+	for k, want := range map[string]string{"Authorization": "Bearer t0ken", "Accept": "application/vnd.github+json", "Content-Type": "application/json", "X-Github-Api-Version": "2022-11-28"} {
+		if got := create.header.Get(k); got != want {
+			t.Errorf("header %s = %q, want %q", k, got, want)
+		}
+	}
+	title, anns := outputOf(t, create)
+	if title != "120 finding(s)" || len(anns) != maxAnnotations {
+		t.Errorf("create output = %q with %d annotations, want 120 finding(s) with %d", title, len(anns), maxAnnotations)
+	}
+	want := map[string]any{"path": "svc/a.go", "start_line": 1.0, "end_line": 1.0, "annotation_level": "warning", "title": "godoc", "message": "m0"}
+	if got := anns[0].(map[string]any); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("first annotation = %v, want %v", got, want)
+	}
+
+	for i, n := range []int{50, 20} {
+		update := api.requests[i+1]
+		if update.method != http.MethodPatch || update.path != "/repos/o/r/check-runs/7" {
+			t.Errorf("update %d = %s %s, want PATCH /repos/o/r/check-runs/7", i+1, update.method, update.path)
+		}
+		if _, anns := outputOf(t, update); len(anns) != n {
+			t.Errorf("update %d has %d annotations, want %d", i+1, len(anns), n)
+		}
+	}
+	_, last := outputOf(t, api.requests[2])
+	if got := last[len(last)-1].(map[string]any); got["annotation_level"] != "failure" || got["path"] != "main.go" {
+		t.Errorf("last annotation = %v, want the failing finding in main.go", got)
+	}
+}
+
+func TestClient_Publish_Conclusion(t *testing.T) {
+	tests := []struct {
+		name       string
+		findings   []report.Finding
+		conclusion string
+		title      string
+	}{
+		{"none", nil, "success", "No findings"},
+		{"warn and info", append(findingsN(1, report.SeverityWarn), findingsN(1, report.SeverityInfo)...), "neutral", "2 finding(s)"},
+		{"error", findingsN(1, report.SeverityError), "failure", "1 finding(s)"},
+		{"exactly a batch", findingsN(maxAnnotations, report.SeverityInfo), "neutral", "50 finding(s)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{}
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+			c := &Client{APIURL: srv.URL, Token: "t"}
+			if _, err := c.Publish(context.Background(), CheckRun{Repo: "o/r", HeadSHA: "abc", Name: "stdcheck"}, tt.findings); err != nil {
+				t.Fatal(err)
+			}
+			if len(api.requests) != 1 {
+				t.Fatalf("API received %d requests, want only the create", len(api.requests))
+			}
+			title, anns := outputOf(t, api.requests[0])
+			if got := api.requests[0].body["conclusion"]; got != tt.conclusion || title != tt.title || len(anns) != len(tt.findings) {
+				t.Errorf("create = %v, %q with %d annotations; want %v, %q with %d", got, title, len(anns), tt.conclusion, tt.title, len(tt.findings))
+			}
+		})
+	}
+}
+
+func TestClient_Publish_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    map[string]int
+		wantURL string
+		wantErr []string
+	}{
+		{"create rejected", map[string]int{http.MethodPost: http.StatusUnprocessableEntity}, "", []string{"creating check run", "POST /repos/o/r/check-runs: 422 Unprocessable Entity", "Validation Failed"}},
+		{"forbidden", map[string]int{http.MethodPost: http.StatusForbidden}, "", []string{"creating check run", "403 Forbidden"}},
+		{"update failed", map[string]int{http.MethodPatch: http.StatusBadGateway}, "https://github.example/o/r/runs/7", []string{"adding annotations", "PATCH /repos/o/r/check-runs/7: 502 Bad Gateway"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeAPI{fail: tt.fail}
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+			c := &Client{APIURL: srv.URL, Token: "t"}
+			url, err := c.Publish(context.Background(), CheckRun{Repo: "o/r", HeadSHA: "abc", Name: "stdcheck"}, findingsN(60, report.SeverityError))
+			if url != tt.wantURL {
+				t.Errorf("Publish() URL = %q, want %q", url, tt.wantURL)
+			}
+			if err == nil {
+				t.Fatal("Publish() succeeded")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Publish() error = %v, want it to say %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSummary_Rules(t *testing.T) {
+	rules := []report.Rule{{ID: "nildeps", Summary: "checked"}, {ID: "godoc", Summary: "documented"}}
+	findings := []report.Finding{{Rule: "review"}, {Rule: "godoc"}, {Rule: "godoc"}}
+	want := "| Rule | Findings | Checks |\n| --- | ---: | --- |\n| `godoc` | 2 | documented |\n| `review` | 1 | |\n"
+	if got := summary(rules, findings); got != want {
+		t.Errorf("summary() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"html/template"
+	"io"
+)
+
+// HTML reports findings as a standalone HTML page grouped by rule and then
+// by package.
+type HTML struct{}
+
+type htmlRule struct {
+	Rule     Rule
+	Count    int
+	Packages []htmlPackage
+}
+
+type htmlPackage struct {
+	Path     string
+	Findings []Finding
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Standards compliance report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { border-bottom: 1px solid #ccc; padding-bottom: .2em; }
+h3 { font-family: monospace; font-weight: normal; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 1em; }
+td { border-top: 1px solid #eee; padding: .3em .6em; vertical-align: top; }
+td.loc { font-family: monospace; white-space: nowrap; }
+pre { background: #f6f8fa; padding: .8em; white-space: pre-wrap; }
+.clean { color: #1a7f37; }
+.count { color: #cf222e; }
+.sev { color: #9a6700; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>Standards compliance report</h1>
+<p>{{.Total}} finding(s) across {{len .Rules}} rule(s).</p>
+{{range .Rules}}
+<h2 id="{{.Rule.ID}}">{{.Rule.ID}} {{if .Count}}<span class="count">({{.Count}})</span>{{else}}<span class="clean">(clean)</span>{{end}}</h2>
+<p>{{.Rule.Summary}}</p>
+{{if .Rule.Help}}<pre>{{.Rule.Help}}</pre>{{end}}
+{{range .Packages}}
+<h3>{{.Path}}</h3>
+<table>
+{{range .Findings}}<tr><td class="loc">{{.File}}:{{.Line}}:{{.Column}}</td><td>{{if not .Failing}}<span class="sev">{{.Severity}}</span> {{end}}{{.Message}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+</body>
+</html>
+`))
+
+// Report writes the page to w. Rules without findings are listed as
+// clean.
+func (HTML) Report(w io.Writer, rules []Rule, findings []Finding) error {
+	var data struct {
+		Total int
+		Rules []htmlRule
+	}
+	data.Total = len(findings)
+	for _, g := range groupByRule(rules, findings) {
+		r := htmlRule{Rule: g.Rule, Count: len(g.Findings)}
+		index := make(map[string]int)
+		for _, f := range g.Findings {
+			pkg := f.Package()
+			i, ok := index[pkg]
+			if !ok {
+				i = len(r.Packages)
+				index[pkg] = i
+				r.Packages = append(r.Packages, htmlPackage{Path: pkg})
+			}
+			r.Packages[i].Findings = append(r.Packages[i].Findings, f)
+		}
+		data.Rules = append(data.Rules, r)
+	}
+	return htmlTemplate.Execute(w, data)
+}
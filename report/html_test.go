@@ -0,0 +1,34 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHTML_Report(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (HTML{}).Report(&buf, testRules, testFindings); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	tests := []struct {
+		name, want string
+	}{
+		{"total", "<p>3 finding(s) across 3 rule(s).</p>"},
+		{"rule with findings", `<h2 id="godoc">godoc <span class="count">(2)</span></h2>`},
+		{"clean rule", `<h2 id="nildeps">nildeps <span class="clean">(clean)</span></h2>`},
+		{"package", "<h3>svc</h3>"},
+		{"location", `<td class="loc">svc/a.go:3:6</td>`},
+		{"severity", `<span class="sev">warn</span>`},
+		{"escaped message", "exported type &lt;B&gt; has no doc comment"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(page, tt.want) {
+			t.Errorf("%s: page lacks %q", tt.name, tt.want)
+		}
+	}
+	if strings.Contains(page, "<B>") {
+		t.Error("page contains an unescaped message")
+	}
+}
@@ -0,0 +1,40 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// JSON reports the rules and findings as a single JSON document for
+// consumption by pipelines.
+type JSON struct{}
+
+type jsonReport struct {
+	Rules    []Rule    `json:"rules"`
+	Findings []Finding `json:"findings"`
+}
+
+// Report writes the rules and findings to w as one indented JSON object.
+func (JSON) Report(w io.Writer, rules []Rule, findings []Finding) error {
+	r := jsonReport{Rules: rules, Findings: findings}
+	if r.Rules == nil {
+		r.Rules = []Rule{}
+	}
+	if r.Findings == nil {
+		r.Findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadJSON reads a report written by JSON, such as one produced by the AI
+// review, and returns its rules and findings.
+func ReadJSON(r io.Reader) ([]Rule, []Finding, error) {
+	var rep jsonReport
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, nil, fmt.Errorf("reading JSON report: %w", err)
+	}
+	return rep.Rules, rep.Findings, nil
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestJSON_Report(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []Rule
+		findings []Finding
+	}{
+		{"empty", nil, nil},
+		{"findings", testRules, testFindings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (JSON{}).Report(&buf, tt.rules, tt.findings); err != nil {
+				t.Fatal(err)
+			}
+			rules, findings, err := ReadJSON(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rules) != len(tt.rules) || len(findings) != len(tt.findings) {
+				t.Fatalf("ReadJSON() = %d rules, %d findings; want %d, %d", len(rules), len(findings), len(tt.rules), len(tt.findings))
+			}
+			if len(tt.findings) > 0 && !reflect.DeepEqual(findings, tt.findings) {
+				t.Errorf("findings = %+v, want %+v", findings, tt.findings)
+			}
+			if len(tt.rules) > 0 && !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("rules = %+v, want %+v", rules, tt.rules)
+			}
+		})
+	}
+
+	// Empty lists are written as [], not null, for consumers that iterate.
+	var buf bytes.Buffer
+	if err := (JSON{}).Report(&buf, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); !strings.Contains(got, `"rules": []`) || !strings.Contains(got, `"findings": []`) {
+		t.Errorf("empty report = %s, want empty lists", got)
+	}
+}
+
+func TestReadJSON_Invalid(t *testing.T) {
+	if _, _, err := ReadJSON(strings.NewReader("{")); err == nil || !strings.Contains(err.Error(), "reading JSON report") {
+		t.Errorf("ReadJSON() error = %v, want a reading error", err)
+	}
+}
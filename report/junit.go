@@ -0,0 +1,118 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// JUnit reports findings as JUnit XML so CI servers such as Jenkins show
+// violations as failed tests. Each rule is a test suite; each finding is a
+// failed test case, and a rule without findings has a single passing case.
+// Findings that don't fail the build (warn and info) are skipped cases.
+type JUnit struct{}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// Report writes the test suites to w as a JUnit XML document.
+func (JUnit) Report(w io.Writer, rules []Rule, findings []Finding) error {
+	byRule := groupByRule(rules, findings)
+	var doc junitSuites
+	for _, r := range byRule {
+		suite := junitSuite{Name: r.Rule.ID}
+		for _, f := range r.Findings {
+			c := junitCase{
+				Name:      fmt.Sprintf("%s:%d", f.File, f.Line),
+				Classname: f.Package(),
+			}
+			if f.Failing() {
+				c.Failure = &junitFailure{
+					Message: f.Message,
+					Type:    f.Rule,
+					Text:    f.String() + "\n" + r.Rule.Summary,
+				}
+				suite.Failures++
+			} else {
+				c.Skipped = &junitSkipped{Message: string(f.Severity) + ": " + f.Message}
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, c)
+		}
+		if len(suite.Cases) == 0 {
+			suite.Cases = []junitCase{{Name: r.Rule.ID, Classname: r.Rule.ID}}
+		}
+		suite.Tests = len(suite.Cases)
+		doc.Suites = append(doc.Suites, suite)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ruleFindings pairs a rule with its findings.
+type ruleFindings struct {
+	Rule     Rule
+	Findings []Finding
+}
+
+// groupByRule groups findings under their rules, in rules order. Findings for
+// rules not listed are grouped under a rule synthesised from their ID, after
+// the listed rules.
+func groupByRule(rules []Rule, findings []Finding) []ruleFindings {
+	groups := make([]ruleFindings, 0, len(rules))
+	index := make(map[string]int, len(rules))
+	for _, r := range rules {
+		index[r.ID] = len(groups)
+		groups = append(groups, ruleFindings{Rule: r})
+	}
+	for _, f := range findings {
+		i, ok := index[f.Rule]
+		if !ok {
+			i = len(groups)
+			index[f.Rule] = i
+			groups = append(groups, ruleFindings{Rule: Rule{ID: f.Rule, Summary: f.Rule}})
+		}
+		groups[i].Findings = append(groups[i].Findings, f)
+	}
+	return groups
+}
@@ -0,0 +1,48 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestJUnit_Report(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (JUnit{}).Report(&buf, testRules, testFindings); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("Report() does not start with the XML header:\n%s", buf.String())
+	}
+	var doc junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Tests != 4 || doc.Failures != 1 {
+		t.Errorf("tests, failures = %d, %d; want 4, 1", doc.Tests, doc.Failures)
+	}
+
+	tests := []struct {
+		suite                    string
+		tests, failures, skipped int
+		firstCase                string
+	}{
+		{"godoc", 2, 1, 1, "svc/a.go:3"},
+		{"nildeps", 1, 0, 0, "nildeps"},
+		{"review", 1, 0, 1, "main.go:1"},
+	}
+	if len(doc.Suites) != len(tests) {
+		t.Fatalf("got %d suites, want %d", len(doc.Suites), len(tests))
+	}
+	for i, tt := range tests {
+		s := doc.Suites[i]
+		if s.Name != tt.suite || s.Tests != tt.tests || s.Failures != tt.failures || s.Skipped != tt.skipped || s.Cases[0].Name != tt.firstCase {
+			t.Errorf("suite %d = %s with %d tests, %d failures, %d skipped, first %q; want %s with %d, %d, %d, first %q",
+				i, s.Name, s.Tests, s.Failures, s.Skipped, s.Cases[0].Name, tt.suite, tt.tests, tt.failures, tt.skipped, tt.firstCase)
+		}
+	}
+	if f := doc.Suites[0].Cases[0].Failure; f == nil || f.Type != "godoc" || f.Message != testFindings[0].Message {
+		t.Errorf("failure = %+v, want the godoc finding", f)
+	}
+}
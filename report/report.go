@@ -0,0 +1,112 @@
+// Package report defines the findings model shared by the static analyzers
+// and the AI review, and the emitters that serialise it.
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// Severity is how much a finding matters. Only errors fail the build.
+type Severity string
+
+const (
+	SeverityError Severity = "error"
+	SeverityWarn  Severity = "warn"
+	SeverityInfo  Severity = "info"
+)
+
+// Finding is a single standards violation at a source location.
+type Finding struct {
+	Rule    string `json:"rule"`
+	File    string `json:"file"` // slash-separated, relative to the repository root where possible
+	Line    int    `json:"line"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+
+	// Severity is empty for sources that don't set one, which counts as
+	// SeverityError.
+	Severity Severity `json:"severity,omitempty"`
+
+	// Fingerprint identifies the finding across runs independently of its
+	// line number. See Fingerprint.
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// String formats the finding as file:line:col: message (rule), the
+// message prefixed with the severity unless the finding fails the build.
+func (f Finding) String() string {
+	if !f.Failing() {
+		return fmt.Sprintf("%s:%d:%d: %s: %s (%s)", f.File, f.Line, f.Column, f.Severity, f.Message, f.Rule)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", f.File, f.Line, f.Column, f.Message, f.Rule)
+}
+
+// Failing reports whether the finding fails the build.
+func (f Finding) Failing() bool {
+	return f.Severity == "" || f.Severity == SeverityError
+}
+
+// Package returns the slash-separated directory of the finding's file, which
+// identifies its Go package.
+func (f Finding) Package() string {
+	return path.Dir(f.File)
+}
+
+// Suppressed is a finding silenced by an inline suppression directive,
+// together with the justification given for it.
+type Suppressed struct {
+	Finding
+	Reason string `json:"reason"`
+}
+
+// Rule describes a rule that can produce findings.
+type Rule struct {
+	ID      string `json:"id"`
+	Summary string `json:"summary"` // one line
+	Help    string `json:"help"`    // full explanation, drawn from the standards docs
+}
+
+// Reporter writes findings in a particular output format. rules describes
+// every rule that was run, including those without findings.
+type Reporter interface {
+	Report(w io.Writer, rules []Rule, findings []Finding) error
+}
+
+// Sort orders findings by file, line, column and rule.
+func Sort(fs []Finding) {
+	sort.Slice(fs, func(i, j int) bool {
+		a, b := fs[i], fs[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return a.Rule < b.Rule
+	})
+}
+
+// Fingerprint sets the Fingerprint of every finding in fs, which must already
+// be sorted. The fingerprint hashes the rule, file and message, plus the
+// finding's ordinal among identical (rule, file, message) findings, so it
+// survives edits that only move code up or down the file.
+func Fingerprint(fs []Finding) {
+	seen := make(map[string]int)
+	for i := range fs {
+		f := &fs[i]
+		key := f.Rule + "\x00" + f.File + "\x00" + f.Message
+		n := seen[key]
+		seen[key] = n + 1
+		sum := sha256.Sum256([]byte(key + "\x00" + strconv.Itoa(n)))
+		f.Fingerprint = hex.EncodeToString(sum[:16])
+	}
+}
@@ -0,0 +1,55 @@
+package report
+
+import "testing"
+
+// testRules and testFindings are the report every format is tested with: a
+// failing and a non-failing finding of one rule, a rule without findings,
+// and a finding of a rule not listed.
+var (
+	testRules = []Rule{
+		{ID: "godoc", Summary: "exported declarations have doc comments", Help: "Document them."},
+		{ID: "nildeps", Summary: "dependencies are not nil", Help: "Pass a no-op."},
+	}
+	testFindings = []Finding{
+		{Rule: "godoc", File: "svc/a.go", Line: 3, Column: 6, Message: "exported function A has no doc comment", Fingerprint: "f1"},
+		{Rule: "godoc", File: "svc/b.go", Line: 7, Column: 1, Message: "exported type <B> has no doc comment", Severity: SeverityWarn, Fingerprint: "f2"},
+		{Rule: "review", File: "main.go", Line: 1, Message: "looks off", Severity: SeverityInfo},
+	}
+)
+
+func TestFinding_String(t *testing.T) {
+	tests := []struct {
+		f    Finding
+		want string
+	}{
+		{testFindings[0], "svc/a.go:3:6: exported function A has no doc comment (godoc)"},
+		{testFindings[1], "svc/b.go:7:1: warn: exported type <B> has no doc comment (godoc)"},
+		{Finding{Rule: "x", File: "a.go", Line: 1, Message: "m", Severity: SeverityError}, "a.go:1:0: m (x)"},
+	}
+	for _, tt := range tests {
+		if got := tt.f.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestFingerprint_Moves(t *testing.T) {
+	fs := []Finding{
+		{Rule: "godoc", File: "a.go", Line: 3, Message: "m"},
+		{Rule: "godoc", File: "a.go", Line: 9, Message: "m"},
+	}
+	Fingerprint(fs)
+	if fs[0].Fingerprint == fs[1].Fingerprint {
+		t.Fatal("identical findings share a fingerprint")
+	}
+	moved := []Finding{
+		{Rule: "godoc", File: "a.go", Line: 5, Message: "m"},
+		{Rule: "godoc", File: "a.go", Line: 12, Message: "m"},
+	}
+	Fingerprint(moved)
+	for i := range fs {
+		if fs[i].Fingerprint != moved[i].Fingerprint {
+			t.Errorf("finding %d changed fingerprint when it moved", i)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package sarif writes findings as a SARIF 2.1.0 log, the format accepted by
+// GitHub Code Scanning.
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+const (
+	version = "2.1.0"
+	schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	// fingerprintKey versions the partial fingerprint so the algorithm in
+	// report.Fingerprint can change without matching stale alerts.
+	fingerprintKey = "stdcheck/v1"
+
+	// srcRoot is the base ID that artifact URIs are relative to.
+	srcRoot = "%SRCROOT%"
+)
+
+type log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []run  `json:"runs"`
+}
+
+type run struct {
+	Tool    tool     `json:"tool"`
+	Results []result `json:"results"`
+}
+
+type tool struct {
+	Driver driver `json:"driver"`
+}
+
+type driver struct {
+	Name  string `json:"name"`
+	Rules []rule `json:"rules"`
+}
+
+type rule struct {
+	ID               string  `json:"id"`
+	ShortDescription message `json:"shortDescription"`
+	FullDescription  message `json:"fullDescription"`
+	Help             message `json:"help"`
+}
+
+type message struct {
+	Text string `json:"text"`
+}
+
+type result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             message           `json:"message"`
+	Locations           []location        `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type location struct {
+	PhysicalLocation physicalLocation `json:"physicalLocation"`
+}
+
+type physicalLocation struct {
+	ArtifactLocation artifactLocation `json:"artifactLocation"`
+	Region           region           `json:"region"`
+}
+
+type artifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId"`
+}
+
+type region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// Write encodes findings as a single-run SARIF log produced by toolName.
+// Every finding's rule must appear in rules; findings for unknown rules are
+// still written, with a rule entry synthesised from the ID.
+func Write(w io.Writer, toolName string, rules []report.Rule, findings []report.Finding) error {
+	d := driver{Name: toolName, Rules: []rule{}}
+	index := make(map[string]int, len(rules))
+	add := func(r report.Rule) {
+		index[r.ID] = len(d.Rules)
+		d.Rules = append(d.Rules, rule{
+			ID:               r.ID,
+			ShortDescription: message{r.Summary},
+			FullDescription:  message{r.Help},
+			Help:             message{r.Help},
+		})
+	}
+	for _, r := range rules {
+		add(r)
+	}
+
+	results := make([]result, 0, len(findings))
+	for _, f := range findings {
+		i, ok := index[f.Rule]
+		if !ok {
+			add(report.Rule{ID: f.Rule, Summary: f.Rule, Help: f.Rule})
+			i = index[f.Rule]
+		}
+		res := result{
+			RuleID:    f.Rule,
+			RuleIndex: i,
+			Level:     level(f.Severity),
+			Message:   message{f.Message},
+			Locations: []location{{PhysicalLocation: physicalLocation{
+				ArtifactLocation: artifactLocation{URI: f.File, URIBaseID: srcRoot},
+				Region:           region{StartLine: f.Line, StartColumn: f.Column},
+			}}},
+		}
+		if f.Fingerprint != "" {
+			res.PartialFingerprints = map[string]string{fingerprintKey: f.Fingerprint}
+		}
+		results = append(results, res)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log{
+		Version: version,
+		Schema:  schema,
+		Runs:    []run{{Tool: tool{Driver: d}, Results: results}},
+	})
+}
+
+// Reporter adapts Write to the report.Reporter interface.
+type Reporter struct {
+	Tool string // driver name recorded in the log
+}
+
+// Report writes the findings to w as a SARIF log, as Write does.
+func (r Reporter) Report(w io.Writer, rules []report.Rule, findings []report.Finding) error {
+	return Write(w, r.Tool, rules, findings)
+}
+
+// level maps a finding severity to a SARIF result level.
+func level(s report.Severity) string {
+	switch s {
+	case report.SeverityWarn:
+		return "warning"
+	case report.SeverityInfo:
+		return "note"
+	}
+	return "error"
+}
@@ -0,0 +1,62 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+func TestReporter_Report(t *testing.T) {
+	rules := []report.Rule{{ID: "godoc", Summary: "documented", Help: "Document them."}}
+	findings := []report.Finding{
+		{Rule: "godoc", File: "svc/a.go", Line: 3, Column: 6, Message: "no doc", Fingerprint: "f1"},
+		{Rule: "review", File: "main.go", Line: 1, Message: "looks off", Severity: report.SeverityInfo},
+		{Rule: "godoc", File: "svc/b.go", Line: 7, Message: "no doc", Severity: report.SeverityWarn},
+	}
+	var buf bytes.Buffer
+	if err := (Reporter{Tool: "stdcheck"}).Report(&buf, rules, findings); err != nil {
+		t.Fatal(err)
+	}
+	var got log
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != version || got.Schema != schema || len(got.Runs) != 1 {
+		t.Fatalf("log = %+v, want one SARIF %s run", got, version)
+	}
+	run := got.Runs[0]
+	if run.Tool.Driver.Name != "stdcheck" {
+		t.Errorf("driver = %q, want stdcheck", run.Tool.Driver.Name)
+	}
+	var ids []string
+	for _, r := range run.Tool.Driver.Rules {
+		ids = append(ids, r.ID)
+	}
+	if want := []string{"godoc", "review"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("rules = %q, want %q, the unlisted rule synthesised", ids, want)
+	}
+
+	tests := []struct {
+		ruleIndex   int
+		level       string
+		uri         string
+		fingerprint string
+	}{
+		{0, "error", "svc/a.go", "f1"},
+		{1, "note", "main.go", ""},
+		{0, "warning", "svc/b.go", ""},
+	}
+	if len(run.Results) != len(tests) {
+		t.Fatalf("got %d results, want %d", len(run.Results), len(tests))
+	}
+	for i, tt := range tests {
+		r := run.Results[i]
+		loc := r.Locations[0].PhysicalLocation
+		if r.RuleIndex != tt.ruleIndex || r.Level != tt.level || loc.ArtifactLocation.URI != tt.uri || loc.ArtifactLocation.URIBaseID != srcRoot || r.PartialFingerprints[fingerprintKey] != tt.fingerprint {
+			t.Errorf("result %d = %+v, want rule %d, level %s, uri %s, fingerprint %q", i, r, tt.ruleIndex, tt.level, tt.uri, tt.fingerprint)
+		}
+	}
+}
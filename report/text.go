@@ -0,0 +1,21 @@
+package report
+
+import (
+	"fmt"
+	"io"
+)
+
+// Text reports one finding per line in the file:line:col format understood
+// by editors and CI log parsers.
+type Text struct{}
+
+// Report writes each finding to w on a line of its own. The rules are not
+// written.
+func (Text) Report(w io.Writer, _ []Rule, findings []Finding) error {
+	for _, f := range findings {
+		if _, err := fmt.Fprintln(w, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package report
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestText_Report(t *testing.T) {
+	tests := []struct {
+		name     string
+		findings []Finding
+		want     string
+	}{
+		{"none", nil, ""},
+		{"findings", testFindings, "svc/a.go:3:6: exported function A has no doc comment (godoc)\n" +
+			"svc/b.go:7:1: warn: exported type <B> has no doc comment (godoc)\n" +
+			"main.go:1:0: info: looks off (review)\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := (Text{}).Report(&buf, testRules, tt.findings); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Report() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
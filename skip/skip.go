@@ -0,0 +1,101 @@
+// Package skip decides which source files are left out of a check, so that
+// vendored, third-party and oversized files are excluded the same way by
+// every consumer.
+package skip
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultDirs are the directories skipped by default: code the project does
+// not own.
+var DefaultDirs = []string{"vendor", "third_party"}
+
+// DefaultMaxKB is the default size limit. Larger files are almost always
+// generated or data, and are expensive to review.
+const DefaultMaxKB = 512
+
+// Policy describes the files to skip.
+type Policy struct {
+	// Dirs are directory names skipped wherever they occur in a path.
+	Dirs []string
+	// MaxKB is the largest file size checked, in KiB. Zero means no limit.
+	MaxKB int64
+	// Filter, if set, returns a reason to skip the file at rel, or "".
+	// It lets project configuration exclude further paths.
+	Filter func(rel string) string
+}
+
+// Default returns the default policy.
+func Default() Policy {
+	return Policy{Dirs: DefaultDirs, MaxKB: DefaultMaxKB}
+}
+
+// Item is a skipped file and why it was skipped.
+type Item struct {
+	Path   string // slash-separated, relative to the repository root where possible
+	Reason string
+}
+
+// String formats the item as the path followed by the reason in
+// parentheses.
+func (it Item) String() string {
+	return fmt.Sprintf("%s (%s)", it.Path, it.Reason)
+}
+
+// Reason returns why the file at rel, of the given size in bytes, is
+// skipped, or "" if it is checked. rel is slash-separated and relative to
+// the repository root.
+func (p Policy) Reason(rel string, size int64) string {
+	for _, dir := range strings.Split(path.Dir(rel), "/") {
+		for _, d := range p.Dirs {
+			if dir == d {
+				return "in " + d + "/"
+			}
+		}
+	}
+	if p.MaxKB > 0 && size > p.MaxKB*1024 {
+		return fmt.Sprintf("%d KB exceeds %d KB limit", (size+1023)/1024, p.MaxKB)
+	}
+	if p.Filter != nil {
+		return p.Filter(rel)
+	}
+	return ""
+}
+
+// Files applies the policy to the named files, reporting paths relative to
+// root. It returns the set of skipped names, as given, and the skipped items
+// sorted by path.
+func (p Policy) Files(root string, names []string) (map[string]bool, []Item, error) {
+	skipped := make(map[string]bool)
+	var items []Item
+	for _, name := range names {
+		if skipped[name] {
+			continue
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		rel := relative(root, name)
+		if reason := p.Reason(rel, info.Size()); reason != "" {
+			skipped[name] = true
+			items = append(items, Item{Path: rel, Reason: reason})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Path < items[j].Path })
+	return skipped, items, nil
+}
+
+func relative(root, name string) string {
+	rel, err := filepath.Rel(root, name)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(name)
+	}
+	return filepath.ToSlash(rel)
+}
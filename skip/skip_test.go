@@ -0,0 +1,77 @@
+package skip
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Reason(t *testing.T) {
+	p := Policy{
+		Dirs:  []string{"vendor", "third_party"},
+		MaxKB: 1,
+		Filter: func(rel string) string {
+			if strings.HasSuffix(rel, ".pb.go") {
+				return "generated"
+			}
+			return ""
+		},
+	}
+	tests := []struct {
+		rel  string
+		size int64
+		want string
+	}{
+		{"main.go", 100, ""},
+		{"vendor/x/x.go", 100, "in vendor/"},
+		{"a/third_party/b/b.go", 100, "in third_party/"},
+		{"vendored/v.go", 100, ""},
+		{"vendor.go", 100, ""},
+		{"big.go", 1024, ""},
+		{"big.go", 1025, "2 KB exceeds 1 KB limit"},
+		{"api/api.pb.go", 100, "generated"},
+	}
+	for _, tt := range tests {
+		if got := p.Reason(tt.rel, tt.size); got != tt.want {
+			t.Errorf("Reason(%q, %d) = %q, want %q", tt.rel, tt.size, got, tt.want)
+		}
+	}
+	if got := (Policy{}).Reason("vendor/big.go", 1<<30); got != "" {
+		t.Errorf("the zero Policy skips vendor/big.go: %s", got)
+	}
+}
+
+func TestPolicy_Files(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel string, size int) string {
+		name := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+	small := write("a.go", 10)
+	vendored := write("vendor/v/v.go", 10)
+	big := write("big.go", 2048)
+
+	skipped, items, err := Policy{Dirs: DefaultDirs, MaxKB: 1}.Files(root, []string{small, vendored, big, vendored})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{vendored: true, big: true}; !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped = %v, want %v", skipped, want)
+	}
+	want := []Item{{Path: "big.go", Reason: "2 KB exceeds 1 KB limit"}, {Path: "vendor/v/v.go", Reason: "in vendor/"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("items = %v, want %v", items, want)
+	}
+
+	if _, _, err := Default().Files(root, []string{filepath.Join(root, "missing.go")}); err == nil {
+		t.Error("Files() of a missing file succeeded, want an error")
+	}
+}
@@ -0,0 +1,84 @@
+// Package suppress implements inline suppression directives:
+//
+//	//stdignore:<rule> reason
+//
+// A directive silences findings of one rule on the line it is on and on the
+// following line, so it may trail the offending code or sit just above it.
+// The reason is mandatory; a directive without one suppresses nothing.
+package suppress
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+// Prefix introduces a directive.
+const Prefix = "//stdignore:"
+
+// Directive is an inline suppression of one rule on one source line.
+type Directive struct {
+	File   string `json:"file"` // as in report.Finding.File
+	Line   int    `json:"line"`
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// Valid reports whether d names a rule and gives a reason.
+func (d Directive) Valid() bool {
+	return d.Rule != "" && d.Reason != ""
+}
+
+// Parse returns the directives in f, whose path as reported in findings is
+// rel.
+func Parse(fset *token.FileSet, f *ast.File, rel string) []Directive {
+	var ds []Directive
+	for _, cg := range f.Comments {
+		for _, c := range cg.List {
+			text, ok := strings.CutPrefix(c.Text, Prefix)
+			if !ok {
+				continue
+			}
+			rule, reason, _ := strings.Cut(text, " ")
+			ds = append(ds, Directive{
+				File:   rel,
+				Line:   fset.Position(c.Slash).Line,
+				Rule:   strings.TrimSpace(rule),
+				Reason: strings.TrimSpace(reason),
+			})
+		}
+	}
+	return ds
+}
+
+// key identifies the findings a directive may suppress.
+type key struct {
+	file string
+	line int
+	rule string
+}
+
+// Apply removes the findings silenced by ds. A directive without a reason
+// does not suppress anything and is returned as invalid, so every
+// suppression stays justified.
+func Apply(findings []report.Finding, ds []Directive) (kept []report.Finding, suppressed []report.Suppressed, invalid []Directive) {
+	reasons := make(map[key]string)
+	for _, d := range ds {
+		if !d.Valid() {
+			invalid = append(invalid, d)
+			continue
+		}
+		reasons[key{d.File, d.Line, d.Rule}] = d.Reason
+		reasons[key{d.File, d.Line + 1, d.Rule}] = d.Reason
+	}
+	for _, f := range findings {
+		if reason, ok := reasons[key{f.File, f.Line, f.Rule}]; ok {
+			suppressed = append(suppressed, report.Suppressed{Finding: f, Reason: reason})
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	return kept, suppressed, invalid
+}
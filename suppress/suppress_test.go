@@ -0,0 +1,72 @@
+package suppress
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+
+	"github.com/benjaminabbitt/ai_assisted_requirements_workflow/report"
+)
+
+func TestParse_Directives(t *testing.T) {
+	const src = `package p
+
+//stdignore:godoc generated code
+func A() {}
+
+func B() {} //stdignore:factorylogic  wiring only, reviewed 
+//stdignore:nildeps
+// stdignore:godoc not a directive
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Directive{
+		{File: "p/p.go", Line: 3, Rule: "godoc", Reason: "generated code"},
+		{File: "p/p.go", Line: 6, Rule: "factorylogic", Reason: "wiring only, reviewed"},
+		{File: "p/p.go", Line: 7, Rule: "nildeps"},
+	}
+	if got := Parse(fset, f, "p/p.go"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestApply_Directives(t *testing.T) {
+	directives := []Directive{
+		{File: "a.go", Line: 10, Rule: "godoc", Reason: "generated"},
+		{File: "a.go", Line: 20, Rule: "nildeps"},
+	}
+	tests := []struct {
+		name       string
+		finding    report.Finding
+		suppressed bool
+	}{
+		{"same line", report.Finding{File: "a.go", Line: 10, Rule: "godoc"}, true},
+		{"next line", report.Finding{File: "a.go", Line: 11, Rule: "godoc"}, true},
+		{"line before", report.Finding{File: "a.go", Line: 9, Rule: "godoc"}, false},
+		{"two lines after", report.Finding{File: "a.go", Line: 12, Rule: "godoc"}, false},
+		{"other rule", report.Finding{File: "a.go", Line: 10, Rule: "factorylogic"}, false},
+		{"other file", report.Finding{File: "b.go", Line: 10, Rule: "godoc"}, false},
+		{"no reason", report.Finding{File: "a.go", Line: 20, Rule: "nildeps"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, suppressed, invalid := Apply([]report.Finding{tt.finding}, directives)
+			if got := len(suppressed) == 1; got != tt.suppressed {
+				t.Errorf("suppressed = %v, want %v (kept %v)", got, tt.suppressed, kept)
+			}
+			if tt.suppressed && suppressed[0].Reason != "generated" {
+				t.Errorf("reason = %q, want %q", suppressed[0].Reason, "generated")
+			}
+			if len(kept)+len(suppressed) != 1 {
+				t.Errorf("kept %v and suppressed %v, want the finding in one of them", kept, suppressed)
+			}
+			if len(invalid) != 1 || invalid[0].Line != 20 {
+				t.Errorf("invalid = %+v, want the directive without a reason", invalid)
+			}
+		})
+	}
+}